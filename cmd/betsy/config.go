@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/transeptorlabs/betsy/internal/config"
+	"github.com/transeptorlabs/betsy/internal/docker"
+	"github.com/urfave/cli/v2"
+)
+
+// loadConfig loads the config file passed with --config or discovered in the project directory and applies the flag and env var overrides
+func loadConfig(cCtx *cli.Context) (*config.Config, error) {
+	path := cCtx.String("config")
+	if path == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+
+		path, err = config.Discover(wd)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Flags and env vars take precedence over the config file
+	if cCtx.IsSet("runtime") {
+		cfg.Runtime = cCtx.String("runtime")
+	}
+	if cCtx.IsSet("log.level") {
+		cfg.Log.Level = cCtx.String("log.level")
+	}
+	if cCtx.IsSet("log.persist.dir") {
+		cfg.Log.PersistDir = cCtx.String("log.persist.dir")
+	}
+	if cCtx.IsSet("debug") {
+		cfg.HTTP.Debug = cCtx.Bool("debug")
+	}
+	if cCtx.IsSet("http.port") {
+		cfg.HTTP.Port = cCtx.Uint("http.port")
+	}
+	if cCtx.IsSet("pull-policy") {
+		cfg.Images.PullPolicy = cCtx.String("pull-policy")
+	}
+	if cCtx.IsSet("max-restarts") {
+		cfg.Restart.MaxRestarts = cCtx.Int("max-restarts")
+	}
+	if cCtx.IsSet("eth.client") {
+		cfg.Eth.Client = cCtx.String("eth.client")
+	}
+	if cCtx.IsSet("persist") {
+		cfg.Eth.Persist = cCtx.String("persist")
+	}
+	if cCtx.IsSet("block-time") {
+		cfg.Eth.Mining.BlockTime = cCtx.Duration("block-time")
+		cfg.Eth.Mining.Mode = docker.MiningInterval
+	}
+	if cCtx.IsSet("mining") {
+		cfg.Eth.Mining.Mode = cCtx.String("mining")
+	}
+	if cCtx.IsSet("eth.port") {
+		cfg.Eth.Port = cCtx.Uint("eth.port")
+	}
+	if cCtx.IsSet("bundler") {
+		cfg.Bundler.Name = cCtx.String("bundler")
+	}
+	if cCtx.IsSet("bundlers") {
+		cfg.Bundler.Count = cCtx.Int("bundlers")
+	}
+	if cCtx.IsSet("bundler.port") {
+		cfg.Bundler.Port = cCtx.Uint("bundler.port")
+	}
+	if cCtx.IsSet("bundler.bundle-interval") {
+		cfg.Bundler.BundleInterval = cCtx.Duration("bundler.bundle-interval")
+	}
+	if cCtx.IsSet("bundler.bundling") {
+		cfg.Bundler.Bundling = cCtx.String("bundler.bundling")
+	}
+	if cCtx.IsSet("bundler.safe") {
+		cfg.Bundler.Safe = cCtx.Bool("bundler.safe")
+	}
+	if cCtx.IsSet("bundler.tx-mode") {
+		cfg.Bundler.TxMode = cCtx.String("bundler.tx-mode")
+	}
+	if cCtx.IsSet("bundler.min-stake") {
+		cfg.Bundler.MinStake = cCtx.String("bundler.min-stake")
+	}
+	if cCtx.IsSet("bundler.max-bundle-gas") {
+		cfg.Bundler.MaxBundleGas = cCtx.Uint64("bundler.max-bundle-gas")
+	}
+	if cCtx.IsSet("accounts.mnemonic") {
+		cfg.Accounts.Mnemonic = cCtx.String("accounts.mnemonic")
+	}
+	if cCtx.IsSet("accounts.count") {
+		cfg.Accounts.Count = cCtx.Int("accounts.count")
+	}
+	if cCtx.IsSet("accounts.path") {
+		cfg.Accounts.Path = cCtx.String("accounts.path")
+	}
+	if cCtx.IsSet("accounts.balance") {
+		cfg.Accounts.Balance = cCtx.String("accounts.balance")
+	}
+	if cCtx.IsSet("accounts.balances") {
+		balances, err := parseAccountBalances(cCtx.StringSlice("accounts.balances"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid flags: %w", err)
+		}
+		cfg.Accounts.Balances = balances
+	}
+	if cCtx.IsSet("smart-accounts") {
+		cfg.SmartAccounts.Enabled = cCtx.Bool("smart-accounts")
+	}
+	if cCtx.IsSet("smart-accounts.deploy") {
+		cfg.SmartAccounts.Deploy = cCtx.Bool("smart-accounts.deploy")
+		cfg.SmartAccounts.Enabled = true
+	}
+	if cCtx.IsSet("smart-accounts.salt") {
+		cfg.SmartAccounts.Salt = cCtx.Uint64("smart-accounts.salt")
+	}
+	if cCtx.IsSet("smart-accounts.balance") {
+		cfg.SmartAccounts.Balance = cCtx.String("smart-accounts.balance")
+		cfg.SmartAccounts.Enabled = true
+	}
+	if cCtx.IsSet("smart-accounts.deposit") {
+		cfg.SmartAccounts.Deposit = cCtx.String("smart-accounts.deposit")
+		cfg.SmartAccounts.Enabled = true
+	}
+	if cCtx.IsSet("predeploy.layout") {
+		cfg.PreDeploy.Layout = cCtx.String("predeploy.layout")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid flags: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// parseAccountBalances parses the index=ether balances of the --accounts.balances flag
+func parseAccountBalances(values []string) (map[int]string, error) {
+	balances := make(map[int]string, len(values))
+	for _, value := range values {
+		index, amount, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("accounts.balances: %q must be index=ether", value)
+		}
+
+		i, err := strconv.Atoi(strings.TrimSpace(index))
+		if err != nil {
+			return nil, fmt.Errorf("accounts.balances: %q has an invalid account index", value)
+		}
+		balances[i] = strings.TrimSpace(amount)
+	}
+
+	return balances, nil
+}
+
+// inheritFlags applies the flags set before a command that declares them too, e.g. betsy --bundler alto up, its own defaults would shadow them otherwise
+func inheritFlags(cCtx *cli.Context) error {
+	for _, flag := range cCtx.Command.Flags {
+		name := flag.Names()[0]
+		if cCtx.IsSet(name) {
+			continue
+		}
+
+		for _, parent := range cCtx.Lineage()[1:] {
+			if !parent.IsSet(name) {
+				continue
+			}
+
+			if _, ok := flag.(*cli.StringSliceFlag); ok {
+				for _, value := range parent.StringSlice(name) {
+					if err := cCtx.Set(name, value); err != nil {
+						return err
+					}
+				}
+			} else if err := cCtx.Set(name, fmt.Sprint(parent.Value(name))); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/transeptorlabs/betsy/internal/config"
+	"github.com/urfave/cli/v2"
+)
+
+// runUp runs the up command with args and returns the config it loads
+func runUp(t *testing.T, args ...string) *config.Config {
+	t.Helper()
+
+	var cfg *config.Config
+	up := upCommand(nil)
+	up.Action = func(cCtx *cli.Context) error {
+		var err error
+		cfg, err = loadConfig(cCtx)
+		return err
+	}
+
+	app := &cli.App{
+		Name:     "betsy",
+		Flags:    environmentFlags([]string{"d"}),
+		Commands: []*cli.Command{up},
+	}
+	if err := app.Run(append([]string{"betsy"}, args...)); err != nil {
+		t.Fatalf("betsy %v error = %v", args, err)
+	}
+
+	return cfg
+}
+
+func TestUpInheritsRootFlags(t *testing.T) {
+	cfg := runUp(t, "--bundler", "alto", "--accounts.balances", "1=2", "--block-time", "5s", "up")
+	if cfg.Bundler.Name != "alto" {
+		t.Errorf("bundler = %q, want the alto bundler set before up", cfg.Bundler.Name)
+	}
+	if cfg.Accounts.Balances[1] != "2" {
+		t.Errorf("accounts.balances = %v, want account 1 set before up", cfg.Accounts.Balances)
+	}
+	if cfg.Eth.Mining.BlockTime.String() != "5s" {
+		t.Errorf("block time = %s, want 5s set before up", cfg.Eth.Mining.BlockTime)
+	}
+
+	cfg = runUp(t, "--bundler", "alto", "up", "--bundler", "rundler")
+	if cfg.Bundler.Name != "rundler" {
+		t.Errorf("bundler = %q, want the rundler bundler set on up", cfg.Bundler.Name)
+	}
+
+	cfg = runUp(t, "up")
+	if cfg.Bundler.Name != "transeptor" {
+		t.Errorf("bundler = %q, want the default bundler", cfg.Bundler.Name)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "syscall"
+
+// detachedSysProcAttr returns the default process attributes on platforms without sessions
+func detachedSysProcAttr() *syscall.SysProcAttr {
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/transeptorlabs/betsy/internal/compose"
+	"github.com/transeptorlabs/betsy/internal/docker"
+	"github.com/transeptorlabs/betsy/internal/session"
+	"github.com/urfave/cli/v2"
+)
+
+// exportCommand exports the running environment so it can be reproduced without Betsy
+func exportCommand(containerManager *docker.ContainerManager) *cli.Command {
+	return &cli.Command{
+		Name:  "export",
+		Usage: "Export the running environment",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "compose",
+				Usage: "Render the running environment as a docker-compose project, with a one-shot service funding the accounts and deploying the contracts",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Value:   "betsy-compose",
+						Usage:   "Directory the compose project is written to",
+					},
+				},
+				Action: func(cCtx *cli.Context) error {
+					initCommandLogger(cCtx)
+
+					state, err := session.Load(cCtx.String("state.file"))
+					if err != nil {
+						return cli.Exit(err.Error(), 1)
+					}
+
+					if !state.IsProcessAlive() {
+						return cli.Exit(fmt.Sprintf("Betsy session %s is not running, start it with betsy up", state.SessionID), 1)
+					}
+
+					if len(state.Accounts) == 0 {
+						return cli.Exit(fmt.Sprintf("Betsy session %s has no dev accounts to fund", state.SessionID), 1)
+					}
+
+					fundedAccounts, balances, err := sessionFunding(state)
+					if err != nil {
+						return cli.Exit(err.Error(), 1)
+					}
+
+					initImage, err := containerManager.ImageNames([]string{"anvil"})
+					if err != nil {
+						return err
+					}
+
+					containers, err := containerManager.ExportContainers(cCtx.Context, state)
+					if err != nil {
+						return err
+					}
+
+					dir := cCtx.String("output")
+					if err := compose.Write(dir, compose.Options{
+						SessionID:             state.SessionID,
+						Containers:            containers,
+						InitImage:             initImage[0],
+						FundedAccounts:        fundedAccounts,
+						Balance:               new(big.Int), // every funded account has its balance
+						Balances:              balances,
+						DeployerPrivateKeyHex: state.Accounts[0].PrivateKeyHex,
+						PreDeployedContracts:  state.PreDeployedContracts,
+						SmartAccounts:         state.SmartAccounts,
+					}); err != nil {
+						return err
+					}
+
+					composeFile := filepath.Join(dir, compose.FileName)
+					fmt.Fprintf(cCtx.App.Writer, "Exported session %s to %s\n", state.SessionID, composeFile)
+					fmt.Fprintf(cCtx.App.Writer, "Stop betsy and run it with: docker compose -f %s up\n", composeFile)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// sessionFunding returns the accounts funded by the session in the order betsy funds them, the dev accounts and then the bundler signers that are not dev accounts, with the balances they received
+func sessionFunding(state *session.State) ([]common.Address, map[common.Address]*big.Int, error) {
+	fundedAccounts := make([]common.Address, 0, len(state.Accounts)+len(state.Bundlers))
+	balances := make(map[common.Address]*big.Int, len(state.Accounts)+len(state.Bundlers))
+	for _, account := range state.Accounts {
+		if account.Balance == nil {
+			return nil, nil, fmt.Errorf("Betsy session %s does not record the balance of %s, restart it to export it", state.SessionID, account.Address)
+		}
+		fundedAccounts = append(fundedAccounts, account.Address)
+		balances[account.Address] = account.Balance
+	}
+
+	for _, bundler := range state.Bundlers {
+		if slices.Contains(fundedAccounts, bundler.SignerAddress) {
+			continue
+		}
+		if bundler.SignerBalance == nil {
+			return nil, nil, fmt.Errorf("Betsy session %s does not record the balance of %s, restart it to export it", state.SessionID, bundler.SignerAddress)
+		}
+		fundedAccounts = append(fundedAccounts, bundler.SignerAddress)
+		balances[bundler.SignerAddress] = bundler.SignerBalance
+	}
+
+	return fundedAccounts, balances, nil
+}
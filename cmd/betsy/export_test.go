@@ -0,0 +1,52 @@
+package main
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/transeptorlabs/betsy/internal/session"
+)
+
+func TestSessionFunding(t *testing.T) {
+	account0 := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	account1 := common.HexToAddress("0x0000000000000000000000000000000000000002")
+	signer := common.HexToAddress("0x0000000000000000000000000000000000000042")
+
+	state := &session.State{
+		SessionID: "running",
+		Accounts: []session.Account{
+			{Address: account0, Balance: big.NewInt(1000)},
+			{Address: account1, Balance: big.NewInt(0)},
+		},
+		Bundlers: []session.Bundler{
+			{Name: "transeptor", SignerAddress: account0, SignerBalance: big.NewInt(1000)},
+			{Name: "transeptor-2", SignerAddress: signer, SignerBalance: big.NewInt(5)},
+		},
+	}
+
+	// The balances come from the session, the bundler signing with dev account 0 is funded once
+	fundedAccounts, balances, err := sessionFunding(state)
+	if err != nil {
+		t.Fatalf("sessionFunding() error = %v", err)
+	}
+	if !slices.Equal(fundedAccounts, []common.Address{account0, account1, signer}) {
+		t.Errorf("sessionFunding() accounts = %v, want the dev accounts then the bundler signer", fundedAccounts)
+	}
+	for address, want := range map[common.Address]int64{account0: 1000, account1: 0, signer: 5} {
+		if balances[address].Int64() != want {
+			t.Errorf("sessionFunding() balance of %s = %s, want %d", address, balances[address], want)
+		}
+	}
+
+	// A session started by an older betsy does not record its balances
+	state.Bundlers[1].SignerBalance = nil
+	if _, _, err := sessionFunding(state); err == nil {
+		t.Error("sessionFunding() without the signer balance succeeded")
+	}
+	state.Accounts[1].Balance = nil
+	if _, _, err := sessionFunding(state); err == nil {
+		t.Error("sessionFunding() without the account balance succeeded")
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/transeptorlabs/betsy/internal/client"
+	"github.com/transeptorlabs/betsy/internal/session"
+	"github.com/urfave/cli/v2"
+)
+
+// fundCommand sends ETH from the coinbase account of the running session to an address using the faucet of the dashboard server
+func fundCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "fund",
+		Usage:     "Send ETH to an address, e.g. a smart account or a paymaster, from the running session",
+		ArgsUsage: "<address> <amount in ETH>",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "deposit",
+				Usage: "Top up the EntryPoint deposit of the address instead of its balance",
+			},
+		},
+		Action: func(cCtx *cli.Context) error {
+			initCommandLogger(cCtx)
+
+			if cCtx.NArg() != 2 {
+				return cli.Exit("an address and an amount in ETH are required", 1)
+			}
+
+			state, err := session.Load(cCtx.String("state.file"))
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+
+			if !state.IsProcessAlive() {
+				return cli.Exit(fmt.Sprintf("Betsy session %s is not running, start it with betsy up", state.SessionID), 1)
+			}
+
+			address := cCtx.Args().Get(0)
+			amount := cCtx.Args().Get(1)
+			deposit := cCtx.Bool("deposit")
+
+			result, err := client.NewDashboardClient(state.DashboardServerUrl).Faucet(cCtx.Context, address, amount, deposit)
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+
+			if result.Pending {
+				fmt.Fprintf(cCtx.App.Writer, "Sent %s ETH to %s (tx %s), pending until the next block is mined\n", amount, address, result.TxHash.Hex())
+				return nil
+			}
+
+			if deposit {
+				fmt.Fprintf(cCtx.App.Writer, "Deposited %s ETH to the EntryPoint for %s (tx %s), deposit is now %s wei\n", amount, address, result.TxHash.Hex(), result.Balance)
+				return nil
+			}
+
+			fmt.Fprintf(cCtx.App.Writer, "Funded %s with %s ETH (tx %s), balance is now %s wei\n", address, amount, result.TxHash.Hex(), result.Balance)
+			return nil
+		},
+	}
+}
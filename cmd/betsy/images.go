@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/transeptorlabs/betsy/internal/docker"
+	"github.com/urfave/cli/v2"
+)
+
+const defaultImagesFile = "betsy-images.tar"
+
+// imagesCommand exports and imports the container images used by Betsy for offline environments
+func imagesCommand(containerManager *docker.ContainerManager) *cli.Command {
+	return &cli.Command{
+		Name:  "images",
+		Usage: "Save and load the container images used by Betsy for air-gapped environments",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "save",
+				Usage: "Pull the required images and export them to a tarball",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "output",
+						Usage:   "Path of the tarball to write",
+						Aliases: []string{"o"},
+						Value:   defaultImagesFile,
+					},
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "Save the images of every supported execution client and bundler instead of the configured ones",
+					},
+				},
+				Action: func(cCtx *cli.Context) error {
+					cfg, err := prepare(cCtx)
+					if err != nil {
+						return err
+					}
+
+					requiredImages, err := configureImages(cfg, containerManager)
+					if err != nil {
+						return err
+					}
+					if cCtx.Bool("all") {
+						requiredImages = append(docker.SupportedExecutionClients(), docker.SupportedBundlers()...)
+					}
+
+					if !containerManager.IsDockerInstalled(cCtx.Context) {
+						return cli.Exit("Docker needs to be installed and its daemon reachable (see DOCKER_HOST) to use Betsy!", 1)
+					}
+
+					if _, err := containerManager.PullRequiredImages(cCtx.Context, requiredImages, cfg.Images.PullPolicy); err != nil {
+						return err
+					}
+
+					output := cCtx.String("output")
+					file, err := os.Create(output)
+					if err != nil {
+						return err
+					}
+					defer file.Close()
+
+					if err := containerManager.SaveImages(cCtx.Context, requiredImages, file); err != nil {
+						os.Remove(output)
+						return err
+					}
+
+					imageNames, err := containerManager.ImageNames(requiredImages)
+					if err != nil {
+						return err
+					}
+					sort.Strings(imageNames)
+
+					fmt.Fprintf(cCtx.App.Writer, "Saved %d images to %s:\n  %s\n", len(imageNames), output, strings.Join(imageNames, "\n  "))
+					return nil
+				},
+			},
+			{
+				Name:      "load",
+				Usage:     "Load the images of a tarball created with betsy images save",
+				ArgsUsage: "[" + defaultImagesFile + "]",
+				Action: func(cCtx *cli.Context) error {
+					initCommandLogger(cCtx)
+
+					input := cCtx.Args().First()
+					if input == "" {
+						input = defaultImagesFile
+					}
+
+					file, err := os.Open(input)
+					if err != nil {
+						return err
+					}
+					defer file.Close()
+
+					if !containerManager.IsDockerInstalled(cCtx.Context) {
+						return cli.Exit("Docker needs to be installed and its daemon reachable (see DOCKER_HOST) to use Betsy!", 1)
+					}
+
+					return containerManager.LoadImages(cCtx.Context, file, cCtx.App.Writer)
+				},
+			},
+		},
+	}
+}
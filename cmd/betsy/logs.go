@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/transeptorlabs/betsy/internal/docker"
+	"github.com/transeptorlabs/betsy/internal/session"
+	"github.com/urfave/cli/v2"
+)
+
+// logsCommand streams the logs of a container of the running session
+func logsCommand(containerManager *docker.ContainerManager) *cli.Command {
+	return &cli.Command{
+		Name:      "logs",
+		Usage:     "Show the logs of a Betsy container",
+		ArgsUsage: "[geth|bundler|<container name>]",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    "follow",
+				Usage:   "Follow the log output",
+				Aliases: []string{"f"},
+			},
+			&cli.StringFlag{
+				Name:  "since",
+				Usage: "Show logs since a timestamp (e.g. 2024-06-01T10:00:00Z) or relative duration (e.g. 10m)",
+			},
+			&cli.IntFlag{
+				Name:  "tail",
+				Usage: "Number of lines to show from the end of the logs (0 for all)",
+				Value: 0,
+			},
+			&cli.BoolFlag{
+				Name:    "timestamps",
+				Usage:   "Show timestamps",
+				Aliases: []string{"t"},
+			},
+		},
+		Action: func(cCtx *cli.Context) error {
+			initCommandLogger(cCtx)
+
+			state, err := session.Load(cCtx.String("state.file"))
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+
+			component := cCtx.Args().First()
+			if component == "" {
+				component = "bundler"
+			}
+
+			sessionContainer, err := session.FindContainer(state.Containers, component)
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+
+			// Stop following the logs on ctrl-c
+			ctx, stop := signal.NotifyContext(cCtx.Context, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return containerManager.StreamLogs(
+				ctx,
+				sessionContainer.ContainerID,
+				docker.LogOptions{
+					Follow:     cCtx.Bool("follow"),
+					Since:      cCtx.String("since"),
+					Tail:       cCtx.Int("tail"),
+					Timestamps: cCtx.Bool("timestamps"),
+				},
+				os.Stdout,
+				os.Stderr,
+			)
+		},
+	}
+}
@@ -1,23 +1,15 @@
 package main
 
 import (
-	"context"
 	"fmt"
 	"html/template"
-	"net"
-	"net/http"
+	"math/big"
 	"os"
-	"os/signal"
-	"strconv"
-	"syscall"
-	"time"
+	"strings"
 
 	"github.com/rs/zerolog/log"
 	"github.com/transeptorlabs/betsy/internal/docker"
-	"github.com/transeptorlabs/betsy/internal/mempool"
-	"github.com/transeptorlabs/betsy/internal/server"
-	"github.com/transeptorlabs/betsy/internal/utils"
-	"github.com/transeptorlabs/betsy/logger"
+	"github.com/transeptorlabs/betsy/internal/session"
 	"github.com/transeptorlabs/betsy/version"
 	"github.com/transeptorlabs/betsy/wallet"
 	"github.com/urfave/cli/v2"
@@ -27,18 +19,23 @@ type NodeInfo struct {
 	EthNodeUrl           string
 	BundlerNodeUrl       string
 	DashboardServerUrl   string
+	Bundlers             []session.Bundler
+	ChainID              *big.Int
+	GasLimit             uint64
+	BlockProduction      string
+	Mnemonic             string
+	DerivationPath       string
 	DevAccounts          []wallet.DevAccount
+	SmartAccounts        []wallet.SmartAccount
 	PreDeployedContracts wallet.PreDeployedContracts
 }
 
 func main() {
-	var err error
-	var betsyWallet *wallet.Wallet
-
 	containerManager, err := docker.NewContainerManager()
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize container manager")
 	}
+	defer containerManager.Close()
 
 	app := &cli.App{
 		Name:    "betsy",
@@ -55,99 +52,17 @@ func main() {
 		EnableBashCompletion: true,
 		HideVersion:          false,
 		HideHelp:             false,
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:     "log.level",
-				Usage:    "Enable debug mode on server",
-				Aliases:  []string{"log"},
-				Value:    "INFO",
-				Required: false,
-				Category: "Logger selection:",
-			},
-			&cli.BoolFlag{
-				Name:     "debug",
-				Usage:    "Enable debug mode on server",
-				Aliases:  []string{"d"},
-				Value:    false,
-				Required: false,
-				Category: "Http server selection:",
-			},
-			&cli.UintFlag{
-				Name:     "http.port",
-				Usage:    "HTTP server listening port",
-				Required: false,
-				Value:    8080,
-				Category: "Http server selection:",
-			},
-			&cli.UintFlag{
-				Name:     "eth.port",
-				Usage:    "ETH client network port",
-				Required: false,
-				Value:    8545,
-				Category: "ETH client selection:",
-			},
-			&cli.StringFlag{
-				Name:     "bundler",
-				Usage:    "ERC 4337 bundler",
-				Required: false,
-				Value:    "transeptor",
-				Category: "ERC 4337 bundler selection:",
-			},
-			&cli.UintFlag{
-				Name:     "bundler.port",
-				Usage:    "ERC 4337 bundler listening port",
-				Required: false,
-				Value:    4337,
-				Category: "ERC 4337 bundler selection:",
-			},
-		},
-		Before: func(cCtx *cli.Context) error {
-			log.Logger, err = logger.GetLogger(cCtx.String("log.level"))
-			if err != nil {
-				log.Fatal().Err(err).Msg("Failed to initialize logger")
-			}
-
-			err = printWelcomeBanner()
-			if err != nil {
-				log.Fatal().Err(err).Msg("Failed to load welcome banner")
-			}
-
-			log.Debug().Msgf("Running preflight checks...")
-
-			// Check that docker is installed and pull required images
-			ok := containerManager.IsDockerInstalled()
-			if !ok {
-				log.Fatal().Err(err).Msg("Docker needs to be installed to use Betsy!")
-			}
-
-			_, err := containerManager.PullRequiredImages(
-				cCtx.Context,
-				[]string{"geth", cCtx.String("bundler")},
-			)
-			if err != nil {
-				log.Fatal().Err(err).Msg("Failed to pull required images")
-			}
-
-			return nil
-		},
-		After: func(cCtx *cli.Context) error {
-			log.Info().Msgf("Tearing down docker containers!\n")
-			_, err := containerManager.StopAndRemoveRunningContainers(cCtx.Context)
-			if err != nil {
-				log.Fatal().Err(err).Msg("Failed to tear down docker containers!")
-			}
-
-			containerManager.Close()
-			if err != nil {
-				log.Fatal().Err(err).Msg("Failed to close container manager")
-			}
-
-			err = utils.RemoveDevWallets()
-			if err != nil {
-				log.Fatal().Err(err).Msg("Failed to remove wallets")
-			}
-
-			return nil
+		Flags:                environmentFlags([]string{"d"}),
+		Commands: []*cli.Command{
+			upCommand(containerManager),
+			downCommand(containerManager),
+			statusCommand(containerManager),
+			logsCommand(containerManager),
+			pruneCommand(containerManager),
+			imagesCommand(containerManager),
+			snapshotCommand(containerManager),
+			exportCommand(containerManager),
+			fundCommand(),
 		},
 		CommandNotFound: func(cCtx *cli.Context, command string) {
 			fmt.Fprintf(cCtx.App.Writer, "Thar be no %q here.\n", command)
@@ -160,132 +75,11 @@ func main() {
 			log.Error().Err(err).Msgf("WRONG: %#v\n", err)
 			return nil
 		},
+		Before: func(cCtx *cli.Context) error {
+			return selectRuntime(cCtx, containerManager)
+		},
 		Action: func(cCtx *cli.Context) error {
-			// Create a context that will be canceled when an interrupt signal is caught
-			ctx, stop := signal.NotifyContext(cCtx.Context, os.Interrupt, syscall.SIGTERM)
-			defer stop()
-
-			// Wait until the eth node container is ready before starting the bundler
-			// Using a channel to signal when the container is ready
-			// and a channel to signal if there was an error
-			readyChan := make(chan struct{})
-			readyErrorChan := make(chan error)
-
-			ctxWithReadyChan := context.WithValue(ctx, docker.EthNodeReady, readyChan)
-
-			go func() {
-				_, err := containerManager.RunContainerInTheBackground(
-					ctxWithReadyChan,
-					"geth",
-					strconv.Itoa(cCtx.Int("eth.port")),
-				)
-				if err != nil {
-					readyErrorChan <- err
-				}
-			}()
-
-			/* Handle case where
-			- eth node container fails to start
-			- eth node container is ready
-			- context is canceled with an interrupt signal (ctrl-c)
-			*/
-			select {
-			case err := <-readyErrorChan:
-				log.Err(err).Msg("Failed to run ETH node container")
-				return nil
-			case <-readyChan:
-				log.Info().Msg("ETH node is ready, starting bundler and initializing dev wallet...")
-
-				// create dev wallet with default accounts
-				betsyWallet, err = wallet.NewWallet(
-					ctx,
-					strconv.Itoa(cCtx.Int("eth.port")),
-					containerManager.CoinbaseKeystoreFile,
-				)
-				if err != nil {
-					log.Err(err).Msg("Failed to create dev wallet")
-					return nil
-				}
-
-				// Start the bundler container passing a context with the wallet details
-				ctxWithBundlerDetails := context.WithValue(ctx, docker.BundlerNodeWalletDetails, betsyWallet.GetBundlerWalletDetails())
-
-				_, err = containerManager.RunContainerInTheBackground(
-					ctxWithBundlerDetails,
-					cCtx.String("bundler"),
-					strconv.Itoa(cCtx.Int("bundler.port")),
-				)
-				if err != nil {
-					log.Err(err).Msgf("Failed to run %s bundler container", cCtx.String("bundler"))
-					return nil
-				}
-			case <-ctx.Done():
-				log.Info().Msg("Received signal, shutting down...")
-				return nil
-			}
-
-			// create a start mempool polling
-			bundlerUrl := "http://localhost:" + strconv.Itoa(cCtx.Int("bundler.port")) + "/rpc"
-			mempool := mempool.NewUserOpMempool(
-				betsyWallet.GetBundlerWalletDetails().EntryPointAddress,
-				betsyWallet.GetEthClient(),
-				bundlerUrl,
-			)
-			go func() {
-				if err := mempool.Run(); err != nil {
-					log.Err(err).Msg("mempool failed")
-					stop()
-				}
-			}()
-
-			// create and start http server
-			httpServer := server.NewHTTPServer(
-				net.JoinHostPort("localhost", strconv.Itoa(cCtx.Int("http.port"))),
-				cCtx.Bool("debug"),
-				betsyWallet,
-				mempool,
-			)
-			go func() {
-				if err := httpServer.Run(); err != nil && err != http.ErrServerClosed {
-					log.Err(err).Msg("HTTP server failed")
-					stop()
-				}
-			}()
-
-			accounts, err := betsyWallet.GetDevAccounts(ctx)
-			if err != nil {
-				log.Err(err).Msg("Failed to get dev accounts")
-				stop()
-			}
-
-			prefix := "http://localhost:"
-			err = printBetsyInfo(NodeInfo{
-				EthNodeUrl:           prefix + strconv.Itoa(cCtx.Int("eth.port")),
-				BundlerNodeUrl:       bundlerUrl,
-				DashboardServerUrl:   prefix + strconv.Itoa(cCtx.Int("http.port")),
-				DevAccounts:          accounts,
-				PreDeployedContracts: betsyWallet.GetPreDeployedContracts(),
-			})
-			if err != nil {
-				log.Err(err).Msg("Failed print Betsy info")
-				stop()
-			}
-
-			<-ctx.Done()
-
-			// Create a context with timeout to allow the server to shut down gracefully
-			shutdownCtx, cancel := context.WithTimeout(cCtx.Context, 5*time.Second)
-			defer cancel()
-
-			mempool.Stop()
-
-			if err := httpServer.Shutdown(shutdownCtx); err != nil {
-				log.Err(err).Msg("Server shutdown failed")
-			} else {
-				log.Info().Msg("Server shutdown completed")
-			}
-
-			return nil
+			return runEnvironment(cCtx, containerManager)
 		},
 	}
 
@@ -294,6 +88,296 @@ func main() {
 	}
 }
 
+// environmentFlags returns the flags used to configure a Betsy environment
+func environmentFlags(debugAliases []string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:     "config",
+			Usage:    "Path to the Betsy config file (defaults to betsy.yaml in the current directory)",
+			Aliases:  []string{"c"},
+			EnvVars:  []string{"BETSY_CONFIG"},
+			Required: false,
+			Category: "Config selection:",
+		},
+		&cli.StringFlag{
+			Name:     "state.file",
+			Usage:    "Path to the session state file used by up, down and status",
+			EnvVars:  []string{"BETSY_STATE_FILE"},
+			Value:    session.DefaultStateFile,
+			Required: false,
+			Category: "Config selection:",
+		},
+		&cli.StringFlag{
+			Name:     "runtime",
+			Usage:    "Runtime running the nodes (" + strings.Join(docker.Runtimes(), ", ") + "), native runs the binaries installed on the host without Docker",
+			EnvVars:  []string{"BETSY_RUNTIME"},
+			Value:    docker.RuntimeDocker,
+			Required: false,
+			Category: "Config selection:",
+		},
+		&cli.BoolFlag{
+			Name:     "reap-orphans",
+			Usage:    "Remove containers and networks left behind by crashed Betsy sessions on start-up",
+			EnvVars:  []string{"BETSY_REAP_ORPHANS"},
+			Required: false,
+			Category: "Config selection:",
+		},
+		&cli.StringFlag{
+			Name:     "pull-policy",
+			Usage:    "When to pull the container images (" + strings.Join(docker.PullPolicies(), ", ") + ")",
+			EnvVars:  []string{"BETSY_PULL_POLICY"},
+			Value:    docker.PullMissing,
+			Required: false,
+			Category: "Config selection:",
+		},
+		&cli.StringFlag{
+			Name:     "log.level",
+			Usage:    "Enable debug mode on server",
+			Aliases:  []string{"log"},
+			EnvVars:  []string{"BETSY_LOG_LEVEL"},
+			Value:    "INFO",
+			Required: false,
+			Category: "Logger selection:",
+		},
+		&cli.StringFlag{
+			Name:     "log.persist.dir",
+			Usage:    "Directory where each container's logs are saved on shutdown (disabled when empty)",
+			EnvVars:  []string{"BETSY_LOG_PERSIST_DIR"},
+			Required: false,
+			Category: "Logger selection:",
+		},
+		&cli.BoolFlag{
+			Name:     "debug",
+			Usage:    "Enable debug mode on server",
+			Aliases:  debugAliases,
+			EnvVars:  []string{"BETSY_DEBUG"},
+			Value:    false,
+			Required: false,
+			Category: "Http server selection:",
+		},
+		&cli.IntFlag{
+			Name:     "max-restarts",
+			Usage:    "Number of times a crashed container is restarted with backoff, 0 disables restarts",
+			EnvVars:  []string{"BETSY_MAX_RESTARTS"},
+			Value:    5,
+			Required: false,
+			Category: "Config selection:",
+		},
+		&cli.BoolFlag{
+			Name:     "auto-ports",
+			Usage:    "Pick free host ports when the configured ports are already in use",
+			EnvVars:  []string{"BETSY_AUTO_PORTS"},
+			Required: false,
+			Category: "Config selection:",
+		},
+		&cli.UintFlag{
+			Name:     "http.port",
+			Usage:    "HTTP server listening port",
+			EnvVars:  []string{"BETSY_HTTP_PORT"},
+			Required: false,
+			Value:    8080,
+			Category: "Http server selection:",
+		},
+		&cli.StringFlag{
+			Name:     "eth.client",
+			Usage:    "ETH execution client (" + strings.Join(docker.SupportedExecutionClients(), ", ") + ")",
+			EnvVars:  []string{"BETSY_ETH_CLIENT"},
+			Required: false,
+			Value:    "geth",
+			Category: "ETH client selection:",
+		},
+		&cli.UintFlag{
+			Name:     "eth.port",
+			Usage:    "ETH client network port",
+			EnvVars:  []string{"BETSY_ETH_PORT"},
+			Required: false,
+			Value:    8545,
+			Category: "ETH client selection:",
+		},
+		&cli.StringFlag{
+			Name:     "persist",
+			Usage:    "Keep the chain state in the named Docker volume and resume it on the next start (geth only)",
+			EnvVars:  []string{"BETSY_PERSIST"},
+			Required: false,
+			Category: "ETH client selection:",
+		},
+		&cli.StringFlag{
+			Name:     "mining",
+			Usage:    "Block production mode (" + strings.Join(docker.MiningModes(), ", ") + "), manual mode is only supported by anvil and mines blocks with POST /api/mine",
+			EnvVars:  []string{"BETSY_MINING"},
+			Required: false,
+			Value:    docker.MiningAuto,
+			Category: "ETH client selection:",
+		},
+		&cli.DurationFlag{
+			Name:     "block-time",
+			Usage:    "Mine a block at this interval instead of one per transaction (e.g. 5s), implies --mining interval",
+			EnvVars:  []string{"BETSY_BLOCK_TIME"},
+			Required: false,
+			Category: "ETH client selection:",
+		},
+		&cli.StringFlag{
+			Name:     "bundler",
+			Usage:    "ERC 4337 bundler (" + strings.Join(docker.SupportedBundlers(), ", ") + ")",
+			EnvVars:  []string{"BETSY_BUNDLER"},
+			Required: false,
+			Value:    "transeptor",
+			Category: "ERC 4337 bundler selection:",
+		},
+		&cli.UintFlag{
+			Name:     "bundler.port",
+			Usage:    "ERC 4337 bundler listening port",
+			EnvVars:  []string{"BETSY_BUNDLER_PORT"},
+			Required: false,
+			Value:    4337,
+			Category: "ERC 4337 bundler selection:",
+		},
+		&cli.IntFlag{
+			Name:     "bundlers",
+			Usage:    "Number of bundler instances to run side by side, instance N listens on bundler.port + N - 1",
+			EnvVars:  []string{"BETSY_BUNDLERS"},
+			Required: false,
+			Value:    1,
+			Category: "ERC 4337 bundler selection:",
+		},
+		&cli.StringFlag{
+			Name:     "bundler.bundling",
+			Usage:    "Bundling mode (" + strings.Join(docker.BundlingModes(), ", ") + "), manual mode only sends bundles with debug_bundler_sendBundleNow",
+			EnvVars:  []string{"BETSY_BUNDLER_BUNDLING"},
+			Required: false,
+			Value:    docker.BundlingAuto,
+			Category: "ERC 4337 bundler selection:",
+		},
+		&cli.DurationFlag{
+			Name:     "bundler.bundle-interval",
+			Usage:    "Interval between bundles in auto bundling mode (e.g. 2s), defaults to the bundler default",
+			EnvVars:  []string{"BETSY_BUNDLER_BUNDLE_INTERVAL"},
+			Required: false,
+			Category: "ERC 4337 bundler selection:",
+		},
+		&cli.BoolFlag{
+			Name:     "bundler.safe",
+			Usage:    "Run the bundler in safe mode with full ERC-7562 validation (needs an eth client supporting debug_traceCall tracers)",
+			EnvVars:  []string{"BETSY_BUNDLER_SAFE"},
+			Required: false,
+			Category: "ERC 4337 bundler selection:",
+		},
+		&cli.StringFlag{
+			Name:     "bundler.tx-mode",
+			Usage:    "Transaction mode used to send bundles (transeptor: base, searcher, conditional)",
+			EnvVars:  []string{"BETSY_BUNDLER_TX_MODE"},
+			Required: false,
+			Category: "ERC 4337 bundler selection:",
+		},
+		&cli.StringFlag{
+			Name:     "bundler.min-stake",
+			Usage:    "Minimum stake in ether an entity needs to pass the reputation checks",
+			EnvVars:  []string{"BETSY_BUNDLER_MIN_STAKE"},
+			Required: false,
+			Category: "ERC 4337 bundler selection:",
+		},
+		&cli.Uint64Flag{
+			Name:     "bundler.max-bundle-gas",
+			Usage:    "Maximum gas of a bundle",
+			EnvVars:  []string{"BETSY_BUNDLER_MAX_BUNDLE_GAS"},
+			Required: false,
+			Category: "ERC 4337 bundler selection:",
+		},
+		&cli.StringFlag{
+			Name:     "accounts.mnemonic",
+			Usage:    "Mnemonic the dev accounts are derived from",
+			EnvVars:  []string{"BETSY_ACCOUNTS_MNEMONIC"},
+			Required: false,
+			Category: "Dev accounts selection:",
+		},
+		&cli.IntFlag{
+			Name:     "accounts.count",
+			Usage:    "Number of dev accounts",
+			EnvVars:  []string{"BETSY_ACCOUNTS_COUNT"},
+			Required: false,
+			Category: "Dev accounts selection:",
+		},
+		&cli.StringFlag{
+			Name:     "accounts.path",
+			Usage:    "Derivation path template of the dev accounts, {index} is replaced by the account index (default: " + wallet.DefaultDerivationPath + ")",
+			EnvVars:  []string{"BETSY_ACCOUNTS_PATH"},
+			Required: false,
+			Category: "Dev accounts selection:",
+		},
+		&cli.StringFlag{
+			Name:     "accounts.balance",
+			Usage:    "Balance in ether funded to each dev account",
+			EnvVars:  []string{"BETSY_ACCOUNTS_BALANCE"},
+			Required: false,
+			Category: "Dev accounts selection:",
+		},
+		&cli.StringSliceFlag{
+			Name:     "accounts.balances",
+			Usage:    "Balance in ether of a single dev account as index=ether (e.g. 0=100), 0 leaves the account unfunded",
+			EnvVars:  []string{"BETSY_ACCOUNTS_BALANCES"},
+			Required: false,
+			Category: "Dev accounts selection:",
+		},
+		&cli.BoolFlag{
+			Name:     "smart-accounts",
+			Usage:    "Compute the SimpleAccount smart account of every dev account",
+			EnvVars:  []string{"BETSY_SMART_ACCOUNTS"},
+			Required: false,
+			Category: "Dev accounts selection:",
+		},
+		&cli.BoolFlag{
+			Name:     "smart-accounts.deploy",
+			Usage:    "Deploy the smart accounts through the SimpleAccountFactory, implies --smart-accounts",
+			EnvVars:  []string{"BETSY_SMART_ACCOUNTS_DEPLOY"},
+			Required: false,
+			Category: "Dev accounts selection:",
+		},
+		&cli.Uint64Flag{
+			Name:     "smart-accounts.salt",
+			Usage:    "Salt of the smart accounts passed to the SimpleAccountFactory",
+			EnvVars:  []string{"BETSY_SMART_ACCOUNTS_SALT"},
+			Required: false,
+			Category: "Dev accounts selection:",
+		},
+		&cli.StringFlag{
+			Name:     "smart-accounts.balance",
+			Usage:    "Balance in ether funded to each smart account, implies --smart-accounts",
+			EnvVars:  []string{"BETSY_SMART_ACCOUNTS_BALANCE"},
+			Required: false,
+			Category: "Dev accounts selection:",
+		},
+		&cli.StringFlag{
+			Name:     "smart-accounts.deposit",
+			Usage:    "EntryPoint deposit in ether of each smart account, implies --smart-accounts",
+			EnvVars:  []string{"BETSY_SMART_ACCOUNTS_DEPOSIT"},
+			Required: false,
+			Category: "Dev accounts selection:",
+		},
+		&cli.StringFlag{
+			Name:     "predeploy.layout",
+			Usage:    "Addresses of the pre-deployed contracts (" + strings.Join(wallet.Layouts(), ", ") + "), canonical deploys them with CREATE2 at the addresses of the public networks",
+			EnvVars:  []string{"BETSY_PREDEPLOY_LAYOUT"},
+			Required: false,
+			Category: "Pre-deployed contracts selection:",
+		},
+	}
+}
+
+// selectRuntime switches the container manager to the runtime set with --runtime or, for the commands managing a session, to the runtime of the session
+func selectRuntime(cCtx *cli.Context, containerManager *docker.ContainerManager) error {
+	stateFile := cCtx.String("state.file")
+	name := cCtx.String("runtime")
+	if !cCtx.IsSet("runtime") {
+		state, err := session.Load(stateFile)
+		if err != nil || state.Runtime == "" {
+			return nil
+		}
+		name = state.Runtime
+	}
+
+	return containerManager.UseRuntime(name, session.NativeDir(stateFile))
+}
+
 // printWelcomeBanner prints the welcome banner to the console
 func printWelcomeBanner() error {
 	var tmplBannerFile = "ui/templates/banner.tmpl"
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/transeptorlabs/betsy/internal/config"
+	"github.com/transeptorlabs/betsy/internal/utils"
+)
+
+// checkPorts verifies that every host port of the environment is free before anything starts and returns the port of each bundler instance, with autoPorts busy ports are replaced by free ones
+func checkPorts(cfg *config.Config, autoPorts bool) ([]uint, error) {
+	type hostPort struct {
+		key  string
+		flag string
+		port *uint
+	}
+
+	bundlerPorts := make([]uint, cfg.Bundler.Count)
+	bundlerPorts[0] = cfg.Bundler.Port
+
+	ports := []hostPort{
+		{"eth.port", "eth.port", &cfg.Eth.Port},
+		{"bundler.port", "bundler.port", &bundlerPorts[0]},
+	}
+	for instance := 2; instance <= cfg.Bundler.Count; instance++ {
+		bundlerPorts[instance-1] = cfg.Bundler.Port + uint(instance) - 1
+		ports = append(ports, hostPort{fmt.Sprintf("bundler %d port", instance), "bundler.port", &bundlerPorts[instance-1]})
+	}
+	ports = append(ports, hostPort{"http.port", "http.port", &cfg.HTTP.Port})
+
+	used := make(map[uint]bool)
+	for _, item := range ports {
+		available := !used[*item.port] && utils.IsPortAvailable(*item.port)
+		if !available && !autoPorts {
+			return nil, fmt.Errorf("port %d (%s) is already in use, free it, choose another port with --%s or use --auto-ports", *item.port, item.key, item.flag)
+		}
+
+		if !available {
+			freePort, err := utils.FindFreePort(used)
+			if err != nil {
+				return nil, err
+			}
+			log.Warn().Msgf("Port %d (%s) is already in use, using port %d instead", *item.port, item.key, freePort)
+			*item.port = freePort
+		}
+
+		used[*item.port] = true
+	}
+
+	cfg.Bundler.Port = bundlerPorts[0]
+	return bundlerPorts, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+	"github.com/transeptorlabs/betsy/internal/docker"
+	"github.com/transeptorlabs/betsy/internal/session"
+	"github.com/urfave/cli/v2"
+)
+
+// pruneCommand removes every Betsy-owned Docker resource
+func pruneCommand(containerManager *docker.ContainerManager) *cli.Command {
+	return &cli.Command{
+		Name:  "prune",
+		Usage: "Remove all Betsy-owned containers, networks and volumes",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "Remove the resources even when a Betsy session is running",
+			},
+		},
+		Action: func(cCtx *cli.Context) error {
+			initCommandLogger(cCtx)
+
+			stateFile := cCtx.String("state.file")
+			if state, err := session.Load(stateFile); err == nil && state.IsProcessAlive() {
+				if !cCtx.Bool("force") {
+					return cli.Exit(fmt.Sprintf("Betsy session %s (pid %d) is still running, stop it with betsy down or remove its containers with betsy prune --force", state.SessionID, state.PID), 1)
+				}
+				log.Warn().Msgf("Betsy session %s (pid %d) is still running, its containers will be removed", state.SessionID, state.PID)
+			}
+
+			report, err := containerManager.Prune(cCtx.Context)
+			if err != nil {
+				return err
+			}
+
+			if err := session.Remove(stateFile); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cCtx.App.Writer, "Removed %d containers, %d networks and %d volumes\n", len(report.Containers), len(report.Networks), len(report.Volumes))
+			return nil
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/transeptorlabs/betsy/internal/docker"
+	"github.com/transeptorlabs/betsy/internal/docker/fakeruntime"
+	"github.com/transeptorlabs/betsy/internal/session"
+	"github.com/urfave/cli/v2"
+)
+
+// runCommand runs a session command against a fake runtime with the session state file
+func runCommand(t *testing.T, newCommand func(*docker.ContainerManager) *cli.Command, stateFile string, args ...string) error {
+	t.Helper()
+
+	containerManager, err := docker.NewContainerManagerWithRuntime(fakeruntime.New())
+	if err != nil {
+		t.Fatalf("NewContainerManagerWithRuntime() error = %v", err)
+	}
+
+	app := &cli.App{
+		Name:   "betsy",
+		Writer: io.Discard,
+		Flags: []cli.Flag{
+			&cli.StringFlag{Name: "log.level", Value: "ERROR"},
+			&cli.StringFlag{Name: "state.file", Value: stateFile},
+		},
+		Commands: []*cli.Command{newCommand(containerManager)},
+		// Keep cli.Exit errors from exiting the test binary
+		ExitErrHandler: func(cCtx *cli.Context, err error) {},
+	}
+
+	return app.Run(append([]string{"betsy"}, args...))
+}
+
+func TestPruneRefusesRunningSession(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "session.json")
+	if err := session.Save(stateFile, &session.State{PID: os.Getpid(), SessionID: "running"}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if err := runCommand(t, pruneCommand, stateFile, "prune"); err == nil {
+		t.Fatal("prune of a running session succeeded")
+	}
+	if _, err := session.Load(stateFile); err != nil {
+		t.Errorf("session state was removed by a refused prune: %v", err)
+	}
+
+	if err := runCommand(t, pruneCommand, stateFile, "prune", "--force"); err != nil {
+		t.Fatalf("prune --force error = %v", err)
+	}
+	if _, err := session.Load(stateFile); err != session.ErrNoSession {
+		t.Errorf("session state after prune --force = %v, want it removed", err)
+	}
+}
+
+func TestPruneWithoutRunningSession(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "session.json")
+	if err := runCommand(t, pruneCommand, stateFile, "prune"); err != nil {
+		t.Errorf("prune without session error = %v", err)
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/transeptorlabs/betsy/internal/docker"
+	"github.com/transeptorlabs/betsy/internal/session"
+	"github.com/transeptorlabs/betsy/logger"
+	"github.com/urfave/cli/v2"
+)
+
+const detachedLogFile = "betsy.log"
+
+// upCommand starts the environment in the foreground or, with --detach, in a background process
+func upCommand(containerManager *docker.ContainerManager) *cli.Command {
+	return &cli.Command{
+		Name:  "up",
+		Usage: "Start the Betsy environment",
+		Flags: append(
+			environmentFlags(nil),
+			&cli.BoolFlag{
+				Name:    "detach",
+				Usage:   "Run the environment in the background and return once it is ready",
+				Aliases: []string{"d"},
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "How long to wait for a detached environment to become ready",
+				Value: 5 * time.Minute,
+			},
+		),
+		Before: inheritFlags,
+		Action: func(cCtx *cli.Context) error {
+			if !cCtx.Bool("detach") {
+				return runEnvironment(cCtx, containerManager)
+			}
+
+			return startDetached(cCtx)
+		},
+	}
+}
+
+// downCommand stops the session started by betsy up from another shell
+func downCommand(containerManager *docker.ContainerManager) *cli.Command {
+	return &cli.Command{
+		Name:  "down",
+		Usage: "Stop the running Betsy environment and remove its containers",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "How long to wait for the Betsy process to shut down before removing its containers",
+				Value: 30 * time.Second,
+			},
+		},
+		Action: func(cCtx *cli.Context) error {
+			initCommandLogger(cCtx)
+
+			stateFile := cCtx.String("state.file")
+			state, err := session.Load(stateFile)
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+
+			if state.IsProcessAlive() {
+				log.Info().Msgf("Stopping Betsy session %s (pid %d)...", state.SessionID, state.PID)
+				process, err := os.FindProcess(state.PID)
+				if err != nil {
+					return err
+				}
+
+				if err := process.Signal(syscall.SIGTERM); err != nil {
+					return err
+				}
+
+				if waitForShutdown(state, stateFile, cCtx.Duration("timeout")) {
+					fmt.Fprintln(cCtx.App.Writer, "Betsy session stopped")
+					return nil
+				}
+				log.Warn().Msg("Betsy process did not shut down in time, removing its containers")
+			}
+
+			// The owning process is gone, clean up what it left behind
+			if err := containerManager.TearDownSession(cCtx.Context, state); err != nil {
+				return err
+			}
+
+			if err := session.Remove(stateFile); err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cCtx.App.Writer, "Betsy session removed")
+			return nil
+		},
+	}
+}
+
+// statusCommand reports the live health of the running session
+func statusCommand(containerManager *docker.ContainerManager) *cli.Command {
+	return &cli.Command{
+		Name:  "status",
+		Usage: "Show the status of the running Betsy environment",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print the status as JSON",
+			},
+		},
+		Action: func(cCtx *cli.Context) error {
+			initCommandLogger(cCtx)
+
+			state, err := session.Load(cCtx.String("state.file"))
+			if err != nil {
+				return cli.Exit(err.Error(), 1)
+			}
+
+			alive := state.IsProcessAlive()
+			healthy := alive
+			components := make([]docker.ComponentStatus, 0, len(state.Containers))
+			for _, sessionContainer := range state.Containers {
+				status := containerManager.StatusOf(cCtx.Context, sessionContainer)
+				healthy = healthy && status.Ready
+				components = append(components, status)
+			}
+
+			if cCtx.Bool("json") {
+				err = json.NewEncoder(cCtx.App.Writer).Encode(map[string]interface{}{
+					"session":      state,
+					"processAlive": alive,
+					"healthy":      healthy,
+					"components":   components,
+				})
+				if err != nil {
+					return err
+				}
+			} else {
+				printSessionStatus(cCtx, state, alive, components)
+			}
+
+			if !healthy {
+				return cli.Exit("", 1)
+			}
+
+			return nil
+		},
+	}
+}
+
+// initCommandLogger initializes the logger for commands that do not start the environment
+func initCommandLogger(cCtx *cli.Context) {
+	var err error
+	log.Logger, err = logger.GetLogger(cCtx.String("log.level"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize logger")
+	}
+}
+
+// startDetached re-runs betsy up in a background process and waits for it to save its session state
+func startDetached(cCtx *cli.Context) error {
+	initCommandLogger(cCtx)
+
+	stateFile := cCtx.String("state.file")
+	if existing, err := session.Load(stateFile); err == nil && existing.IsProcessAlive() {
+		return cli.Exit(fmt.Sprintf("A Betsy session is already running (pid %d), stop it with betsy down", existing.PID), 1)
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(stateFile), 0755); err != nil {
+		return err
+	}
+
+	logPath := filepath.Join(filepath.Dir(stateFile), detachedLogFile)
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(executable, withoutDetachFlag(os.Args[1:])...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = detachedSysProcAttr()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	log.Info().Msgf("Starting Betsy in the background (pid %d), logs: %s", cmd.Process.Pid, logPath)
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(cCtx.Duration("timeout"))
+
+	for {
+		select {
+		case err := <-exited:
+			return cli.Exit(fmt.Sprintf("Betsy exited before becoming ready (%v), see %s", err, logPath), 1)
+		case <-timeout:
+			_ = cmd.Process.Signal(syscall.SIGTERM)
+			return cli.Exit(fmt.Sprintf("Betsy did not become ready in %s, see %s", cCtx.Duration("timeout"), logPath), 1)
+		case <-ticker.C:
+			state, err := session.Load(stateFile)
+			if errors.Is(err, session.ErrNoSession) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			if state.PID == cmd.Process.Pid {
+				printSessionStatus(cCtx, state, true, nil)
+				return nil
+			}
+		}
+	}
+}
+
+// waitForShutdown waits until the session process exits and removes its state file
+func waitForShutdown(state *session.State, stateFile string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if _, err := session.Load(stateFile); errors.Is(err, session.ErrNoSession) && !state.IsProcessAlive() {
+			return true
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	return false
+}
+
+// withoutDetachFlag removes the detach flag given to the up command so the child process runs in the foreground
+func withoutDetachFlag(args []string) []string {
+	result := make([]string, 0, len(args))
+	afterUp := false
+	for _, arg := range args {
+		if afterUp {
+			switch arg {
+			case "-d", "--d", "-detach", "--detach", "-d=true", "--d=true", "-detach=true", "--detach=true":
+				continue
+			}
+		}
+
+		if arg == "up" {
+			afterUp = true
+		}
+		result = append(result, arg)
+	}
+
+	return result
+}
+
+// printSessionStatus prints a human readable summary of a session
+func printSessionStatus(cCtx *cli.Context, state *session.State, alive bool, components []docker.ComponentStatus) {
+	processState := "running"
+	if !alive {
+		processState = "not running"
+	}
+
+	w := cCtx.App.Writer
+	fmt.Fprintf(w, "Betsy session %s (pid %d, %s), started at %s\n", state.SessionID, state.PID, processState, state.StartedAt.Format(time.RFC3339))
+	fmt.Fprintf(w, "- ETH node: %s\n", state.EthNodeUrl)
+	if len(state.Bundlers) == 0 {
+		fmt.Fprintf(w, "- Bundler (%s): %s\n", state.Bundler, state.BundlerNodeUrl)
+	}
+	for _, bundler := range state.Bundlers {
+		fmt.Fprintf(w, "- Bundler (%s): %s\n", bundler.Name, bundler.Url)
+	}
+	fmt.Fprintf(w, "- Dashboard: %s/dashboard\n", state.DashboardServerUrl)
+	fmt.Fprintf(w, "- EntryPoint V7: %s\n", state.PreDeployedContracts.EntryPointAddress)
+
+	for _, component := range components {
+		ready := "ready"
+		if !component.Ready {
+			ready = "not ready: " + component.Error
+		}
+		fmt.Fprintf(w, "  %-12s %-8s %-10s %s\n", component.Name, component.NodeType, component.State, ready)
+	}
+}
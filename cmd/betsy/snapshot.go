@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/transeptorlabs/betsy/internal/docker"
+	"github.com/transeptorlabs/betsy/internal/session"
+	"github.com/urfave/cli/v2"
+)
+
+// snapshotCommand saves and restores snapshots of the persisted chain of the running session
+func snapshotCommand(containerManager *docker.ContainerManager) *cli.Command {
+	return &cli.Command{
+		Name:  "snapshot",
+		Usage: "Save and restore snapshots of the chain of a session started with --persist",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "save",
+				Usage:     "Save the chain state of the running session",
+				ArgsUsage: "<name>",
+				Action: func(cCtx *cli.Context) error {
+					state, name, err := loadSnapshotSession(cCtx)
+					if err != nil {
+						return err
+					}
+
+					startedAt := time.Now()
+					if err := containerManager.SaveSnapshot(cCtx.Context, state, name); err != nil {
+						return err
+					}
+
+					// Keep the deployments and funded accounts of the snapshot with it
+					if err := copyPersisted(
+						session.PersistedStateFile(cCtx.String("state.file"), state.Persist),
+						session.PersistedSnapshotFile(cCtx.String("state.file"), state.Persist, name),
+					); err != nil {
+						return err
+					}
+
+					fmt.Fprintf(cCtx.App.Writer, "Saved snapshot %s of chain %s in %s\n", name, state.Persist, time.Since(startedAt).Round(time.Millisecond))
+					return nil
+				},
+			},
+			{
+				Name:      "restore",
+				Usage:     "Restore a snapshot in the running session and restart its bundlers with a clean state",
+				ArgsUsage: "<name>",
+				Action: func(cCtx *cli.Context) error {
+					state, name, err := loadSnapshotSession(cCtx)
+					if err != nil {
+						return err
+					}
+
+					startedAt := time.Now()
+					if err := containerManager.RestoreSnapshot(cCtx.Context, state, name); err != nil {
+						return err
+					}
+
+					if err := copyPersisted(
+						session.PersistedSnapshotFile(cCtx.String("state.file"), state.Persist, name),
+						session.PersistedStateFile(cCtx.String("state.file"), state.Persist),
+					); err != nil {
+						return err
+					}
+
+					fmt.Fprintf(cCtx.App.Writer, "Restored snapshot %s of chain %s in %s\n", name, state.Persist, time.Since(startedAt).Round(time.Millisecond))
+					return nil
+				},
+			},
+			{
+				Name:  "list",
+				Usage: "List the snapshots of the persisted chain selected with --persist or of the running session",
+				Action: func(cCtx *cli.Context) error {
+					initCommandLogger(cCtx)
+
+					persist := cCtx.String("persist")
+					if persist == "" {
+						state, err := session.Load(cCtx.String("state.file"))
+						if err != nil {
+							return cli.Exit(err.Error(), 1)
+						}
+						persist = state.Persist
+					}
+					if persist == "" {
+						return cli.Exit(docker.ErrNoPersistedSession.Error(), 1)
+					}
+
+					snapshots, err := containerManager.ListSnapshots(cCtx.Context, persist)
+					if err != nil {
+						return err
+					}
+
+					if len(snapshots) == 0 {
+						fmt.Fprintf(cCtx.App.Writer, "No snapshots of chain %s\n", persist)
+						return nil
+					}
+
+					for _, snapshot := range snapshots {
+						fmt.Fprintf(cCtx.App.Writer, "%-20s %-40s %s\n", snapshot.Name, snapshot.Volume, snapshot.CreatedAt)
+					}
+					return nil
+				},
+			},
+		},
+	}
+}
+
+// loadSnapshotSession returns the running session and the snapshot name passed as argument
+func loadSnapshotSession(cCtx *cli.Context) (*session.State, string, error) {
+	initCommandLogger(cCtx)
+
+	name := cCtx.Args().First()
+	if name == "" {
+		return nil, "", cli.Exit("a snapshot name is required", 1)
+	}
+
+	state, err := session.Load(cCtx.String("state.file"))
+	if err != nil {
+		return nil, "", cli.Exit(err.Error(), 1)
+	}
+
+	if !state.IsProcessAlive() {
+		return nil, "", cli.Exit(fmt.Sprintf("Betsy session %s is not running, start it with betsy up --persist", state.SessionID), 1)
+	}
+
+	if state.Persist == "" {
+		return nil, "", cli.Exit(docker.ErrNoPersistedSession.Error(), 1)
+	}
+
+	return state, name, nil
+}
+
+// copyPersisted copies the stored state of a persisted chain, a missing source is ignored
+func copyPersisted(source string, target string) error {
+	persisted, err := session.LoadPersisted(source)
+	if err == session.ErrNoPersistedState {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	persisted.UpdatedAt = time.Now().UTC()
+	return session.SavePersisted(target, persisted)
+}
@@ -0,0 +1,615 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/transeptorlabs/betsy/internal/config"
+	"github.com/transeptorlabs/betsy/internal/docker"
+	"github.com/transeptorlabs/betsy/internal/mempool"
+	"github.com/transeptorlabs/betsy/internal/mining"
+	"github.com/transeptorlabs/betsy/internal/server"
+	"github.com/transeptorlabs/betsy/internal/session"
+	"github.com/transeptorlabs/betsy/internal/utils"
+	"github.com/transeptorlabs/betsy/logger"
+	"github.com/transeptorlabs/betsy/wallet"
+	"github.com/urfave/cli/v2"
+)
+
+// prepare loads the config and initializes the logger
+func prepare(cCtx *cli.Context) (*config.Config, error) {
+	cfg, err := loadConfig(cCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Logger, err = logger.GetLogger(cfg.Log.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// runEnvironment starts the Betsy environment in the foreground and blocks until it receives an interrupt signal
+func runEnvironment(cCtx *cli.Context, containerManager *docker.ContainerManager) error {
+	cfg, err := prepare(cCtx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load config")
+	}
+
+	err = printWelcomeBanner()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load welcome banner")
+	}
+
+	stateFile := cCtx.String("state.file")
+	if existing, err := session.Load(stateFile); err == nil && existing.IsProcessAlive() {
+		log.Fatal().Msgf("A Betsy session is already running (pid %d), stop it with betsy down", existing.PID)
+	}
+
+	if err := containerManager.UseRuntime(cfg.Runtime, session.NativeDir(stateFile)); err != nil {
+		log.Fatal().Err(err).Msg("Failed to select the runtime")
+	}
+
+	log.Debug().Msgf("Running preflight checks...")
+
+	if !docker.IsSupportedExecutionClient(cfg.Eth.Client) {
+		log.Fatal().Msgf("Execution client %s is not supported, choose one of: %s", cfg.Eth.Client, strings.Join(docker.SupportedExecutionClients(), ", "))
+	}
+
+	if cfg.Eth.Persist != "" && !docker.IsValidPersistName(cfg.Eth.Persist) {
+		log.Fatal().Msgf("Invalid persist name %s, use letters, digits, '_', '.' and '-'", cfg.Eth.Persist)
+	}
+
+	if cfg.Eth.Persist != "" && !containerManager.SupportsPersistence(cfg.Eth.Client) {
+		log.Fatal().Msgf("Execution client %s does not support --persist, use geth", cfg.Eth.Client)
+	}
+
+	if !docker.IsSupportedBundler(cfg.Bundler.Name) {
+		log.Fatal().Msgf("Bundler %s is not supported, choose one of: %s", cfg.Bundler.Name, strings.Join(docker.SupportedBundlers(), ", "))
+	}
+
+	requiredImages, err := configureImages(cfg, containerManager)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to configure images")
+	}
+
+	if err := containerManager.ConfigureMining(cfg.Eth.Client, cfg.Eth.Mining.Mode, cfg.Eth.Mining.BlockTime); err != nil {
+		log.Fatal().Err(err).Msg("Failed to configure block production")
+	}
+
+	// Check that every host port is free before pulling images and starting containers
+	bundlerPorts, err := checkPorts(cfg, cCtx.Bool("auto-ports"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Port preflight check failed")
+	}
+
+	// Check that docker is installed, or that the binaries are installed on the host with the native runtime
+	native := containerManager.RuntimeName() == docker.RuntimeNative
+	if native {
+		if err := containerManager.CheckNativeBinaries(requiredImages); err != nil {
+			log.Fatal().Err(err).Msg("Install the binaries or set eth.binary and bundler.binary to use the native runtime")
+		}
+	} else if !containerManager.IsDockerInstalled(cCtx.Context) {
+		log.Fatal().Msg("Docker needs to be installed and its daemon reachable (see DOCKER_HOST) to use Betsy, or start it with --runtime native")
+	}
+
+	// Detect containers and networks left behind by crashed sessions
+	orphans, err := containerManager.FindOrphans(cCtx.Context)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to look for orphaned Betsy containers")
+	}
+	if !orphans.IsEmpty() {
+		if cCtx.Bool("reap-orphans") {
+			log.Info().Msgf("Removing %d containers and %d networks left behind by crashed Betsy sessions", len(orphans.Containers), len(orphans.Networks))
+			if err := containerManager.RemoveOrphans(cCtx.Context, orphans); err != nil {
+				log.Fatal().Err(err).Msg("Failed to remove orphaned Betsy containers")
+			}
+		} else if len(orphans.Containers) > 0 {
+			log.Fatal().Msgf("Found %d containers left behind by a crashed Betsy session, remove them with betsy prune or start with --reap-orphans", len(orphans.Containers))
+		} else {
+			log.Warn().Msgf("Found %d networks left behind by a crashed Betsy session, remove them with betsy prune", len(orphans.Networks))
+		}
+	}
+
+	if !native {
+		_, err = containerManager.PullRequiredImages(
+			cCtx.Context,
+			requiredImages,
+			cfg.Images.PullPolicy,
+		)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to pull required images")
+		}
+	}
+
+	// Mount the persisted chain state and load the deployments and funded accounts of the previous session
+	var persisted *session.PersistedState
+	if cfg.Eth.Persist != "" {
+		persisted, err = resumePersisted(cCtx.Context, containerManager, cfg, stateFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to set up the persisted chain state")
+		}
+	}
+
+	defer tearDown(cCtx.Context, containerManager, stateFile, cfg.Log.PersistDir)
+
+	// Create a context that will be canceled when an interrupt signal is caught
+	ctx, stop := signal.NotifyContext(cCtx.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// A failure of the running environment shuts it down and makes betsy exit with status 1
+	failed := make(chan error, 1)
+	fail := func(err error, msg string) {
+		log.Err(err).Msg(msg)
+		select {
+		case failed <- err:
+		default:
+		}
+		stop()
+	}
+
+	// Wait until the eth node container is ready before starting the bundler
+	// Using a channel to signal when the container is ready
+	// and a channel to signal if there was an error, buffered so the goroutine exits when nobody waits for it after an interrupt
+	readyChan := make(chan struct{})
+	readyErrorChan := make(chan error, 1)
+
+	ctxWithReadyChan := context.WithValue(ctx, docker.EthNodeReady, readyChan)
+
+	go func() {
+		_, err := containerManager.RunContainerInTheBackground(
+			ctxWithReadyChan,
+			cfg.Eth.Client,
+			strconv.Itoa(int(cfg.Eth.Port)),
+		)
+		if err != nil {
+			readyErrorChan <- err
+		}
+	}()
+
+	var betsyWallet *wallet.Wallet
+
+	/* Handle case where
+	- eth node container fails to start
+	- eth node container is ready
+	- context is canceled with an interrupt signal (ctrl-c)
+	*/
+	select {
+	case err := <-readyErrorChan:
+		return environmentFailure(err, "Failed to run ETH node container")
+	case <-readyChan:
+		log.Info().Msg("ETH node is ready, starting bundler and initializing dev wallet...")
+
+		accountBalance, accountBalances, err := cfg.Accounts.ParseBalances()
+		if err != nil {
+			return environmentFailure(err, "Invalid dev account balance")
+		}
+
+		smartAccountBalance, smartAccountDeposit, err := cfg.SmartAccounts.ParseAmounts()
+		if err != nil {
+			return environmentFailure(err, "Invalid smart account amounts")
+		}
+
+		walletConfig := wallet.Config{
+			Mnemonic:                   cfg.Accounts.Mnemonic,
+			AccountCount:               cfg.Accounts.Count,
+			DerivationPath:             cfg.Accounts.Path,
+			AccountBalance:             accountBalance,
+			AccountBalances:            accountBalances,
+			BundlerCount:               cfg.Bundler.Count,
+			DeploySimpleAccountFactory: cfg.PreDeploy.Has(config.ContractSimpleAccountFactory),
+			DeployGlobalCounter:        cfg.PreDeploy.Has(config.ContractGlobalCounter),
+			Layout:                     cfg.PreDeploy.Layout,
+			SmartAccounts: wallet.SmartAccountsConfig{
+				Enabled: cfg.SmartAccounts.Enabled,
+				Deploy:  cfg.SmartAccounts.Deploy,
+				Salt:    new(big.Int).SetUint64(cfg.SmartAccounts.Salt),
+				Balance: smartAccountBalance,
+				Deposit: smartAccountDeposit,
+			},
+		}
+		if persisted != nil {
+			walletConfig.FundedAccounts = persisted.FundedAccounts
+			walletConfig.PreDeployedContracts = &persisted.PreDeployedContracts
+		}
+
+		// create dev wallet with the configured accounts
+		betsyWallet, err = wallet.NewWallet(
+			ctx,
+			strconv.Itoa(int(cfg.Eth.Port)),
+			containerManager.EthNodeSigner,
+			walletConfig,
+		)
+		if err != nil {
+			return environmentFailure(err, "Failed to create dev wallet")
+		}
+
+		// Start each bundler container passing a context with the wallet details of the instance
+		for index, port := range bundlerPorts {
+			instanceName := docker.BundlerInstanceName(cfg.Bundler.Name, index+1)
+			ctxWithBundlerDetails := context.WithValue(ctx, docker.BundlerNodeWalletDetails, betsyWallet.GetBundlerWalletDetails(index))
+
+			_, err = containerManager.RunContainerInTheBackground(
+				ctxWithBundlerDetails,
+				instanceName,
+				strconv.Itoa(int(port)),
+			)
+			if err != nil {
+				return environmentFailure(err, fmt.Sprintf("Failed to run %s bundler container", instanceName))
+			}
+		}
+	case <-ctx.Done():
+		log.Info().Msg("Received signal, shutting down...")
+		return nil
+	}
+
+	// create and start a mempool poller for each bundler instance
+	rpcPath, err := containerManager.GetRPCPath(cfg.Bundler.Name)
+	if err != nil {
+		return environmentFailure(err, "Failed to get bundler rpc path")
+	}
+	mempools := make([]*mempool.UserOpMempool, 0, len(bundlerPorts))
+	bundlers := make([]session.Bundler, 0, len(bundlerPorts))
+	for index, port := range bundlerPorts {
+		instanceName := docker.BundlerInstanceName(cfg.Bundler.Name, index+1)
+		walletDetails := betsyWallet.GetBundlerWalletDetails(index)
+		bundlerUrl := "http://localhost:" + strconv.Itoa(int(port)) + rpcPath
+
+		userOpMempool := mempool.NewUserOpMempool(
+			instanceName,
+			walletDetails.SignerAddress,
+			walletDetails.EntryPointAddress,
+			betsyWallet.GetEthClient(),
+			bundlerUrl,
+		)
+		go func() {
+			if err := userOpMempool.Run(); err != nil {
+				fail(err, fmt.Sprintf("%s mempool failed", instanceName))
+			}
+		}()
+
+		mempools = append(mempools, userOpMempool)
+		bundlers = append(bundlers, session.Bundler{
+			Name:          instanceName,
+			Url:           bundlerUrl,
+			Beneficiary:   walletDetails.Beneficiary,
+			SignerAddress: walletDetails.SignerAddress,
+			SignerBalance: betsyWallet.FundingBalance(walletDetails.SignerAddress),
+		})
+	}
+	bundlerUrl := bundlers[0].Url
+
+	// Switch the eth node to the configured block production mode now that the environment is set up
+	miningDefinition, err := containerManager.GetMiningDefinition(cfg.Eth.Client)
+	if err != nil {
+		return environmentFailure(err, "Failed to get mining definition")
+	}
+	miner := mining.NewMiner(betsyWallet.GetEthClient(), cfg.Eth.Mining.Mode, cfg.Eth.Mining.BlockTime, miningDefinition, betsyWallet.DevAccountLock())
+	if err := miner.Start(ctx); err != nil {
+		return environmentFailure(err, "Failed to configure block production")
+	}
+
+	// Restart the containers that crash and re-wire the environment once they are back
+	supervisor := containerManager.NewSupervisor(
+		docker.RestartPolicy{
+			MaxRestarts: cfg.Restart.MaxRestarts,
+			Backoff:     cfg.Restart.Backoff,
+			MaxBackoff:  cfg.Restart.MaxBackoff,
+		},
+		docker.SupervisorHooks{
+			OnCrash: func(report docker.CrashReport) {
+				if userOpMempool := findMempool(mempools, report.Name); userOpMempool != nil {
+					userOpMempool.Pause()
+				}
+			},
+			OnRestart: func(ctx context.Context, report docker.CrashReport) error {
+				if report.NodeType == "eth" {
+					// The restarted node starts with automatic mining again
+					return miner.Start(ctx)
+				}
+
+				if userOpMempool := findMempool(mempools, report.Name); userOpMempool != nil {
+					userOpMempool.Resume()
+				}
+				return nil
+			},
+		},
+	)
+	go supervisor.Run(ctx)
+
+	header, err := betsyWallet.GetEthClient().HeaderByNumber(ctx, nil)
+	if err != nil {
+		return environmentFailure(err, "Failed to get latest block")
+	}
+
+	// create and start http server
+	prefix := "http://localhost:"
+	ethNodeUrl := prefix + strconv.Itoa(int(cfg.Eth.Port))
+	dashboardServerUrl := prefix + strconv.Itoa(int(cfg.HTTP.Port))
+	httpServer := server.NewHTTPServer(
+		net.JoinHostPort("localhost", strconv.Itoa(int(cfg.HTTP.Port))),
+		cfg.HTTP.Debug,
+		betsyWallet,
+		mempools,
+		containerManager,
+		miner,
+		supervisor,
+		server.NodeInfo{
+			EthNodeUrl:         ethNodeUrl,
+			BundlerNodeUrl:     bundlerUrl,
+			DashboardServerUrl: dashboardServerUrl,
+			Bundlers:           bundlers,
+			ChainID:            betsyWallet.GetChainID(),
+			GasLimit:           header.GasLimit,
+			BlockProduction:    miner.String(),
+		},
+	)
+	go func() {
+		if err := httpServer.Run(); err != nil && err != http.ErrServerClosed {
+			fail(err, "HTTP server failed")
+		}
+	}()
+
+	accounts, err := betsyWallet.GetDevAccounts(ctx)
+	if err != nil {
+		fail(err, "Failed to get dev accounts")
+	}
+
+	smartAccounts, err := betsyWallet.GetSmartAccounts(ctx)
+	if err != nil {
+		fail(err, "Failed to get smart accounts")
+	}
+
+	nodeInfo := NodeInfo{
+		EthNodeUrl:           ethNodeUrl,
+		BundlerNodeUrl:       bundlerUrl,
+		DashboardServerUrl:   dashboardServerUrl,
+		Bundlers:             bundlers,
+		ChainID:              betsyWallet.GetChainID(),
+		GasLimit:             header.GasLimit,
+		BlockProduction:      miner.String(),
+		Mnemonic:             betsyWallet.Mnemonic(),
+		DerivationPath:       betsyWallet.DerivationPath(),
+		DevAccounts:          accounts,
+		SmartAccounts:        smartAccounts,
+		PreDeployedContracts: betsyWallet.GetPreDeployedContracts(),
+	}
+	err = printBetsyInfo(nodeInfo)
+	if err != nil {
+		fail(err, "Failed print Betsy info")
+	}
+
+	// Store the deployments and funded accounts next to the persisted chain state
+	if cfg.Eth.Persist != "" {
+		err = savePersisted(cfg, stateFile, persisted, betsyWallet)
+		if err != nil {
+			fail(err, "Failed to save persisted state")
+		}
+	}
+
+	// Persist the session so it can be managed from another shell
+	err = session.Save(stateFile, newSessionState(cfg, containerManager, nodeInfo, betsyWallet))
+	if err != nil {
+		fail(err, "Failed to save session state")
+	}
+
+	<-ctx.Done()
+
+	// Create a context with timeout to allow the server to shut down gracefully
+	shutdownCtx, cancel := context.WithTimeout(cCtx.Context, 5*time.Second)
+	defer cancel()
+
+	for _, userOpMempool := range mempools {
+		userOpMempool.Stop()
+	}
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Err(err).Msg("Server shutdown failed")
+	} else {
+		log.Info().Msg("Server shutdown completed")
+	}
+
+	select {
+	case <-failed:
+		return cli.Exit("", 1)
+	default:
+		return nil
+	}
+}
+
+// environmentFailure logs a start-up failure and returns the error making betsy exit with status 1 once the environment is torn down
+func environmentFailure(err error, msg string) error {
+	log.Err(err).Msg(msg)
+	return cli.Exit("", 1)
+}
+
+// findMempool returns the mempool polling the bundler instance with the given name, nil for other components
+func findMempool(mempools []*mempool.UserOpMempool, name string) *mempool.UserOpMempool {
+	for _, userOpMempool := range mempools {
+		if userOpMempool.Name() == name {
+			return userOpMempool
+		}
+	}
+
+	return nil
+}
+
+// configureImages applies the image overrides of the config and returns the supported images required by the environment
+func configureImages(cfg *config.Config, containerManager *docker.ContainerManager) ([]string, error) {
+	if err := containerManager.OverrideImage(cfg.Eth.Client, cfg.Eth.Image, cfg.Eth.Args); err != nil {
+		return nil, fmt.Errorf("failed to configure eth node image: %w", err)
+	}
+
+	if err := containerManager.OverrideImage(cfg.Bundler.Name, cfg.Bundler.Image, cfg.Bundler.Args); err != nil {
+		return nil, fmt.Errorf("failed to configure bundler image: %w", err)
+	}
+
+	if cfg.Eth.Binary != "" {
+		if err := containerManager.OverrideBinary(cfg.Eth.Client, cfg.Eth.Binary); err != nil {
+			return nil, fmt.Errorf("failed to configure eth node binary: %w", err)
+		}
+	}
+
+	if cfg.Bundler.Binary != "" {
+		if err := containerManager.OverrideBinary(cfg.Bundler.Name, cfg.Bundler.Binary); err != nil {
+			return nil, fmt.Errorf("failed to configure bundler binary: %w", err)
+		}
+	}
+
+	// Custom args replace the bundler command, the options are only mapped onto the default one
+	if len(cfg.Bundler.Args) == 0 {
+		options, err := bundlerOptions(cfg.Bundler)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := containerManager.ConfigureBundler(cfg.Bundler.Name, cfg.Eth.Client, options); err != nil {
+			return nil, fmt.Errorf("failed to configure bundler options: %w", err)
+		}
+	}
+
+	requiredImages := []string{cfg.Eth.Client, cfg.Bundler.Name}
+	for instance := 2; instance <= cfg.Bundler.Count; instance++ {
+		instanceName, err := containerManager.AddBundlerInstance(cfg.Bundler.Name, instance)
+		if err != nil {
+			return nil, err
+		}
+		requiredImages = append(requiredImages, instanceName)
+	}
+
+	return requiredImages, nil
+}
+
+// bundlerOptions returns the bundler runtime options of the config
+func bundlerOptions(bundlerConfig config.BundlerConfig) (docker.BundlerOptions, error) {
+	options := docker.BundlerOptions{
+		Bundling:       bundlerConfig.Bundling,
+		BundleInterval: bundlerConfig.BundleInterval,
+		Safe:           bundlerConfig.Safe,
+		TxMode:         bundlerConfig.TxMode,
+		MaxBundleGas:   bundlerConfig.MaxBundleGas,
+	}
+
+	if bundlerConfig.MinStake != "" {
+		minStake, err := utils.ParseEther(bundlerConfig.MinStake)
+		if err != nil {
+			return options, err
+		}
+		options.MinStake = minStake
+	}
+
+	return options, nil
+}
+
+// resumePersisted mounts the volume of the persisted chain and returns the state stored by the previous session, nil when the chain is new
+func resumePersisted(ctx context.Context, containerManager *docker.ContainerManager, cfg *config.Config, stateFile string) (*session.PersistedState, error) {
+	resumed, err := containerManager.EnablePersistence(ctx, cfg.Eth.Persist, cfg.Eth.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	if !resumed {
+		return nil, nil
+	}
+
+	persisted, err := session.LoadPersisted(session.PersistedStateFile(stateFile, cfg.Eth.Persist))
+	if err == session.ErrNoPersistedState {
+		log.Warn().Msgf("No state stored for the persisted chain %s, accounts are funded and contracts deployed again", cfg.Eth.Persist)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return persisted, nil
+}
+
+// savePersisted stores the deployments and funded accounts of the persisted chain, keeping the accounts funded by previous sessions
+func savePersisted(cfg *config.Config, stateFile string, previous *session.PersistedState, betsyWallet *wallet.Wallet) error {
+	fundedAccounts := betsyWallet.GetFundedAccounts()
+	if previous != nil {
+		for _, address := range previous.FundedAccounts {
+			if !slices.Contains(fundedAccounts, address) {
+				fundedAccounts = append(fundedAccounts, address)
+			}
+		}
+	}
+
+	return session.SavePersisted(session.PersistedStateFile(stateFile, cfg.Eth.Persist), &session.PersistedState{
+		Name:                 cfg.Eth.Persist,
+		EthClient:            cfg.Eth.Client,
+		UpdatedAt:            time.Now().UTC(),
+		PreDeployedContracts: betsyWallet.GetPreDeployedContracts(),
+		FundedAccounts:       fundedAccounts,
+	})
+}
+
+// tearDown removes the containers, dev wallets and session state of the environment
+func tearDown(ctx context.Context, containerManager *docker.ContainerManager, stateFile string, logsDir string) {
+	if logsDir != "" {
+		err := containerManager.SaveLogs(ctx, filepath.Join(logsDir, containerManager.SessionID))
+		if err != nil {
+			log.Err(err).Msg("Failed to save container logs")
+		}
+	}
+
+	log.Info().Msgf("Tearing down docker containers!\n")
+	_, err := containerManager.StopAndRemoveRunningContainers(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to tear down docker containers!")
+	}
+
+	err = utils.RemoveDevWallets()
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to remove wallets")
+	}
+
+	err = session.Remove(stateFile)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to remove session state")
+	}
+}
+
+// newSessionState builds the session state persisted for the up, down and status commands
+func newSessionState(cfg *config.Config, containerManager *docker.ContainerManager, nodeInfo NodeInfo, betsyWallet *wallet.Wallet) *session.State {
+	accounts := make([]session.Account, 0, len(nodeInfo.DevAccounts))
+	for _, account := range nodeInfo.DevAccounts {
+		accounts = append(accounts, session.Account{
+			Address:       account.Address,
+			PrivateKeyHex: account.PrivateKeyHex,
+			Balance:       betsyWallet.FundingBalance(account.Address),
+		})
+	}
+
+	return &session.State{
+		PID:                  os.Getpid(),
+		SessionID:            containerManager.SessionID,
+		StartedAt:            time.Now().UTC(),
+		Runtime:              containerManager.RuntimeName(),
+		Bundler:              cfg.Bundler.Name,
+		Persist:              cfg.Eth.Persist,
+		NetworkName:          containerManager.NetworkName,
+		EthNodeUrl:           nodeInfo.EthNodeUrl,
+		BundlerNodeUrl:       nodeInfo.BundlerNodeUrl,
+		Bundlers:             nodeInfo.Bundlers,
+		DashboardServerUrl:   nodeInfo.DashboardServerUrl,
+		Containers:           containerManager.SessionContainers(),
+		PreDeployedContracts: nodeInfo.PreDeployedContracts,
+		Accounts:             accounts,
+		SmartAccounts:        nodeInfo.SmartAccounts,
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/rs/zerolog/log"
 	"github.com/transeptorlabs/betsy/internal/data"
 )
@@ -154,3 +155,63 @@ func (b *BundlerClient) Debug_bundler_addUserOps(ops []data.UserOpV7Hexify) erro
 
 	return nil
 }
+
+// UserOpReceipt contains the fields of an eth_getUserOperationReceipt result used by Betsy
+type UserOpReceipt struct {
+	UserOpHash common.Hash `json:"userOpHash"`
+	Success    bool        `json:"success"`
+	Receipt    struct {
+		TransactionHash common.Hash    `json:"transactionHash"`
+		From            common.Address `json:"from"`
+	} `json:"receipt"`
+}
+
+// eth_getUserOperationReceiptRes is the response struct for eth_getUserOperationReceipt rpc method
+type eth_getUserOperationReceiptRes struct {
+	jsonrpcBase
+	Result *UserOpReceipt `json:"result"`
+}
+
+// Eth_getUserOperationReceipt calls the eth_getUserOperationReceipt rpc method, it returns nil when the userOp is not included yet
+func (b *BundlerClient) Eth_getUserOperationReceipt(userOpHash common.Hash) (*UserOpReceipt, error) {
+	log.Debug().Msgf("Making call to bundler node eth_getUserOperationReceipt at %s", b.bundlerUrl)
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	req, err := b.getRequest("eth_getUserOperationReceipt", []interface{}{
+		userOpHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	client := http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	// handle json rpc response
+	b.jsonRpcRequestID = b.jsonRpcRequestID + 1
+	if res.StatusCode != 200 {
+		return nil, errors.New(fmt.Sprintf("Request to bundler eth_getUserOperationReceipt rpc method failed with status code: %d", res.StatusCode))
+	}
+
+	resJsonBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// parse result
+	var data *eth_getUserOperationReceiptRes
+	err = json.Unmarshal(resJsonBody, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Result, nil
+}
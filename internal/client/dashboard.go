@@ -0,0 +1,69 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/transeptorlabs/betsy/wallet"
+)
+
+// DashboardClient is a client for the API of the dashboard server of a running session
+type DashboardClient struct {
+	dashboardUrl string
+	client       http.Client
+}
+
+// faucetReq is the body of the POST /api/faucet request
+type faucetReq struct {
+	Address string `json:"address"`
+	Amount  string `json:"amount"`
+	Deposit bool   `json:"deposit"`
+}
+
+// NewDashboardClient creates a new DashboardClient
+func NewDashboardClient(dashboardUrl string) *DashboardClient {
+	return &DashboardClient{
+		dashboardUrl: dashboardUrl,
+		client: http.Client{
+			Timeout: time.Minute, // the faucet submits its transaction and waits a few seconds for it to be mined
+		},
+	}
+}
+
+// Faucet sends amount ether to address, or to its EntryPoint deposit, from the coinbase account of the session
+func (d *DashboardClient) Faucet(ctx context.Context, address string, amount string, deposit bool) (wallet.FaucetResult, error) {
+	jsonBody, _ := json.Marshal(faucetReq{Address: address, Amount: amount, Deposit: deposit})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.dashboardUrl+"/api/faucet", bytes.NewReader(jsonBody))
+	if err != nil {
+		return wallet.FaucetResult{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return wallet.FaucetResult{}, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		var errorRes struct {
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&errorRes); err != nil || errorRes.Error == "" {
+			return wallet.FaucetResult{}, fmt.Errorf("Request to the faucet failed with status code: %d", res.StatusCode)
+		}
+		return wallet.FaucetResult{}, fmt.Errorf("Request to the faucet failed: %s", errorRes.Error)
+	}
+
+	var result wallet.FaucetResult
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return wallet.FaucetResult{}, err
+	}
+
+	return result, nil
+}
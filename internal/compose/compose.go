@@ -0,0 +1,206 @@
+// Package compose renders a running Betsy environment as a docker-compose project that runs without the Betsy binary
+package compose
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/transeptorlabs/betsy/internal/docker"
+	"github.com/transeptorlabs/betsy/wallet"
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the rendered compose file
+const FileName = "docker-compose.yml"
+
+const (
+	networkName     = "betsy"
+	initServiceName = "betsy-init"
+	initDir         = "init"
+)
+
+// Project is the subset of the compose file format rendered by Betsy
+type Project struct {
+	Name     string             `yaml:"name"`
+	Services map[string]Service `yaml:"services"`
+	Networks map[string]Network `yaml:"networks"`
+}
+
+// Service is a compose service
+type Service struct {
+	Image       string                `yaml:"image"`
+	Entrypoint  []string              `yaml:"entrypoint,omitempty"`
+	Command     []string              `yaml:"command,omitempty"`
+	Environment []string              `yaml:"environment,omitempty"`
+	Ports       []string              `yaml:"ports,omitempty"`
+	Volumes     []string              `yaml:"volumes,omitempty"`
+	Networks    []string              `yaml:"networks"`
+	Healthcheck *Healthcheck          `yaml:"healthcheck,omitempty"`
+	DependsOn   map[string]Dependency `yaml:"depends_on,omitempty"`
+}
+
+// Healthcheck is the health check of a compose service
+type Healthcheck struct {
+	Test     []string `yaml:"test"`
+	Interval string   `yaml:"interval"`
+	Timeout  string   `yaml:"timeout"`
+	Retries  int      `yaml:"retries"`
+}
+
+// Dependency is the condition a compose service waits for before it starts
+type Dependency struct {
+	Condition string `yaml:"condition"`
+}
+
+// Network is a compose network
+type Network struct {
+	Driver string `yaml:"driver"`
+}
+
+// Options contains the resolved environment to export
+type Options struct {
+	SessionID             string
+	Containers            []docker.ExportedContainer
+	InitImage             string                      // image providing cast, used by the one-shot init service
+	FundedAccounts        []common.Address            // accounts funded by the init service, in the order betsy funds them
+	Balance               *big.Int                    // balance sent to each funded account in wei
+	Balances              map[common.Address]*big.Int // balances in wei overriding Balance for some accounts, zero skips the funding
+	DeployerPrivateKeyHex string                      // dev account deploying the contracts
+	PreDeployedContracts  wallet.PreDeployedContracts
+	SmartAccounts         []wallet.SmartAccount // smart accounts deployed, funded and deposited for once the contracts are deployed
+}
+
+// Write renders the compose project and the files of its init service to dir
+func Write(dir string, options Options) error {
+	project, err := NewProject(options)
+	if err != nil {
+		return err
+	}
+
+	initFiles, err := newInitFiles(options)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, initDir), 0755); err != nil {
+		return err
+	}
+
+	for name, content := range initFiles {
+		if err := os.WriteFile(filepath.Join(dir, initDir, name), content, 0644); err != nil {
+			return err
+		}
+	}
+
+	var output bytes.Buffer
+	fmt.Fprintf(&output, "# Generated by betsy export compose from session %s\n", options.SessionID)
+	encoder := yaml.NewEncoder(&output)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(project); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, FileName), output.Bytes(), 0644)
+}
+
+// NewProject returns the compose project running the exported containers, the bundlers start once the init service has funded the accounts and deployed the contracts
+func NewProject(options Options) (*Project, error) {
+	project := &Project{
+		Name:     "betsy",
+		Services: map[string]Service{},
+		Networks: map[string]Network{networkName: {Driver: "bridge"}},
+	}
+
+	var ethNode *docker.ExportedContainer
+	for i, item := range options.Containers {
+		if item.NodeType == "eth" {
+			ethNode = &options.Containers[i]
+		}
+	}
+	if ethNode == nil {
+		return nil, fmt.Errorf("the session has no eth node to export")
+	}
+
+	for _, item := range options.Containers {
+		service := Service{
+			Image:       item.Image,
+			Command:     item.Cmd,
+			Environment: item.Env,
+			Ports:       []string{item.HostPort + ":" + item.ContainerPort},
+			Networks:    []string{networkName},
+			Healthcheck: newHealthcheck(item),
+		}
+
+		if item.NodeType == "bundler" {
+			service.DependsOn = map[string]Dependency{
+				initServiceName: {Condition: "service_completed_successfully"},
+			}
+		}
+
+		project.Services[item.ContainerName] = service
+	}
+
+	project.Services[initServiceName] = Service{
+		Image:       options.InitImage,
+		Entrypoint:  []string{"/bin/sh", "/init/init.sh"},
+		Environment: []string{"ETH_RPC_URL=http://" + ethNode.ContainerName + ":" + ethNode.ContainerPort + ethNode.RPCPath},
+		Volumes:     []string{"./" + initDir + ":/init:ro"},
+		Networks:    []string{networkName},
+		DependsOn: map[string]Dependency{
+			ethNode.ContainerName: {Condition: "service_started"},
+		},
+	}
+
+	return project, nil
+}
+
+// newHealthcheck returns a health check sending the readiness probe of a container with wget or curl, whichever the image provides
+func newHealthcheck(item docker.ExportedContainer) *Healthcheck {
+	params := item.ReadinessProbe.Params
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  item.ReadinessProbe.Method,
+		"params":  params,
+	})
+
+	url := "http://localhost:" + item.ContainerPort + item.RPCPath
+	test := fmt.Sprintf(
+		"wget -qO- --header 'Content-Type: application/json' --post-data '%[1]s' %[2]s >/dev/null 2>&1 || curl -sf -H 'Content-Type: application/json' -d '%[1]s' %[2]s >/dev/null",
+		body, url,
+	)
+
+	retries := item.ReadinessProbe.Retries
+	if retries == 0 {
+		retries = 60
+	}
+
+	return &Healthcheck{
+		Test:     []string{"CMD-SHELL", test},
+		Interval: durationOr(item.ReadinessProbe.Interval, time.Second),
+		Timeout:  durationOr(item.ReadinessProbe.Timeout, 2*time.Second),
+		Retries:  retries,
+	}
+}
+
+// durationOr formats a duration for compose, using fallback when it is not set
+func durationOr(value time.Duration, fallback time.Duration) string {
+	if value == 0 {
+		value = fallback
+	}
+
+	return value.String()
+}
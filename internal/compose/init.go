@@ -0,0 +1,162 @@
+package compose
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/transeptorlabs/betsy/internal/docker"
+	"github.com/transeptorlabs/betsy/wallet"
+)
+
+// initScriptHeader waits for the eth node and defines the fund and deploy helpers of the init script
+const initScriptHeader = `#!/bin/sh
+# Funds the dev accounts, deploys the pre-deployed contracts and sets up the smart accounts like betsy does on start-up
+# Generated by betsy export compose
+set -e
+
+echo "Waiting for the eth node at $ETH_RPC_URL..."
+until cast block-number --rpc-url "$ETH_RPC_URL" >/dev/null 2>&1; do
+  sleep 1
+done
+
+fund() {
+  cast send --rpc-url "$ETH_RPC_URL" $SIGNER --value "$2" "$1" >/dev/null
+  echo "Funded $1"
+}
+
+deploy() {
+  if [ -n "$DETERMINISTIC_DEPLOYER" ]; then
+    cast send --rpc-url "$ETH_RPC_URL" --private-key "$DEPLOYER_PRIVATE_KEY" "$DETERMINISTIC_DEPLOYER" "$(cat "/init/$1.bin")" >/dev/null
+  else
+    cast send --rpc-url "$ETH_RPC_URL" --private-key "$DEPLOYER_PRIVATE_KEY" --create "$(cat "/init/$1.bin")" >/dev/null
+  fi
+  if [ "$(cast code --rpc-url "$ETH_RPC_URL" "$2")" = "0x" ]; then
+    echo "$1 was not deployed at the address expected by the bundlers $2" >&2
+    exit 1
+  fi
+  echo "Deployed $1 at $2"
+}
+
+create_smart_account() {
+  cast send --rpc-url "$ETH_RPC_URL" $SIGNER "$SIMPLE_ACCOUNT_FACTORY" "createAccount(address,uint256)" "$2" "$3" >/dev/null
+  if [ "$(cast code --rpc-url "$ETH_RPC_URL" "$1")" = "0x" ]; then
+    echo "The smart account of $2 was not deployed at $1" >&2
+    exit 1
+  fi
+  echo "Deployed smart account $1"
+}
+
+deposit() {
+  cast send --rpc-url "$ETH_RPC_URL" $SIGNER --value "$2" "$ENTRY_POINT" "depositTo(address)" "$1" >/dev/null
+  echo "Deposited $2 to the EntryPoint for $1"
+}
+`
+
+// initContract is a contract deployed by the init service
+type initContract struct {
+	name     string
+	address  common.Address
+	salt     common.Hash // CREATE2 salt of the canonical layout
+	initCode func(entryPoint common.Address) ([]byte, error)
+}
+
+// newInitFiles returns the init script and the init code of the contracts it deploys keyed by file name
+func newInitFiles(options Options) (map[string][]byte, error) {
+	var ethNode docker.ExportedContainer
+	for _, item := range options.Containers {
+		if item.NodeType == "eth" {
+			ethNode = item
+		}
+	}
+
+	contracts := []initContract{
+		{"EntryPointV7", options.PreDeployedContracts.EntryPointAddress, wallet.EntryPointV7Salt, entryPointInitCode},
+		{"SimpleAccountFactoryV7", options.PreDeployedContracts.SimpleAccountFactoryAddress, common.Hash{}, wallet.SimpleAccountFactoryV7InitCode},
+		{"GlobalCounter", options.PreDeployedContracts.GlobalCounterAddress, common.Hash{}, globalCounterInitCode},
+	}
+
+	files := map[string][]byte{}
+	var script bytes.Buffer
+	script.WriteString(initScriptHeader)
+
+	entryPoint := options.PreDeployedContracts.EntryPointAddress
+	fmt.Fprintf(&script, "\nif [ \"$(cast code --rpc-url \"$ETH_RPC_URL\" %s)\" != \"0x\" ]; then\n  echo \"The chain is already initialized\"\n  exit 0\nfi\n\n", entryPoint.Hex())
+
+	// The funding account of the eth node
+	switch ethNode.Signer.Source {
+	case docker.SignerFromPrivateKey:
+		fmt.Fprintf(&script, "SIGNER=\"--private-key %s\"\n", ethNode.Signer.PrivateKeyHex)
+	default:
+		script.WriteString("SIGNER=\"--unlocked --from $(cast rpc --rpc-url \"$ETH_RPC_URL\" eth_accounts | tr -d '[]\" ' | cut -d, -f1)\"\n")
+	}
+	fmt.Fprintf(&script, "DEPLOYER_PRIVATE_KEY=%s\n\n", options.DeployerPrivateKeyHex)
+
+	// The canonical layout deploys the contracts with CREATE2 through the deterministic deployer, installed with its pre-signed transaction
+	canonical := entryPoint == wallet.CanonicalEntryPointV7Address
+	if canonical {
+		fmt.Fprintf(&script, "DETERMINISTIC_DEPLOYER=%s\n", wallet.DeterministicDeployerAddress.Hex())
+		fmt.Fprintf(&script, "if [ \"$(cast code --rpc-url \"$ETH_RPC_URL\" $DETERMINISTIC_DEPLOYER)\" = \"0x\" ]; then\n  fund %s %s\n  cast publish --rpc-url \"$ETH_RPC_URL\" %s >/dev/null\n  echo \"Installed the deterministic deployer\"\nfi\n\n", wallet.DeterministicDeployerSigner.Hex(), wallet.DeterministicDeployerCost.String(), wallet.DeterministicDeployerTx)
+	}
+
+	for _, account := range options.FundedAccounts {
+		balance := options.Balance
+		if accountBalance, ok := options.Balances[account]; ok {
+			balance = accountBalance
+		}
+		if balance.Sign() == 0 {
+			continue
+		}
+		fmt.Fprintf(&script, "fund %s %s\n", account.Hex(), balance.String())
+	}
+	script.WriteString("\n")
+
+	// The contracts are deployed in the order used by betsy so they get the same addresses with the legacy layout
+	for _, contract := range contracts {
+		if contract.address == (common.Address{}) {
+			continue
+		}
+
+		initCode, err := contract.initCode(entryPoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build the %s init code: %w", contract.name, err)
+		}
+
+		if canonical {
+			initCode = append(contract.salt.Bytes(), initCode...)
+		}
+
+		files[contract.name+".bin"] = []byte(hexutil.Encode(initCode))
+		fmt.Fprintf(&script, "deploy %s %s\n", contract.name, contract.address.Hex())
+	}
+
+	// The smart accounts are deployed and deposited for like betsy does, once the factory and the EntryPoint exist
+	if len(options.SmartAccounts) > 0 {
+		fmt.Fprintf(&script, "\nENTRY_POINT=%s\nSIMPLE_ACCOUNT_FACTORY=%s\n", entryPoint.Hex(), options.PreDeployedContracts.SimpleAccountFactoryAddress.Hex())
+	}
+	for _, account := range options.SmartAccounts {
+		if account.Deployed {
+			fmt.Fprintf(&script, "create_smart_account %s %s %s\n", account.Address.Hex(), account.Owner.Hex(), account.Salt.String())
+		}
+		if account.Deposit != nil && account.Deposit.Sign() > 0 {
+			fmt.Fprintf(&script, "deposit %s %s\n", account.Address.Hex(), account.Deposit.String())
+		}
+		if account.Balance != nil && account.Balance.Sign() > 0 {
+			fmt.Fprintf(&script, "fund %s %s\n", account.Address.Hex(), account.Balance.String())
+		}
+	}
+
+	files["init.sh"] = script.Bytes()
+	return files, nil
+}
+
+// entryPointInitCode returns the creation code of the EntryPoint contract
+func entryPointInitCode(common.Address) ([]byte, error) {
+	return wallet.EntryPointV7InitCode()
+}
+
+// globalCounterInitCode returns the creation code of the GlobalCounter contract
+func globalCounterInitCode(common.Address) ([]byte, error) {
+	return wallet.GlobalCounterInitCode()
+}
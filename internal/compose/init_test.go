@@ -0,0 +1,78 @@
+package compose
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/transeptorlabs/betsy/wallet"
+)
+
+func TestNewInitFilesSmartAccounts(t *testing.T) {
+	deployed := wallet.SmartAccount{
+		Address:  common.HexToAddress("0x1"),
+		Owner:    common.HexToAddress("0xa"),
+		Salt:     big.NewInt(7),
+		Deployed: true,
+		Balance:  big.NewInt(5),
+		Deposit:  big.NewInt(3),
+	}
+	counterfactual := wallet.SmartAccount{
+		Address: common.HexToAddress("0x2"),
+		Owner:   common.HexToAddress("0xb"),
+		Salt:    big.NewInt(7),
+		Balance: big.NewInt(0),
+		Deposit: big.NewInt(0),
+	}
+
+	files, err := newInitFiles(Options{
+		FundedAccounts:        []common.Address{deployed.Owner, counterfactual.Owner},
+		Balance:               big.NewInt(10),
+		DeployerPrivateKeyHex: "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcc5d4c6b39c1ad9e7",
+		PreDeployedContracts: wallet.PreDeployedContracts{
+			EntryPointAddress:           wallet.CanonicalEntryPointV7Address,
+			SimpleAccountFactoryAddress: common.HexToAddress("0x91E60e0613810449d098b0b5Ec8b51A0FE8c8985"),
+		},
+		SmartAccounts: []wallet.SmartAccount{deployed, counterfactual},
+	})
+	if err != nil {
+		t.Fatalf("newInitFiles() error = %v", err)
+	}
+	script := string(files["init.sh"])
+
+	// The smart accounts are set up once the factory and the EntryPoint are deployed
+	want := []string{
+		"deploy SimpleAccountFactoryV7 0x91E60e0613810449d098b0b5Ec8b51A0FE8c8985",
+		"SIMPLE_ACCOUNT_FACTORY=0x91E60e0613810449d098b0b5Ec8b51A0FE8c8985",
+		"create_smart_account " + deployed.Address.Hex() + " " + deployed.Owner.Hex() + " 7",
+		"deposit " + deployed.Address.Hex() + " 3",
+		"fund " + deployed.Address.Hex() + " 5",
+	}
+	last := -1
+	for _, line := range want {
+		index := strings.Index(script, line+"\n")
+		if index < last {
+			t.Fatalf("init.sh = %s\nwant %q after the previous steps", script, line)
+		}
+		last = index
+	}
+
+	// The counterfactual account without balance nor deposit needs no step
+	if strings.Contains(script, counterfactual.Address.Hex()) {
+		t.Errorf("init.sh = %s\nwant no step for the counterfactual smart account %s", script, counterfactual.Address)
+	}
+}
+
+func TestNewInitFilesWithoutSmartAccounts(t *testing.T) {
+	files, err := newInitFiles(Options{
+		Balance:              big.NewInt(10),
+		PreDeployedContracts: wallet.PreDeployedContracts{EntryPointAddress: wallet.CanonicalEntryPointV7Address},
+	})
+	if err != nil {
+		t.Fatalf("newInitFiles() error = %v", err)
+	}
+	if strings.Contains(string(files["init.sh"]), "SIMPLE_ACCOUNT_FACTORY=") {
+		t.Errorf("init.sh = %s\nwant no smart account steps", files["init.sh"])
+	}
+}
@@ -0,0 +1,489 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/transeptorlabs/betsy/internal/utils"
+	"github.com/transeptorlabs/betsy/wallet"
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the config file schema version supported by this build of Betsy
+const CurrentVersion = 1
+
+// DefaultFileNames are the config file names Betsy looks for in the project directory
+var DefaultFileNames = []string{"betsy.yaml", "betsy.yml"}
+
+// Supported contracts that can be pre-deployed on start-up
+const (
+	ContractEntryPoint           = "entrypoint"
+	ContractSimpleAccountFactory = "simpleAccountFactory"
+	ContractGlobalCounter        = "globalCounter"
+)
+
+// Config contains the declarative definition of a Betsy environment
+type Config struct {
+	Version       int                 `yaml:"version"`
+	Runtime       string              `yaml:"runtime"` // docker or native
+	Log           LogConfig           `yaml:"log"`
+	HTTP          HTTPConfig          `yaml:"http"`
+	Images        ImagesConfig        `yaml:"images"`
+	Eth           EthConfig           `yaml:"eth"`
+	Bundler       BundlerConfig       `yaml:"bundler"`
+	Accounts      AccountsConfig      `yaml:"accounts"`
+	SmartAccounts SmartAccountsConfig `yaml:"smartAccounts"`
+	PreDeploy     PreDeployConfig     `yaml:"predeploy"`
+	Restart       RestartConfig       `yaml:"restart"`
+}
+
+// LogConfig contains the logger settings
+type LogConfig struct {
+	Level      string `yaml:"level"`
+	PersistDir string `yaml:"persistDir"`
+}
+
+// HTTPConfig contains the dashboard HTTP server settings
+type HTTPConfig struct {
+	Port  uint `yaml:"port"`
+	Debug bool `yaml:"debug"`
+}
+
+// ImagesConfig contains the container images provisioning settings
+type ImagesConfig struct {
+	PullPolicy string `yaml:"pullPolicy"`
+}
+
+// EthConfig contains the ETH node container settings
+type EthConfig struct {
+	Client  string       `yaml:"client"`
+	Image   string       `yaml:"image"`
+	Binary  string       `yaml:"binary"` // used by the native runtime
+	Port    uint         `yaml:"port"`
+	Args    []string     `yaml:"args"`
+	Persist string       `yaml:"persist"`
+	Mining  MiningConfig `yaml:"mining"`
+}
+
+// MiningConfig contains the block production settings of the dev chain
+type MiningConfig struct {
+	Mode      string        `yaml:"mode"`      // auto, interval or manual
+	BlockTime time.Duration `yaml:"blockTime"` // used by the interval mode
+}
+
+// BundlerConfig contains the ERC 4337 bundler container settings
+type BundlerConfig struct {
+	Name           string        `yaml:"name"`
+	Count          int           `yaml:"count"` // number of instances, instance N listens on port + N - 1
+	Image          string        `yaml:"image"`
+	Binary         string        `yaml:"binary"` // used by the native runtime
+	Port           uint          `yaml:"port"`
+	Args           []string      `yaml:"args"`
+	Bundling       string        `yaml:"bundling"`       // auto or manual
+	BundleInterval time.Duration `yaml:"bundleInterval"` // used by the auto bundling mode, zero keeps the bundler default
+	Safe           bool          `yaml:"safe"`           // full ERC-7562 validation
+	TxMode         string        `yaml:"txMode"`
+	MinStake       string        `yaml:"minStake"` // in ether, empty keeps the bundler default
+	MaxBundleGas   uint64        `yaml:"maxBundleGas"`
+}
+
+// AccountsConfig contains the dev accounts settings
+type AccountsConfig struct {
+	Mnemonic string         `yaml:"mnemonic"`
+	Count    int            `yaml:"count"`
+	Path     string         `yaml:"path"`     // derivation path template, {index} is replaced by the account index
+	Balance  string         `yaml:"balance"`  // in ether, sent to each account
+	Balances map[int]string `yaml:"balances"` // in ether by account index, overrides the balance of these accounts
+}
+
+// ParseBalances returns the default balance and the balances by account index in wei
+func (a AccountsConfig) ParseBalances() (*big.Int, map[int]*big.Int, error) {
+	balance, err := utils.ParseEther(a.Balance)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	balances := make(map[int]*big.Int, len(a.Balances))
+	for index, amount := range a.Balances {
+		balances[index], err = utils.ParseEther(amount)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return balance, balances, nil
+}
+
+// SmartAccountsConfig contains the settings of the SimpleAccount smart accounts created for the dev accounts
+type SmartAccountsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Deploy  bool   `yaml:"deploy"`  // deploy the accounts, otherwise only their counterfactual address is computed
+	Salt    uint64 `yaml:"salt"`    // salt passed to the SimpleAccountFactory
+	Balance string `yaml:"balance"` // in ether, sent to each smart account
+	Deposit string `yaml:"deposit"` // in ether, EntryPoint deposit of each smart account
+}
+
+// ParseAmounts returns the balance and the EntryPoint deposit of each smart account in wei
+func (s SmartAccountsConfig) ParseAmounts() (*big.Int, *big.Int, error) {
+	balance, err := utils.ParseEther(s.Balance)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	deposit, err := utils.ParseEther(s.Deposit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return balance, deposit, nil
+}
+
+// PreDeployConfig contains the list of contracts to deploy on start-up
+type PreDeployConfig struct {
+	Contracts []string `yaml:"contracts"`
+	Layout    string   `yaml:"layout"` // canonical (CREATE2) or legacy (nonce based) addresses
+}
+
+// RestartConfig contains the restart policy applied to the containers that crash
+type RestartConfig struct {
+	MaxRestarts int           `yaml:"maxRestarts"` // per container, 0 disables restarts
+	Backoff     time.Duration `yaml:"backoff"`     // delay before the first restart, doubled after each restart
+	MaxBackoff  time.Duration `yaml:"maxBackoff"`
+}
+
+// Default returns the config used when no config file is found
+func Default() *Config {
+	return &Config{
+		Version: CurrentVersion,
+		Runtime: "docker",
+		Log: LogConfig{
+			Level: "INFO",
+		},
+		HTTP: HTTPConfig{
+			Port:  8080,
+			Debug: false,
+		},
+		Images: ImagesConfig{
+			PullPolicy: "missing",
+		},
+		Eth: EthConfig{
+			Client: "geth",
+			Port:   8545,
+			Mining: MiningConfig{
+				Mode: "auto",
+			},
+		},
+		Bundler: BundlerConfig{
+			Name:     "transeptor",
+			Count:    1,
+			Port:     4337,
+			Bundling: "auto",
+		},
+		Accounts: AccountsConfig{
+			Mnemonic: wallet.DefaultSeedPhrase,
+			Path:     wallet.DefaultDerivationPath,
+			Count:    10,
+			Balance:  "4337",
+		},
+		SmartAccounts: SmartAccountsConfig{
+			Balance: "0",
+			Deposit: "0",
+		},
+		PreDeploy: PreDeployConfig{
+			Contracts: []string{
+				ContractEntryPoint,
+				ContractSimpleAccountFactory,
+				ContractGlobalCounter,
+			},
+			Layout: wallet.LayoutCanonical,
+		},
+		Restart: RestartConfig{
+			MaxRestarts: 5,
+			Backoff:     time.Second,
+			MaxBackoff:  30 * time.Second,
+		},
+	}
+}
+
+// Discover returns the path of the config file found in dir, or an empty string if there is none
+func Discover(dir string) (string, error) {
+	for _, name := range DefaultFileNames {
+		path := filepath.Join(dir, name)
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+
+	return "", nil
+}
+
+// Load reads the config file at path on top of the defaults, an empty path returns the defaults
+func Load(path string) (*Config, error) {
+	cfg := Default()
+	if path == "" {
+		return cfg, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	if err := decode(content, cfg); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return cfg, nil
+}
+
+// decode strictly decodes the yaml content into cfg rejecting unknown keys
+func decode(content []byte, cfg *Config) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	err := decoder.Decode(cfg)
+	if err != nil && err != io.EOF {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			return errors.New(strings.Join(typeErr.Errors, "; "))
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Validate checks that all config values are usable
+func (c *Config) Validate() error {
+	var errs []string
+
+	if c.Version != CurrentVersion {
+		errs = append(errs, fmt.Sprintf("version: unsupported config version %d (expected %d)", c.Version, CurrentVersion))
+	}
+
+	switch c.Runtime {
+	case "docker":
+		if c.Eth.Binary != "" {
+			errs = append(errs, "eth.binary: only used by the native runtime")
+		}
+		if c.Bundler.Binary != "" {
+			errs = append(errs, "bundler.binary: only used by the native runtime")
+		}
+	case "native":
+		if c.Eth.Image != "" {
+			errs = append(errs, "eth.image: not used by the native runtime, set eth.binary instead")
+		}
+		if c.Bundler.Image != "" {
+			errs = append(errs, "bundler.image: not used by the native runtime, set bundler.binary instead")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("runtime: %q must be one of docker, native", c.Runtime))
+	}
+
+	ports := []struct {
+		key  string
+		port uint
+	}{
+		{"http.port", c.HTTP.Port},
+		{"eth.port", c.Eth.Port},
+		{"bundler.port", c.Bundler.Port},
+	}
+	for _, item := range ports {
+		if item.port == 0 || item.port > 65535 {
+			errs = append(errs, fmt.Sprintf("%s: %d is not a valid port", item.key, item.port))
+		}
+	}
+
+	switch c.Images.PullPolicy {
+	case "always", "missing", "never":
+	default:
+		errs = append(errs, fmt.Sprintf("images.pullPolicy: %q must be one of always, missing, never", c.Images.PullPolicy))
+	}
+
+	if c.Eth.Client == "" {
+		errs = append(errs, "eth.client: must not be empty")
+	}
+
+	switch c.Eth.Mining.Mode {
+	case "auto", "manual":
+		if c.Eth.Mining.BlockTime != 0 {
+			errs = append(errs, fmt.Sprintf("eth.mining.blockTime: only used by the interval mode, the mode is %s", c.Eth.Mining.Mode))
+		}
+	case "interval":
+		if c.Eth.Mining.BlockTime < time.Second || c.Eth.Mining.BlockTime%time.Second != 0 {
+			errs = append(errs, fmt.Sprintf("eth.mining.blockTime: %s must be a whole number of seconds of at least 1s", c.Eth.Mining.BlockTime))
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("eth.mining.mode: %q must be one of auto, interval, manual", c.Eth.Mining.Mode))
+	}
+
+	if c.Bundler.Name == "" {
+		errs = append(errs, "bundler.name: must not be empty")
+	}
+
+	if c.Bundler.Count < 1 {
+		errs = append(errs, fmt.Sprintf("bundler.count: %d must be at least 1", c.Bundler.Count))
+	} else if c.Bundler.Port+uint(c.Bundler.Count)-1 > 65535 {
+		errs = append(errs, fmt.Sprintf("bundler.count: %d instances starting at port %d exceed port 65535", c.Bundler.Count, c.Bundler.Port))
+	}
+
+	errs = append(errs, c.Bundler.validateOptions()...)
+
+	if len(strings.Fields(c.Accounts.Mnemonic)) < 12 {
+		errs = append(errs, "accounts.mnemonic: must contain at least 12 words")
+	}
+
+	if c.Accounts.Count < 1 {
+		errs = append(errs, fmt.Sprintf("accounts.count: %d must be at least 1", c.Accounts.Count))
+	}
+
+	if _, err := wallet.AccountPath(c.Accounts.Path, 0); err != nil {
+		errs = append(errs, "accounts.path: "+err.Error())
+	}
+
+	balanceKey := "accounts.balance"
+	balance, err := utils.ParseEther(c.Accounts.Balance)
+	if err != nil {
+		errs = append(errs, "accounts.balance: "+err.Error())
+	}
+
+	indexes := make([]int, 0, len(c.Accounts.Balances))
+	for index := range c.Accounts.Balances {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		amount := c.Accounts.Balances[index]
+		if index < 0 || index >= c.Accounts.Count {
+			errs = append(errs, fmt.Sprintf("accounts.balances: account %d is out of the %d dev accounts", index, c.Accounts.Count))
+		}
+		accountBalance, err := utils.ParseEther(amount)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("accounts.balances[%d]: %s", index, err.Error()))
+		}
+		if index == 0 {
+			balanceKey, balance = "accounts.balances[0]", accountBalance
+		}
+	}
+
+	// Dev account 0 deploys the contracts and signs for the first bundler with the default path, it cannot be left unfunded
+	if balance != nil && balance.Sign() == 0 {
+		errs = append(errs, balanceKey+": dev account 0 deploys the contracts and signs bundles, its balance must not be zero")
+	}
+
+	if _, err := utils.ParseEther(c.SmartAccounts.Balance); err != nil {
+		errs = append(errs, "smartAccounts.balance: "+err.Error())
+	}
+	if _, err := utils.ParseEther(c.SmartAccounts.Deposit); err != nil {
+		errs = append(errs, "smartAccounts.deposit: "+err.Error())
+	}
+	if c.SmartAccounts.Enabled && !c.PreDeploy.Has(ContractSimpleAccountFactory) {
+		errs = append(errs, fmt.Sprintf("smartAccounts.enabled: smart accounts need the %q contract in predeploy.contracts", ContractSimpleAccountFactory))
+	}
+
+	errs = append(errs, c.PreDeploy.validate()...)
+
+	if c.Restart.MaxRestarts < 0 {
+		errs = append(errs, fmt.Sprintf("restart.maxRestarts: %d must not be negative", c.Restart.MaxRestarts))
+	}
+	if c.Restart.Backoff <= 0 {
+		errs = append(errs, fmt.Sprintf("restart.backoff: %s must be positive", c.Restart.Backoff))
+	}
+	if c.Restart.MaxBackoff < c.Restart.Backoff {
+		errs = append(errs, fmt.Sprintf("restart.maxBackoff: %s must be at least the backoff %s", c.Restart.MaxBackoff, c.Restart.Backoff))
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// validateOptions checks the bundler runtime options, the bundler specific support is checked when the bundler is configured
+func (b BundlerConfig) validateOptions() []string {
+	var errs []string
+
+	switch b.Bundling {
+	case "auto":
+	case "manual":
+		if b.BundleInterval != 0 {
+			errs = append(errs, "bundler.bundleInterval: only used by the auto bundling mode, the mode is manual")
+		}
+	default:
+		errs = append(errs, fmt.Sprintf("bundler.bundling: %q must be one of auto, manual", b.Bundling))
+	}
+
+	if b.BundleInterval < 0 || (b.BundleInterval > 0 && b.BundleInterval < time.Millisecond) {
+		errs = append(errs, fmt.Sprintf("bundler.bundleInterval: %s must be at least 1ms", b.BundleInterval))
+	}
+
+	if b.MinStake != "" {
+		if _, err := utils.ParseEther(b.MinStake); err != nil {
+			errs = append(errs, "bundler.minStake: "+err.Error())
+		}
+	}
+
+	// Custom args replace the bundler command the options are added to
+	options := b.Bundling != "auto" || b.BundleInterval != 0 || b.Safe || b.TxMode != "" || b.MinStake != "" || b.MaxBundleGas != 0
+	if len(b.Args) > 0 && options {
+		errs = append(errs, "bundler.args: replaces the bundler command and cannot be combined with the bundler options")
+	}
+
+	return errs
+}
+
+// validate checks the pre-deploy contract names and their dependencies
+func (p PreDeployConfig) validate() []string {
+	var errs []string
+	supported := map[string]bool{
+		ContractEntryPoint:           true,
+		ContractSimpleAccountFactory: true,
+		ContractGlobalCounter:        true,
+	}
+
+	for _, contract := range p.Contracts {
+		if !supported[contract] {
+			errs = append(errs, fmt.Sprintf("predeploy.contracts: unknown contract %q", contract))
+		}
+	}
+
+	if !p.Has(ContractEntryPoint) {
+		errs = append(errs, fmt.Sprintf("predeploy.contracts: %q is required by the bundler", ContractEntryPoint))
+	}
+
+	if !slices.Contains(wallet.Layouts(), p.Layout) {
+		errs = append(errs, fmt.Sprintf("predeploy.layout: %q must be one of %s", p.Layout, strings.Join(wallet.Layouts(), ", ")))
+	}
+
+	return errs
+}
+
+// Has checks if a contract is in the pre-deploy list
+func (p PreDeployConfig) Has(contract string) bool {
+	for _, item := range p.Contracts {
+		if item == contract {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/transeptorlabs/betsy/wallet"
+)
+
+// writeConfig writes a config file in a temporary directory and returns its path
+func writeConfig(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	return path
+}
+
+func TestDefault(t *testing.T) {
+	cfg := Default()
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Default().Validate() error = %v", err)
+	}
+
+	if cfg.Eth.Client != "geth" || cfg.Bundler.Name != "transeptor" || cfg.Runtime != "docker" {
+		t.Errorf("Default() = %s with %s on %s, want geth with transeptor on docker", cfg.Eth.Client, cfg.Bundler.Name, cfg.Runtime)
+	}
+	if cfg.Accounts.Mnemonic != wallet.DefaultSeedPhrase || cfg.Accounts.Count != 10 {
+		t.Errorf("Default() accounts = %+v, want 10 accounts of the default mnemonic", cfg.Accounts)
+	}
+	for _, contract := range []string{ContractEntryPoint, ContractSimpleAccountFactory, ContractGlobalCounter} {
+		if !cfg.PreDeploy.Has(contract) {
+			t.Errorf("Default() does not pre-deploy %s", contract)
+		}
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := Discover(dir)
+	if err != nil || path != "" {
+		t.Errorf("Discover() without config file = %q, %v, want no path", path, err)
+	}
+
+	// betsy.yml is found when there is no betsy.yaml, betsy.yaml comes first
+	for _, name := range []string{"betsy.yml", "betsy.yaml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("version: 1\n"), 0o644); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+
+		path, err := Discover(dir)
+		if err != nil || path != filepath.Join(dir, name) {
+			t.Errorf("Discover() = %q, %v, want %s", path, err, name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	cfg, err := Load("")
+	if err != nil || cfg.Eth.Port != Default().Eth.Port {
+		t.Fatalf("Load() without path = %+v, %v, want the defaults", cfg, err)
+	}
+
+	// The file is read on top of the defaults
+	path := writeConfig(t, "betsy.yaml", "version: 1\neth:\n  client: anvil\n  mining:\n    mode: interval\n    blockTime: 2s\naccounts:\n  count: 3\n")
+	cfg, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Eth.Client != "anvil" || cfg.Eth.Mining.BlockTime != 2*time.Second || cfg.Accounts.Count != 3 {
+		t.Errorf("Load() = %+v, want the values of the file", cfg)
+	}
+	if cfg.Eth.Port != 8545 || cfg.Bundler.Name != "transeptor" {
+		t.Errorf("Load() = %+v, want the defaults of the keys missing from the file", cfg)
+	}
+
+	// An empty file keeps the defaults
+	if _, err := Load(writeConfig(t, "betsy.yaml", "")); err != nil {
+		t.Errorf("Load() of an empty file error = %v", err)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil || !strings.Contains(err.Error(), "failed to read config file") {
+		t.Errorf("Load() of a missing file error = %v, want a read error", err)
+	}
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"unknown key", "version: 1\neth:\n  clinet: anvil\n", "field clinet not found"},
+		{"unknown section", "version: 1\nnodes:\n  eth: geth\n", "field nodes not found"},
+		{"wrong type", "version: 1\nhttp:\n  port: eighty\n", "cannot unmarshal"},
+		{"invalid value", "version: 1\neth:\n  port: 70000\n", "eth.port: 70000 is not a valid port"},
+		{"unsupported version", "version: 2\n", "unsupported config version 2"},
+	}
+	for _, test := range tests {
+		path := writeConfig(t, "betsy.yaml", test.content)
+		_, err := Load(path)
+		if err == nil || !strings.Contains(err.Error(), test.want) || !strings.Contains(err.Error(), path) {
+			t.Errorf("Load() of a file with an %s error = %v, want %q with the file path", test.name, err, test.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(cfg *Config)
+		want   string
+	}{
+		{"version", func(cfg *Config) { cfg.Version = 0 }, "version: unsupported config version 0"},
+		{"runtime", func(cfg *Config) { cfg.Runtime = "podman" }, `runtime: "podman" must be one of docker, native`},
+		{"docker eth binary", func(cfg *Config) { cfg.Eth.Binary = "geth" }, "eth.binary: only used by the native runtime"},
+		{"docker bundler binary", func(cfg *Config) { cfg.Bundler.Binary = "transeptor" }, "bundler.binary: only used by the native runtime"},
+		{"native eth image", func(cfg *Config) { cfg.Runtime = "native"; cfg.Eth.Image = "geth" }, "eth.image: not used by the native runtime"},
+		{"native bundler image", func(cfg *Config) { cfg.Runtime = "native"; cfg.Bundler.Image = "transeptor" }, "bundler.image: not used by the native runtime"},
+		{"http port", func(cfg *Config) { cfg.HTTP.Port = 0 }, "http.port: 0 is not a valid port"},
+		{"eth port", func(cfg *Config) { cfg.Eth.Port = 65536 }, "eth.port: 65536 is not a valid port"},
+		{"bundler port", func(cfg *Config) { cfg.Bundler.Port = 0 }, "bundler.port: 0 is not a valid port"},
+		{"pull policy", func(cfg *Config) { cfg.Images.PullPolicy = "sometimes" }, `images.pullPolicy: "sometimes" must be one of always, missing, never`},
+		{"eth client", func(cfg *Config) { cfg.Eth.Client = "" }, "eth.client: must not be empty"},
+		{"block time without interval", func(cfg *Config) { cfg.Eth.Mining.BlockTime = time.Second }, "eth.mining.blockTime: only used by the interval mode"},
+		{"interval without block time", func(cfg *Config) { cfg.Eth.Mining.Mode = "interval" }, "eth.mining.blockTime: 0s must be a whole number of seconds"},
+		{"fractional block time", func(cfg *Config) {
+			cfg.Eth.Mining = MiningConfig{Mode: "interval", BlockTime: 1500 * time.Millisecond}
+		}, "eth.mining.blockTime: 1.5s must be a whole number of seconds"},
+		{"mining mode", func(cfg *Config) { cfg.Eth.Mining.Mode = "sometimes" }, `eth.mining.mode: "sometimes" must be one of auto, interval, manual`},
+		{"bundler name", func(cfg *Config) { cfg.Bundler.Name = "" }, "bundler.name: must not be empty"},
+		{"bundler count", func(cfg *Config) { cfg.Bundler.Count = 0 }, "bundler.count: 0 must be at least 1"},
+		{"bundler ports", func(cfg *Config) { cfg.Bundler.Port = 65535; cfg.Bundler.Count = 2 }, "bundler.count: 2 instances starting at port 65535 exceed port 65535"},
+		{"bundling mode", func(cfg *Config) { cfg.Bundler.Bundling = "never" }, `bundler.bundling: "never" must be one of auto, manual`},
+		{"manual bundling interval", func(cfg *Config) {
+			cfg.Bundler.Bundling = "manual"
+			cfg.Bundler.BundleInterval = time.Second
+		}, "bundler.bundleInterval: only used by the auto bundling mode"},
+		{"bundle interval", func(cfg *Config) { cfg.Bundler.BundleInterval = time.Microsecond }, "bundler.bundleInterval: 1µs must be at least 1ms"},
+		{"min stake", func(cfg *Config) { cfg.Bundler.MinStake = "a lot" }, "bundler.minStake: "},
+		{"args with options", func(cfg *Config) {
+			cfg.Bundler.Args = []string{"--port", "4337"}
+			cfg.Bundler.Safe = true
+		}, "bundler.args: replaces the bundler command"},
+		{"mnemonic", func(cfg *Config) { cfg.Accounts.Mnemonic = "test test junk" }, "accounts.mnemonic: must contain at least 12 words"},
+		{"account count", func(cfg *Config) { cfg.Accounts.Count = 0 }, "accounts.count: 0 must be at least 1"},
+		{"account path", func(cfg *Config) { cfg.Accounts.Path = "m/44'/60'/0'/0/0" }, "accounts.path: "},
+		{"account balance", func(cfg *Config) { cfg.Accounts.Balance = "-1" }, "accounts.balance: "},
+		{"balance index", func(cfg *Config) { cfg.Accounts.Balances = map[int]string{10: "1"} }, "accounts.balances: account 10 is out of the 10 dev accounts"},
+		{"balance amount", func(cfg *Config) { cfg.Accounts.Balances = map[int]string{1: "one"} }, "accounts.balances[1]: "},
+		{"account 0 balance", func(cfg *Config) { cfg.Accounts.Balances = map[int]string{0: "0"} }, "accounts.balances[0]: dev account 0 deploys the contracts"},
+		{"default balance of account 0", func(cfg *Config) { cfg.Accounts.Balance = "0" }, "accounts.balance: dev account 0 deploys the contracts"},
+		{"smart account balance", func(cfg *Config) { cfg.SmartAccounts.Balance = "one" }, "smartAccounts.balance: "},
+		{"smart account deposit", func(cfg *Config) { cfg.SmartAccounts.Deposit = "one" }, "smartAccounts.deposit: "},
+		{"smart accounts without factory", func(cfg *Config) {
+			cfg.SmartAccounts.Enabled = true
+			cfg.PreDeploy.Contracts = []string{ContractEntryPoint}
+		}, `smartAccounts.enabled: smart accounts need the "simpleAccountFactory" contract`},
+		{"unknown contract", func(cfg *Config) {
+			cfg.PreDeploy.Contracts = append(cfg.PreDeploy.Contracts, "paymaster")
+		}, `predeploy.contracts: unknown contract "paymaster"`},
+		{"no entrypoint", func(cfg *Config) { cfg.PreDeploy.Contracts = []string{ContractGlobalCounter} }, `predeploy.contracts: "entrypoint" is required by the bundler`},
+		{"layout", func(cfg *Config) { cfg.PreDeploy.Layout = "random" }, `predeploy.layout: "random" must be one of`},
+		{"max restarts", func(cfg *Config) { cfg.Restart.MaxRestarts = -1 }, "restart.maxRestarts: -1 must not be negative"},
+		{"backoff", func(cfg *Config) { cfg.Restart.Backoff = 0 }, "restart.backoff: 0s must be positive"},
+		{"max backoff", func(cfg *Config) { cfg.Restart.MaxBackoff = time.Millisecond }, "restart.maxBackoff: 1ms must be at least the backoff 1s"},
+	}
+	for _, test := range tests {
+		cfg := Default()
+		test.change(cfg)
+
+		err := cfg.Validate()
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("Validate() with an invalid %s error = %v, want %q", test.name, err, test.want)
+		}
+	}
+}
+
+func TestValidateAccountBalances(t *testing.T) {
+	// Only dev account 0 needs a balance, the others can be left unfunded
+	cfg := Default()
+	cfg.Accounts.Balance = "0"
+	cfg.Accounts.Balances = map[int]string{0: "1", 1: "0"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with a funded account 0 error = %v", err)
+	}
+}
+
+func TestValidateReportsEveryError(t *testing.T) {
+	cfg := Default()
+	cfg.Eth.Port = 0
+	cfg.Accounts.Count = 0
+
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "eth.port") || !strings.Contains(err.Error(), "accounts.count") {
+		t.Errorf("Validate() error = %v, want both invalid values", err)
+	}
+}
@@ -0,0 +1,149 @@
+package docker
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bundling modes of a bundler
+const (
+	BundlingAuto   = "auto"   // the bundler sends bundles on its own
+	BundlingManual = "manual" // bundles are only sent on demand with debug_bundler_sendBundleNow
+)
+
+// BundlingModes returns the supported bundling modes
+func BundlingModes() []string {
+	return []string{BundlingAuto, BundlingManual}
+}
+
+// BundlerOptions contains the runtime options of a bundler, zero values keep the bundler default
+type BundlerOptions struct {
+	Bundling       string
+	BundleInterval time.Duration
+	Safe           bool // full ERC-7562 validation, which traces the userOps on the eth node
+	TxMode         string
+	MinStake       *big.Int // in wei
+	MaxBundleGas   uint64
+}
+
+// BundlerOptionsDefinition maps the runtime options of a bundler onto its container args, nil fields mark unsupported options
+type BundlerOptionsDefinition struct {
+	Defaults         BundlerOptions
+	SafetyArgs       func(safe bool) []string
+	BundlingArgs     map[string][]string // args keyed by bundling mode
+	IntervalArgs     func(interval time.Duration) []string
+	TxModes          []string
+	TxModeArgs       func(txMode string) []string
+	MinStakeArgs     func(minStake *big.Int) []string
+	MaxBundleGasArgs func(maxBundleGas uint64) []string
+}
+
+// milliseconds formats a bundle interval as whole milliseconds
+func milliseconds(interval time.Duration) string {
+	return strconv.FormatInt(interval.Milliseconds(), 10)
+}
+
+// ether formats a stake in wei as ether
+func ether(wei *big.Int) string {
+	value := new(big.Float).SetPrec(256).SetInt(wei)
+	return value.Quo(value, big.NewFloat(1e18)).Text('f', -1)
+}
+
+// ConfigureBundler checks that a bundler supports the options and the eth client, and adds the args they need to the container command
+func (cm *ContainerManager) ConfigureBundler(bundler string, ethClient string, options BundlerOptions) error {
+	imageFound, ok := cm.supportedImages[bundler]
+	if !ok || imageFound.NodeType != "bundler" {
+		return fmt.Errorf("Bundler %s is not supported", bundler)
+	}
+
+	ethNode, ok := cm.supportedImages[ethClient]
+	if !ok {
+		return fmt.Errorf("Image %s is not supported", ethClient)
+	}
+
+	definition := imageFound.BundlerOptions
+	options = withBundlerDefaults(options, definition.Defaults)
+
+	if options.Safe && !ethNode.DebugTracer {
+		return fmt.Errorf("bundler safe mode traces userOps with debug_traceCall, which execution client %s does not support", ethClient)
+	}
+
+	args := definition.SafetyArgs(options.Safe)
+
+	switch options.Bundling {
+	case BundlingAuto, BundlingManual:
+		bundlingArgs, ok := definition.BundlingArgs[options.Bundling]
+		if !ok && options.Bundling == BundlingManual {
+			return fmt.Errorf("bundler %s does not support manual bundling", bundler)
+		}
+		args = append(args, bundlingArgs...)
+	default:
+		return fmt.Errorf("Bundling mode %s is not supported, choose one of: %s", options.Bundling, strings.Join(BundlingModes(), ", "))
+	}
+
+	if options.BundleInterval > 0 {
+		if options.Bundling == BundlingManual {
+			return fmt.Errorf("a bundle interval cannot be used with manual bundling")
+		}
+		if definition.IntervalArgs == nil {
+			return fmt.Errorf("bundler %s does not support setting the bundle interval", bundler)
+		}
+		args = append(args, definition.IntervalArgs(options.BundleInterval)...)
+	}
+
+	if options.TxMode != "" {
+		if !slices.Contains(definition.TxModes, options.TxMode) {
+			if len(definition.TxModes) == 0 {
+				return fmt.Errorf("bundler %s does not support selecting the tx mode", bundler)
+			}
+			return fmt.Errorf("Tx mode %s is not supported by bundler %s, choose one of: %s", options.TxMode, bundler, strings.Join(definition.TxModes, ", "))
+		}
+		args = append(args, definition.TxModeArgs(options.TxMode)...)
+	}
+
+	if options.MinStake != nil {
+		if definition.MinStakeArgs == nil {
+			return fmt.Errorf("bundler %s does not support setting the minimum stake", bundler)
+		}
+		args = append(args, definition.MinStakeArgs(options.MinStake)...)
+	}
+
+	if options.MaxBundleGas > 0 {
+		if definition.MaxBundleGasArgs == nil {
+			return fmt.Errorf("bundler %s does not support setting the maximum bundle gas", bundler)
+		}
+		args = append(args, definition.MaxBundleGasArgs(options.MaxBundleGas)...)
+	}
+
+	imageFound.Cmd = appendArgs(imageFound.Cmd, imageFound.ShellCommand, args)
+	cm.supportedImages[bundler] = imageFound
+	return nil
+}
+
+// withBundlerDefaults fills the options left unset with the defaults of the bundler definition
+func withBundlerDefaults(options BundlerOptions, defaults BundlerOptions) BundlerOptions {
+	if options.Bundling == "" {
+		options.Bundling = defaults.Bundling
+	}
+	if options.Bundling == "" {
+		options.Bundling = BundlingAuto
+	}
+	if options.BundleInterval == 0 && options.Bundling == defaults.Bundling {
+		options.BundleInterval = defaults.BundleInterval
+	}
+	if options.TxMode == "" {
+		options.TxMode = defaults.TxMode
+	}
+	if options.MinStake == nil {
+		options.MinStake = defaults.MinStake
+	}
+	if options.MaxBundleGas == 0 {
+		options.MaxBundleGas = defaults.MaxBundleGas
+	}
+
+	return options
+}
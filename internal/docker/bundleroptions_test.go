@@ -0,0 +1,83 @@
+package docker
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestConfigureBundlerDefaults(t *testing.T) {
+	cm, _ := newTestManager(t)
+
+	if err := cm.ConfigureBundler("transeptor", "geth", BundlerOptions{}); err != nil {
+		t.Fatalf("ConfigureBundler(transeptor) error = %v", err)
+	}
+
+	cmd := cm.supportedImages["transeptor"].Cmd
+	want := []string{"--unsafe", "--auto", "--autoBundleInterval", "10000", "--txMode", "base"}
+	if !slices.Equal(cmd[len(cmd)-len(want):], want) {
+		t.Errorf("transeptor Cmd = %v, want the default options %v appended", cmd, want)
+	}
+}
+
+func TestConfigureBundlerOptions(t *testing.T) {
+	cm, _ := newTestManager(t)
+
+	err := cm.ConfigureBundler("transeptor", "geth", BundlerOptions{
+		Bundling:     BundlingManual,
+		Safe:         true,
+		TxMode:       "searcher",
+		MinStake:     big.NewInt(5e17),
+		MaxBundleGas: 3_000_000,
+	})
+	if err != nil {
+		t.Fatalf("ConfigureBundler(transeptor) error = %v", err)
+	}
+
+	cmd := cm.supportedImages["transeptor"].Cmd
+	want := []string{"--txMode", "searcher", "--minStake", "0.5", "--maxBundleGas", "3000000"}
+	if !slices.Equal(cmd[len(cmd)-len(want):], want) {
+		t.Errorf("transeptor Cmd = %v, want %v appended", cmd, want)
+	}
+	for _, arg := range []string{"--unsafe", "--auto", "--autoBundleInterval"} {
+		if slices.Contains(cmd, arg) {
+			t.Errorf("transeptor Cmd = %v, want no %s in safe manual mode", cmd, arg)
+		}
+	}
+
+	if err := cm.ConfigureBundler("alto", "geth", BundlerOptions{Safe: true}); err != nil {
+		t.Fatalf("ConfigureBundler(alto) error = %v", err)
+	}
+	altoCmd := cm.supportedImages["alto"].Cmd
+	if !slices.Equal(altoCmd[len(altoCmd)-4:], []string{"--safe-mode", "true", "--bundle-mode", "auto"}) {
+		t.Errorf("alto Cmd = %v, want safe mode and auto bundling", altoCmd)
+	}
+}
+
+func TestConfigureBundlerUnsupportedOptions(t *testing.T) {
+	cm, _ := newTestManager(t)
+
+	tests := []struct {
+		bundler   string
+		ethClient string
+		options   BundlerOptions
+	}{
+		{"transeptor", "anvil", BundlerOptions{Safe: true}},
+		{"transeptor", "geth", BundlerOptions{Bundling: BundlingManual, BundleInterval: time.Second}},
+		{"transeptor", "geth", BundlerOptions{TxMode: "fast"}},
+		{"transeptor", "geth", BundlerOptions{Bundling: "sometimes"}},
+		{"rundler", "geth", BundlerOptions{Bundling: BundlingManual}},
+		{"skandha", "geth", BundlerOptions{MaxBundleGas: 1_000_000}},
+		{"voltaire", "geth", BundlerOptions{TxMode: "base"}},
+	}
+	for _, test := range tests {
+		cmd := cm.supportedImages[test.bundler].Cmd
+		if err := cm.ConfigureBundler(test.bundler, test.ethClient, test.options); err == nil {
+			t.Errorf("ConfigureBundler(%s, %s, %+v) succeeded", test.bundler, test.ethClient, test.options)
+		}
+		if !slices.Equal(cm.supportedImages[test.bundler].Cmd, cmd) {
+			t.Errorf("%s Cmd changed to %v by rejected options", test.bundler, cm.supportedImages[test.bundler].Cmd)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package docker
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const BundlerNodeChainIDPlaceHolder = "$CHAIN_ID"
+const BundlerNodePrivateKeyPlaceHolder = "$BUNDLER_PRIVATE_KEY"
+
+// ReadinessProbe describes the JSON-RPC call used to check that a node is ready to serve requests
+type ReadinessProbe struct {
+	Method   string
+	Params   []interface{}
+	Interval time.Duration
+	Timeout  time.Duration
+	Retries  int
+}
+
+// bundlerDefinitions contains the registry of supported ERC 4337 bundlers keyed by the name used with the --bundler flag
+var bundlerDefinitions = map[string]ContainerDetails{
+	"transeptor": {
+		containerName: "betsy-transeptor",
+		imageName:     "transeptorlabs/bundler:0.6.2-alpha.0", // Betsy Ross - https://github.com/transeptorlabs/transeptor-bundler/releases/tag/v0.6.2-alpha.0
+		Binary:        []string{"transeptor"},
+		Cmd: []string{
+			"--httpApi", "web3,eth,debug",
+			"--port", ListenPortPlaceHolder,
+			"--network", EthNodeRPCURLPlaceHolder,
+		},
+		Env: []string{
+			"TRANSEPTOR_MNEMONIC=" + BundlerNodeMnemonicPlaceHolder,
+			"TRANSEPTOR_BENEFICIARY=" + BundlerNodeBeneficiaryAddressPlaceHolder,
+			"TRANSEPTOR_ENTRYPOINT_ADDRESS=" + BundlerNodeEPAddressPlaceHolder,
+		},
+		Variables: []string{
+			ListenPortPlaceHolder,
+			EthNodeRPCURLPlaceHolder,
+			BundlerNodeMnemonicPlaceHolder,
+			BundlerNodeBeneficiaryAddressPlaceHolder,
+			BundlerNodeEPAddressPlaceHolder,
+		},
+		ContainerPort:  "4337",
+		RPCPath:        "/rpc",
+		ReadinessProbe: ReadinessProbe{Method: "eth_supportedEntryPoints"},
+		NodeType:       "bundler",
+		BundlerOptions: BundlerOptionsDefinition{
+			Defaults:   BundlerOptions{Bundling: BundlingAuto, BundleInterval: 10 * time.Second, TxMode: "base"},
+			SafetyArgs: unsafeFlag("--unsafe"),
+			BundlingArgs: map[string][]string{
+				BundlingAuto:   {"--auto"},
+				BundlingManual: {},
+			},
+			IntervalArgs: func(interval time.Duration) []string {
+				return []string{"--autoBundleInterval", milliseconds(interval)}
+			},
+			TxModes: []string{"base", "searcher", "conditional"},
+			TxModeArgs: func(txMode string) []string {
+				return []string{"--txMode", txMode}
+			},
+			MinStakeArgs: func(minStake *big.Int) []string {
+				return []string{"--minStake", ether(minStake)}
+			},
+			MaxBundleGasArgs: func(maxBundleGas uint64) []string {
+				return []string{"--maxBundleGas", strconv.FormatUint(maxBundleGas, 10)}
+			},
+		},
+	},
+	"alto": {
+		containerName: "betsy-alto",
+		imageName:     "ghcr.io/pimlicolabs/alto:v1.2.1", // https://github.com/pimlicolabs/alto
+		Binary:        []string{"alto"},
+		Cmd: []string{
+			"--entrypoints", BundlerNodeEPAddressPlaceHolder,
+			"--executor-private-keys", BundlerNodePrivateKeyPlaceHolder,
+			"--utility-private-key", BundlerNodePrivateKeyPlaceHolder,
+			"--rpc-url", EthNodeRPCURLPlaceHolder,
+			"--port", ListenPortPlaceHolder,
+			"--enable-debug-endpoints", "true",
+			"--deploy-simulations-contract", "true",
+		},
+		Env: []string{},
+		Variables: []string{
+			ListenPortPlaceHolder,
+			EthNodeRPCURLPlaceHolder,
+			BundlerNodeEPAddressPlaceHolder,
+			BundlerNodePrivateKeyPlaceHolder,
+		},
+		ContainerPort:  "3000",
+		RPCPath:        "/rpc",
+		ReadinessProbe: ReadinessProbe{Method: "eth_supportedEntryPoints", Retries: 120},
+		NodeType:       "bundler",
+		BundlerOptions: BundlerOptionsDefinition{
+			SafetyArgs: func(safe bool) []string {
+				return []string{"--safe-mode", strconv.FormatBool(safe)}
+			},
+			BundlingArgs: map[string][]string{
+				BundlingAuto:   {"--bundle-mode", "auto"},
+				BundlingManual: {"--bundle-mode", "manual"},
+			},
+			MinStakeArgs: func(minStake *big.Int) []string {
+				return []string{"--min-entity-stake", ether(minStake)}
+			},
+			MaxBundleGasArgs: func(maxBundleGas uint64) []string {
+				return []string{"--max-gas-per-bundle", strconv.FormatUint(maxBundleGas, 10)}
+			},
+		},
+	},
+	"rundler": {
+		containerName: "betsy-rundler",
+		imageName:     "alchemyplatform/rundler:v0.2.2", // https://github.com/alchemyplatform/rundler
+		Binary:        []string{"rundler"},
+		Cmd: []string{
+			"node",
+			"--rpc.port", ListenPortPlaceHolder,
+			"--rpc.api", "eth,debug",
+		},
+		Env: []string{
+			"NODE_HTTP=" + EthNodeRPCURLPlaceHolder,
+			"NETWORK=dev",
+			"CHAIN_ID=" + BundlerNodeChainIDPlaceHolder,
+			"ENTRY_POINTS=" + BundlerNodeEPAddressPlaceHolder,
+			"BUILDER_PRIVATE_KEY=" + BundlerNodePrivateKeyPlaceHolder,
+			"BUILDER_BENEFICIARY=" + BundlerNodeBeneficiaryAddressPlaceHolder,
+		},
+		Variables: []string{
+			ListenPortPlaceHolder,
+			EthNodeRPCURLPlaceHolder,
+			BundlerNodeChainIDPlaceHolder,
+			BundlerNodeEPAddressPlaceHolder,
+			BundlerNodePrivateKeyPlaceHolder,
+			BundlerNodeBeneficiaryAddressPlaceHolder,
+		},
+		ContainerPort:  "3000",
+		RPCPath:        "/",
+		ReadinessProbe: ReadinessProbe{Method: "eth_supportedEntryPoints", Retries: 120},
+		NodeType:       "bundler",
+		BundlerOptions: BundlerOptionsDefinition{
+			SafetyArgs: unsafeFlag("--unsafe"),
+			MinStakeArgs: func(minStake *big.Int) []string {
+				return []string{"--min_stake_value", minStake.String()}
+			},
+			MaxBundleGasArgs: func(maxBundleGas uint64) []string {
+				return []string{"--max_bundle_gas", strconv.FormatUint(maxBundleGas, 10)}
+			},
+		},
+	},
+	"skandha": {
+		containerName: "betsy-skandha",
+		imageName:     "etherspot/skandha:1.5.21", // https://github.com/etherspot/skandha
+		Binary:        []string{"skandha"},
+		Cmd: []string{
+			"standalone",
+			"--testingMode",
+			"--api.port", ListenPortPlaceHolder,
+		},
+		Env: []string{
+			"SKANDHA_NETWORK=dev",
+			"SKANDHA_CHAIN_ID=" + BundlerNodeChainIDPlaceHolder,
+			"SKANDHA_ENTRYPOINTS=" + BundlerNodeEPAddressPlaceHolder,
+			"SKANDHA_RELAYERS=" + BundlerNodePrivateKeyPlaceHolder,
+			"SKANDHA_BENEFICIARY=" + BundlerNodeBeneficiaryAddressPlaceHolder,
+			"SKANDHA_RPC=" + EthNodeRPCURLPlaceHolder,
+		},
+		Variables: []string{
+			ListenPortPlaceHolder,
+			EthNodeRPCURLPlaceHolder,
+			BundlerNodeChainIDPlaceHolder,
+			BundlerNodeEPAddressPlaceHolder,
+			BundlerNodePrivateKeyPlaceHolder,
+			BundlerNodeBeneficiaryAddressPlaceHolder,
+		},
+		ContainerPort:  "14337",
+		RPCPath:        "/rpc/",
+		ReadinessProbe: ReadinessProbe{Method: "eth_supportedEntryPoints", Retries: 120},
+		NodeType:       "bundler",
+		BundlerOptions: BundlerOptionsDefinition{
+			SafetyArgs: unsafeFlag("--unsafeMode"),
+		},
+	},
+	"voltaire": {
+		containerName: "betsy-voltaire",
+		imageName:     "candidelabs/voltaire-bundler:0.1.0a51", // https://github.com/candidelabs/voltaire
+		Binary:        []string{"voltaire-bundler"},
+		Cmd: []string{
+			"--entrypoints", BundlerNodeEPAddressPlaceHolder,
+			"--bundler_secret", BundlerNodePrivateKeyPlaceHolder,
+			"--bundler_beneficiary", BundlerNodeBeneficiaryAddressPlaceHolder,
+			"--ethereum_node_url", EthNodeRPCURLPlaceHolder,
+			"--chain_id", BundlerNodeChainIDPlaceHolder,
+			"--rpc_url", "0.0.0.0",
+			"--rpc_port", ListenPortPlaceHolder,
+			"--debug",
+		},
+		Env: []string{},
+		Variables: []string{
+			ListenPortPlaceHolder,
+			EthNodeRPCURLPlaceHolder,
+			BundlerNodeChainIDPlaceHolder,
+			BundlerNodeEPAddressPlaceHolder,
+			BundlerNodePrivateKeyPlaceHolder,
+			BundlerNodeBeneficiaryAddressPlaceHolder,
+		},
+		ContainerPort:  "3000",
+		RPCPath:        "/rpc",
+		ReadinessProbe: ReadinessProbe{Method: "eth_supportedEntryPoints", Retries: 120},
+		NodeType:       "bundler",
+		BundlerOptions: BundlerOptionsDefinition{
+			SafetyArgs: unsafeFlag("--unsafe"),
+		},
+	},
+}
+
+// unsafeFlag returns the safety args of a bundler running in safe mode unless the flag is passed
+func unsafeFlag(flag string) func(safe bool) []string {
+	return func(safe bool) []string {
+		if safe {
+			return nil
+		}
+		return []string{flag}
+	}
+}
+
+// SupportedBundlers returns the sorted names of all bundlers in the registry
+func SupportedBundlers() []string {
+	names := make([]string, 0, len(bundlerDefinitions))
+	for name := range bundlerDefinitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// IsSupportedBundler checks if a bundler is defined in the registry
+func IsSupportedBundler(name string) bool {
+	_, ok := bundlerDefinitions[name]
+	return ok
+}
+
+// BundlerInstanceName returns the name of a bundler instance (1 based), the first instance keeps the bundler name
+func BundlerInstanceName(bundler string, instance int) string {
+	if instance <= 1 {
+		return bundler
+	}
+
+	return fmt.Sprintf("%s-%d", bundler, instance)
+}
+
+// AddBundlerInstance registers an additional instance of a supported bundler with its own container name and returns the instance name
+func (cm *ContainerManager) AddBundlerInstance(bundler string, instance int) (string, error) {
+	details, ok := cm.supportedImages[bundler]
+	if !ok || details.NodeType != "bundler" {
+		return "", fmt.Errorf("Bundler %s is not supported", bundler)
+	}
+
+	name := BundlerInstanceName(bundler, instance)
+	if name == bundler {
+		return name, nil
+	}
+
+	details.containerName = BundlerInstanceName(details.containerName, instance)
+	details.Cmd = append([]string{}, details.Cmd...)
+	details.Env = append([]string{}, details.Env...)
+	cm.supportedImages[name] = details
+
+	return name, nil
+}
+
+// substituteVariables replaces every declared variable found in the templates with its value
+func substituteVariables(templates []string, variables []string, values map[string]string) ([]string, error) {
+	result := make([]string, len(templates))
+	copy(result, templates)
+
+	for _, variable := range variables {
+		value, ok := values[variable]
+		if !ok {
+			return nil, fmt.Errorf("no value provided for variable %s", variable)
+		}
+
+		for index, item := range result {
+			result[index] = strings.ReplaceAll(item, variable, value)
+		}
+	}
+
+	return result, nil
+}
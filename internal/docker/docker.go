@@ -2,18 +2,13 @@ package docker
 
 import (
 	"fmt"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"strings"
-	"time"
+	"sort"
 
 	"context"
-	"io"
 
 	"github.com/docker/docker/api/types/container"
-	"github.com/docker/docker/api/types/image"
 	"github.com/docker/go-connections/nat"
+	"github.com/transeptorlabs/betsy/internal/session"
 	"github.com/transeptorlabs/betsy/wallet"
 
 	"github.com/docker/docker/client"
@@ -22,6 +17,8 @@ import (
 
 const EthNodeReady = "ethNodeReady"
 const EthNodePortPlaceHolder = "$ETH_PORT"
+const EthNodeRPCURLPlaceHolder = "$ETH_RPC_URL"
+const ListenPortPlaceHolder = "$PORT" // port the node listens on, declared by the flag of each definition carrying it
 
 const BundlerNodeWalletDetails = "bundlerNodeWalletDetails"
 const BundlerNodeEPAddressPlaceHolder = "$ENTRYPOINT_ADDRESS"
@@ -30,98 +27,108 @@ const BundlerNodeMnemonicPlaceHolder = "$MNEMONIC"
 
 // ContainerManager manages containers
 type ContainerManager struct {
-	supportedImages      map[string]ContainerDetails
-	client               *client.Client
-	EthNodePort          string
-	CoinbaseKeystoreFile string
+	supportedImages map[string]ContainerDetails
+	client          Runtime
+	runtimeName     string
+	probe           func(ctx context.Context, url string, readinessProbe ReadinessProbe) error
+	networkID       string
+	persistVolume   string
+	SessionID       string
+	NetworkName     string
+	EthNodePort     string
+	EthNodeRPCURL   string
+	EthNodeSigner   wallet.Signer
 }
 
 // ContainerDetails contains details of a container
 type ContainerDetails struct {
-	imageName     string
-	containerName string
-	ContainerID   string
-	IsRunning     bool
-	Cmd           []string
-	Env           []string
-	ExposedPorts  nat.PortSet
-	NodeType      string
+	imageName      string
+	containerName  string
+	ContainerID    string
+	IsRunning      bool
+	Cmd            []string
+	Binary         []string // entrypoint running the node installed on the host with the native runtime
+	Env            []string
+	Variables      []string
+	ExposedPorts   nat.PortSet
+	ContainerPort  string
+	HostPort       string
+	RPCPath        string
+	ReadinessProbe ReadinessProbe
+	Signer         SignerDefinition
+	NodeType       string
+	DataDir        string
+	ShellCommand   bool // the image runs the first Cmd element with /bin/sh -c, extra args are appended to it
+	Mining         MiningDefinition
+	DebugTracer    bool // the execution client runs the debug_traceCall JS tracers used by bundlers in safe mode
+	BundlerOptions BundlerOptionsDefinition
 }
 
-// NewContainerManager creates a new container manager
+// NewContainerManager creates a new container manager connected to the Docker daemon configured by the environment
 func NewContainerManager() (*ContainerManager, error) {
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return nil, err
 	}
 
-	return &ContainerManager{
-		supportedImages: map[string]ContainerDetails{
-			"transeptor": {
-				containerName: "betsy-transeptor",
-				ContainerID:   "",
-				imageName:     "transeptorlabs/bundler:0.6.2-alpha.0", // Betsy Ross - https://github.com/transeptorlabs/transeptor-bundler/releases/tag/v0.6.2-alpha.0
-				IsRunning:     false,
-				Cmd: []string{
-					"--txMode", "base",
-					"--unsafe",
-					"--httpApi", "web3,eth,debug",
-					"--auto",
-					"--autoBundleInterval", "10000", // 10 secs
-					"--network", "http://host.docker.internal:" + EthNodePortPlaceHolder,
-				},
-				Env: []string{
-					"TRANSEPTOR_MNEMONIC=" + BundlerNodeMnemonicPlaceHolder,
-					"TRANSEPTOR_BENEFICIARY=" + BundlerNodeBeneficiaryAddressPlaceHolder,
-					"TRANSEPTOR_ENTRYPOINT_ADDRESS=" + BundlerNodeEPAddressPlaceHolder,
-				},
-				ExposedPorts: nil,
-				NodeType:     "bundler",
-			},
-			"geth": {
-				containerName: "betsy-geth",
-				ContainerID:   "",
-				imageName:     "ethereum/client-go:v1.14.5", // Bothros - https://github.com/ethereum/go-ethereum/releases/tag/v1.14.5
-				IsRunning:     false,
-				Cmd: []string{
-					"--dev",
-					"--nodiscover",
-					"--http",
-					"--dev.gaslimit", "30000000",
-					"--http.api", "eth,net,web3,debug",
-					"--http.corsdomain", "*://localhost:*",
-					"--http.vhosts", "*,localhost,host.docker.internal",
-					"--http.addr", "0.0.0.0",
-					"--networkid", "1337",
-					"--verbosity", "2",
-					"--maxpeers", "0",
-					"--allow-insecure-unlock",
-					"--rpc.allow-unprotected-txs",
-				},
-				Env:          []string{},
-				ExposedPorts: nil,
-				NodeType:     "eth",
-			},
-		},
-		client: cli,
-	}, nil
+	return NewContainerManagerWithRuntime(cli)
 }
 
-// Close closes the Docker client
+// NewContainerManagerWithRuntime creates a new container manager using the given container runtime
+func NewContainerManagerWithRuntime(runtime Runtime) (*ContainerManager, error) {
+	sessionID, err := newSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	cm := &ContainerManager{
+		supportedImages: map[string]ContainerDetails{},
+		client:          runtime,
+		runtimeName:     RuntimeDocker,
+		probe:           probe,
+		SessionID:       sessionID,
+	}
+
+	for name, details := range executionClientDefinitions {
+		cm.supportedImages[name] = details
+	}
+
+	for name, details := range bundlerDefinitions {
+		cm.supportedImages[name] = details
+	}
+
+	return cm, nil
+}
+
+// Close closes the container runtime client
 func (cm *ContainerManager) Close() error {
 	return cm.client.Close()
 }
 
-// IsDockerInstalled checks if docker is installed
-func (cm *ContainerManager) IsDockerInstalled() bool {
-	// Command to check if Docker is installed
-	cmd := exec.Command("docker", "version")
+// OverrideImage replaces the image name and/or command of a supported image, empty values keep the defaults
+func (cm *ContainerManager) OverrideImage(image string, imageName string, cmd []string) error {
+	imageFound, ok := cm.supportedImages[image]
+	if !ok {
+		return fmt.Errorf("Image %s is not supported", image)
+	}
 
-	// Try running the command
-	err := cmd.Run()
+	if imageName != "" {
+		imageFound.imageName = imageName
+	}
+
+	if len(cmd) > 0 {
+		imageFound.Cmd = cmd
+	}
 
-	// If there's an error, Docker is not installed
+	cm.supportedImages[image] = imageFound
+	return nil
+}
+
+// IsDockerInstalled checks if the docker daemon configured for the client is reachable
+func (cm *ContainerManager) IsDockerInstalled(ctx context.Context) bool {
+	_, err := cm.client.Ping(ctx)
 	if err != nil {
+		log.Debug().Err(err).Msg("Failed to ping docker daemon")
 		return false
 	}
 
@@ -142,116 +149,48 @@ func (cm *ContainerManager) ListRunningContainer(ctx context.Context) error {
 	return nil
 }
 
-// PullRequiredImages checks if required images are available and pulls them if not
-func (cm *ContainerManager) PullRequiredImages(ctx context.Context, requiredImages []string) (bool, error) {
-	for _, requiredImage := range requiredImages {
-		if _, ok := cm.supportedImages[requiredImage]; !ok {
-			return false, fmt.Errorf("Image %s is not supported", requiredImage)
-		}
+// RunContainerInTheBackground runs a Docker container in the background given its image and host port to bind
+func (cm *ContainerManager) RunContainerInTheBackground(ctx context.Context, image string, hostPort string) (bool, error) {
+	imageFound, ok := cm.supportedImages[image]
+	if !ok {
+		return false, fmt.Errorf("Image %s is not supported", image)
 	}
 
-	requiredImageFoundCheck := make(map[string]bool)
-	requiredImageNames := make([]string, 0)
-	for _, requiredImage := range requiredImages {
-		requiredImageFoundCheck[cm.supportedImages[requiredImage].imageName] = false
-		requiredImageNames = append(requiredImageNames, cm.supportedImages[requiredImage].imageName)
+	// Processes of the native runtime share the host network, the nodes listen on their host port
+	listenPort := imageFound.ContainerPort
+	if listenPort == "" || cm.runtimeName == RuntimeNative {
+		listenPort = hostPort
 	}
 
-	localImages, err := cm.client.ImageList(ctx, image.ListOptions{})
+	// Substitute the variables declared by the container definition
+	values, err := cm.variableValues(ctx, imageFound, listenPort)
 	if err != nil {
 		return false, err
 	}
 
-	// Check if required images are found locally and update the check map with a caveat for the latest tag to ensure it is always pulled
-	for _, image := range localImages {
-		for _, requiredImageName := range requiredImageNames {
-			if requiredImageName == image.RepoTags[0] && !strings.HasSuffix(requiredImageName, "latest") {
-				requiredImageFoundCheck[requiredImageName] = true
-			}
-		}
-	}
-
-	// Pull required images that are not found
-	for imageName, found := range requiredImageFoundCheck {
-		if !found {
-			_, err := cm.doPullImage(ctx, imageName)
-			if err != nil {
-				return false, err
-			}
-		}
-	}
-
-	return true, nil
-}
-
-// doPullImage pulls a Docker image given its name
-func (cm *ContainerManager) doPullImage(ctx context.Context, imageName string) (bool, error) {
-	log.Info().Msgf("Attempting to pull image: %s", imageName)
-	reader, err := cm.client.ImagePull(ctx, imageName, image.PullOptions{})
+	cmd, err := substituteVariables(imageFound.Cmd, imageFound.Variables, values)
 	if err != nil {
 		return false, err
 	}
-	io.Copy(os.Stdout, reader)
-
-	log.Info().Msgf("Successfully pulled image: %s", imageName)
-	return true, nil
-}
 
-// RunContainerInTheBackground runs a Docker container in the background given its image and host port to bind
-func (cm *ContainerManager) RunContainerInTheBackground(ctx context.Context, image string, hostPort string) (bool, error) {
-	imageFound, ok := cm.supportedImages[image]
-	if !ok {
-		return false, fmt.Errorf("Image %s is not supported", image)
+	env, err := substituteVariables(imageFound.Env, imageFound.Variables, values)
+	if err != nil {
+		return false, err
 	}
 
-	// Update bundler node cmd with ethNode port
-	if imageFound.NodeType == "bundler" {
-		foundIndexPort := 0
-		for index, item := range imageFound.Cmd {
-			if strings.HasSuffix(item, EthNodePortPlaceHolder) {
-				foundIndexPort = index
-				break
-			}
-		}
-		imageFound.Cmd[foundIndexPort] = strings.Replace(imageFound.Cmd[foundIndexPort], EthNodePortPlaceHolder, cm.EthNodePort, 1)
-
-		bundlerDetails := ctx.Value(BundlerNodeWalletDetails).(wallet.BundlerWalletDetails)
-		foundIndexBeneficiary := 0
-		foundIndexMnemonic := 0
-		foundIndexEntryPointAddress := 0
-		for index, item := range imageFound.Env {
-			if strings.HasSuffix(item, BundlerNodeBeneficiaryAddressPlaceHolder) {
-				foundIndexBeneficiary = index
-			}
-
-			if strings.Contains(item, BundlerNodeMnemonicPlaceHolder) {
-				foundIndexMnemonic = index
-			}
-
-			if strings.Contains(item, BundlerNodeEPAddressPlaceHolder) {
-				foundIndexEntryPointAddress = index
-			}
-		}
-		imageFound.Env[foundIndexEntryPointAddress] = strings.Replace(imageFound.Env[foundIndexEntryPointAddress], BundlerNodeEPAddressPlaceHolder, bundlerDetails.EntryPointAddress.Hex(), 1)
-		imageFound.Env[foundIndexBeneficiary] = strings.Replace(imageFound.Env[foundIndexBeneficiary], BundlerNodeBeneficiaryAddressPlaceHolder, bundlerDetails.Beneficiary.Hex(), 1)
-		imageFound.Env[foundIndexMnemonic] = strings.Replace(imageFound.Env[foundIndexMnemonic], BundlerNodeMnemonicPlaceHolder, bundlerDetails.Mnemonic, 1)
-	}
+	// Mount the persisted datadir of the eth node
+	persistArgs, mounts := cm.persistedMounts(imageFound)
+	cmd = appendArgs(cmd, imageFound.ShellCommand, persistArgs)
 
-	containerPort := hostPort + "/tcp"
+	containerPort := listenPort + "/tcp"
 	config := &container.Config{
-		Image: imageFound.imageName,
-		Cmd:   imageFound.Cmd,
-		Env:   imageFound.Env,
+		Image:  imageFound.imageName,
+		Cmd:    cmd,
+		Env:    env,
+		Labels: cm.labels(),
 		ExposedPorts: nat.PortSet{
 			nat.Port(containerPort): struct{}{},
 		},
-		// TODO: Use health check for bundlers and eth node
-		// HealthCheck: &container.HealthConfig{
-		// 	Test:     []string{"CMD", "curl", "-f", "http://localhost:" + hostPort},
-		// 	Interval: 10 * time.Second,
-		// 	Timeout:  5 * time.Second,
-		// 	Retries:  5,
-		// },
 	}
 
 	hostConfig := &container.HostConfig{
@@ -263,9 +202,21 @@ func (cm *ContainerManager) RunContainerInTheBackground(ctx context.Context, ima
 				},
 			},
 		},
+		Mounts: mounts,
 	}
 
-	resp, err := cm.client.ContainerCreate(ctx, config, hostConfig, nil, nil, imageFound.containerName)
+	if cm.runtimeName == RuntimeNative {
+		if len(imageFound.Binary) == 0 {
+			return false, fmt.Errorf("%s cannot run with the native runtime", image)
+		}
+		config.Entrypoint = imageFound.Binary
+	}
+
+	if err := cm.ensureNetwork(ctx); err != nil {
+		return false, err
+	}
+
+	resp, err := cm.client.ContainerCreate(ctx, config, hostConfig, cm.endpointsConfig(imageFound.containerName), nil, imageFound.containerName)
 	if err != nil {
 		return false, err
 	}
@@ -276,41 +227,36 @@ func (cm *ContainerManager) RunContainerInTheBackground(ctx context.Context, ima
 
 	// Update the container details
 	log.Debug().Msgf("%s Container ID successfully started: %s\n", image, resp.ID)
-	cm.supportedImages[image] = ContainerDetails{
-		imageName: imageFound.imageName,
-		Cmd:       imageFound.Cmd,
-		ExposedPorts: nat.PortSet{
-			nat.Port(containerPort): struct{}{},
-		},
-		ContainerID: resp.ID,
-		IsRunning:   true,
+	imageFound.ExposedPorts = nat.PortSet{
+		nat.Port(containerPort): struct{}{},
+	}
+	imageFound.ContainerID = resp.ID
+	imageFound.IsRunning = true
+
+	imageFound.HostPort = hostPort
+	cm.supportedImages[image] = imageFound
+
+	// Wait for the readiness probe to pass before handing the node to its dependents
+	if err := cm.waitUntilReady(ctx, image, imageFound); err != nil {
+		return false, err
 	}
 
 	// Update EthNodeReady channel and signal that eth is ready by closing the channel
 	if imageFound.NodeType == "eth" {
-		log.Info().Msg("Waiting for Eth node container to become ready...")
-		for {
-			containerJSON, err := cm.client.ContainerInspect(ctx, resp.ID)
-			if err != nil {
-				return false, err
-			}
-
-			log.Debug().Msgf("Checking Eth node container ready status: %+v", containerJSON.State.Status)
-			if containerJSON.State.Status == "running" {
-				break
-			}
-			time.Sleep(3 * time.Second)
+		// The dev account keystore of a persisted node lives in its datadir
+		signerDefinition := imageFound.Signer
+		if len(mounts) > 0 {
+			signerDefinition.KeystoreDir = imageFound.DataDir
 		}
 
-		time.Sleep(3 * time.Second)
-		log.Debug().Msgf("Attempting to find eth.coinbase keystore file at /tmp on container: %s", resp.ID)
-		coinbaseKeystoreFile, err := findCoinbaseKeystoreFile(resp.ID, "tmp")
+		signer, err := cm.fundedSigner(ctx, resp.ID, signerDefinition)
 		if err != nil {
 			return false, err
 		}
 
 		cm.EthNodePort = hostPort
-		cm.CoinbaseKeystoreFile = coinbaseKeystoreFile
+		cm.EthNodeRPCURL = "http://" + imageFound.containerName + ":" + listenPort
+		cm.EthNodeSigner = signer
 
 		if readyChan, ok := ctx.Value(EthNodeReady).(chan struct{}); ok {
 			close(readyChan)
@@ -320,103 +266,144 @@ func (cm *ContainerManager) RunContainerInTheBackground(ctx context.Context, ima
 	return true, nil
 }
 
-// StopAndRemoveRunningContainers stops all running containers that are supported
-func (cm *ContainerManager) StopAndRemoveRunningContainers(ctx context.Context) (bool, error) {
-	for _, containerDetails := range cm.supportedImages {
-		if containerDetails.IsRunning {
-			log.Debug().Msgf("Attempting to stop container %s", containerDetails.ContainerID)
-			noWaitTimeout := 0
-
-			if err := cm.client.ContainerStop(ctx, containerDetails.ContainerID, container.StopOptions{Timeout: &noWaitTimeout}); err != nil {
-				return false, err
-			}
-			log.Debug().Msgf("Successfully stopped container %s", containerDetails.ContainerID)
-
-			if err := cm.client.ContainerRemove(ctx, containerDetails.ContainerID, container.RemoveOptions{}); err != nil {
-				return false, err
-			}
-			log.Debug().Msgf("Successfully removed container %s", containerDetails.ContainerID)
+// startedImages returns the sorted names of the images with a running container, eth nodes first
+func (cm *ContainerManager) startedImages() []string {
+	names := make([]string, 0)
+	for name, details := range cm.supportedImages {
+		if details.IsRunning {
+			names = append(names, name)
 		}
 	}
 
-	return true, nil
+	sort.Slice(names, func(i, j int) bool {
+		iEth := cm.supportedImages[names[i]].NodeType == "eth"
+		jEth := cm.supportedImages[names[j]].NodeType == "eth"
+		if iEth != jEth {
+			return iEth
+		}
+		return names[i] < names[j]
+	})
+
+	return names
 }
 
-// findCoinbaseKeystoreFile executes the ls command to find keystore file for the temporary pre-allocated developer account available and unlocked as eth.coinbase(using docker exec)
-func findCoinbaseKeystoreFile(containerID string, dir string) (string, error) {
-	cmd := exec.Command("docker", "exec", containerID, "ls", dir)
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
+// SessionContainers returns the details of the running containers to persist in the session state
+func (cm *ContainerManager) SessionContainers() []session.Container {
+	containers := make([]session.Container, 0)
+	for _, name := range cm.startedImages() {
+		details := cm.supportedImages[name]
+		containers = append(containers, session.Container{
+			Name:        name,
+			NodeType:    details.NodeType,
+			ContainerID: details.ContainerID,
+			HostPort:    details.HostPort,
+			RPCURL:      probeURL(details),
+			ProbeMethod: details.ReadinessProbe.Method,
+		})
 	}
 
-	// Recursive find
-	log.Debug().Msgf("Files in directory: %s\n%s", dir, string(output))
-	fileList := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, file := range fileList {
-		if strings.Contains(file, "UTC") {
-			foundPath := strings.TrimSuffix(dir, "/") + "/" + file
-			log.Debug().Msgf("Found keystore file path: %s", foundPath)
+	return containers
+}
 
-			// Copy the found file to local ./wallet/tmp/coinbase directory
-			if err := copyFileFromContainer(containerID, foundPath, "./wallet/tmp/coinbase"); err != nil {
-				log.Error().Err(err).Msg("Error copying file from container")
-				return "", err
-			}
+// TearDownSession stops and removes the containers and network of a session owned by another process
+func (cm *ContainerManager) TearDownSession(ctx context.Context, state *session.State) error {
+	for _, sessionContainer := range state.Containers {
+		log.Debug().Msgf("Attempting to remove container %s", sessionContainer.ContainerID)
+		timeout := stopTimeout(sessionContainer.NodeType, state.Persist != "")
+		err := cm.client.ContainerStop(ctx, sessionContainer.ContainerID, container.StopOptions{Timeout: &timeout})
+		if err != nil && !client.IsErrNotFound(err) {
+			return err
+		}
 
-			return file, nil
+		err = cm.client.ContainerRemove(ctx, sessionContainer.ContainerID, container.RemoveOptions{Force: true})
+		if err != nil && !client.IsErrNotFound(err) {
+			return err
 		}
 	}
 
-	// If not found, recursively search deeper
-	for _, file := range fileList {
-		if !strings.Contains(file, ".") { // Assuming files without dots are directories
-			newDir := strings.TrimSuffix(dir, "/") + "/" + file
-			log.Debug().Msgf("Not found, searching deeper in directory: %s", newDir)
-			foundPath, err := findCoinbaseKeystoreFile(containerID, newDir)
-			if err == nil {
-				return foundPath, nil
-			}
+	if state.NetworkName != "" {
+		err := cm.client.NetworkRemove(ctx, state.NetworkName)
+		if err != nil && !client.IsErrNotFound(err) {
+			return err
 		}
 	}
 
-	// Keystore file not found
-	log.Warn().Msgf("Keystore file not found in directory: %s", dir)
-	return "", fmt.Errorf("keystore file not found in directory: %s", dir)
+	return nil
 }
 
-// copyFileFromContainer copies a file from a Docker container to the local filesystem
-func copyFileFromContainer(containerID, filePath string, destDir string) error {
-	destLocalFilePath := filepath.Join(destDir, filepath.Base(filePath))
+// GetRPCPath returns the JSON-RPC path served by a supported image
+func (cm *ContainerManager) GetRPCPath(image string) (string, error) {
+	imageFound, ok := cm.supportedImages[image]
+	if !ok {
+		return "", fmt.Errorf("Image %s is not supported", image)
+	}
 
-	if err := os.MkdirAll(destDir, 0755); err != nil {
-		log.Error().Err(err).Msgf("Error creating directory %s", destDir)
-		return err
+	return imageFound.RPCPath, nil
+}
+
+// variableValues returns the values for the placeholders a container definition can declare
+func (cm *ContainerManager) variableValues(ctx context.Context, imageFound ContainerDetails, listenPort string) (map[string]string, error) {
+	values := map[string]string{
+		EthNodePortPlaceHolder:   cm.EthNodePort,
+		EthNodeRPCURLPlaceHolder: cm.EthNodeRPCURL,
+		ListenPortPlaceHolder:    listenPort,
 	}
 
-	// Open a reader to read the file contents from the Docker container
-	cmd := exec.Command("docker", "exec", containerID, "cat", filePath)
-	output, err := cmd.Output()
-	if err != nil {
-		log.Error().Err(err).Msgf("Error reading file %s from container %s", filePath, containerID)
-		return err
+	if imageFound.NodeType == "bundler" {
+		bundlerDetails, ok := ctx.Value(BundlerNodeWalletDetails).(wallet.BundlerWalletDetails)
+		if !ok {
+			return nil, fmt.Errorf("bundler wallet details are missing from context")
+		}
+
+		values[BundlerNodeMnemonicPlaceHolder] = bundlerDetails.Mnemonic
+		values[BundlerNodeBeneficiaryAddressPlaceHolder] = bundlerDetails.Beneficiary.Hex()
+		values[BundlerNodeEPAddressPlaceHolder] = bundlerDetails.EntryPointAddress.Hex()
+		values[BundlerNodePrivateKeyPlaceHolder] = bundlerDetails.PrivateKeyHex
+		values[BundlerNodeChainIDPlaceHolder] = bundlerDetails.ChainID.String()
 	}
 
-	// Create a new file in local directory
-	destFile, err := os.Create(destLocalFilePath)
-	if err != nil {
-		log.Error().Err(err).Msgf("Error creating file %s", destLocalFilePath)
-		return err
+	return values, nil
+}
+
+// StopAndRemoveRunningContainers stops all running containers that are supported
+func (cm *ContainerManager) StopAndRemoveRunningContainers(ctx context.Context) (bool, error) {
+	for _, containerDetails := range cm.supportedImages {
+		if containerDetails.IsRunning {
+			log.Debug().Msgf("Attempting to stop container %s", containerDetails.ContainerID)
+			timeout := stopTimeout(containerDetails.NodeType, cm.persistVolume != "")
+
+			if err := cm.client.ContainerStop(ctx, containerDetails.ContainerID, container.StopOptions{Timeout: &timeout}); err != nil {
+				return false, err
+			}
+			log.Debug().Msgf("Successfully stopped container %s", containerDetails.ContainerID)
+
+			if err := cm.client.ContainerRemove(ctx, containerDetails.ContainerID, container.RemoveOptions{}); err != nil {
+				return false, err
+			}
+			log.Debug().Msgf("Successfully removed container %s", containerDetails.ContainerID)
+		}
 	}
-	defer destFile.Close()
 
-	// Write the file contents to the local file
-	_, err = destFile.Write(output)
-	if err != nil {
-		log.Error().Err(err).Msgf("Error writing to file %s", destLocalFilePath)
-		return err
+	if err := cm.removeNetwork(ctx); err != nil {
+		return false, err
 	}
 
-	log.Debug().Msgf("Copied file %s from container %s to %s", filePath, containerID, destLocalFilePath)
-	return nil
+	return true, nil
+}
+
+// fundedSigner obtains the funded account of the eth node as described by its signer definition
+func (cm *ContainerManager) fundedSigner(ctx context.Context, containerID string, signer SignerDefinition) (wallet.Signer, error) {
+	switch signer.Source {
+	case SignerFromKeystore:
+		log.Debug().Msgf("Attempting to find eth.coinbase keystore file at %s on container: %s", signer.KeystoreDir, containerID)
+		coinbaseKeystoreFile, err := cm.findCoinbaseKeystoreFile(ctx, containerID, signer.KeystoreDir)
+		if err != nil {
+			return wallet.Signer{}, err
+		}
+		return wallet.Signer{KeystoreFile: coinbaseKeystoreFile}, nil
+	case SignerFromPrivateKey:
+		return wallet.Signer{PrivateKeyHex: signer.PrivateKeyHex}, nil
+	default:
+		return wallet.Signer{}, fmt.Errorf("unknown signer source %q", signer.Source)
+	}
 }
@@ -0,0 +1,402 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/transeptorlabs/betsy/internal/docker/fakeruntime"
+	"github.com/transeptorlabs/betsy/internal/session"
+	"github.com/transeptorlabs/betsy/wallet"
+)
+
+var _ Runtime = (*fakeruntime.Runtime)(nil)
+
+const testProbeInterval = 10 * time.Millisecond
+
+var testWalletDetails = wallet.BundlerWalletDetails{
+	Beneficiary:       common.HexToAddress("0x000000000000000000000000000000000000bEEF"),
+	Mnemonic:          "test test test test test test test test test test test junk",
+	PrivateKeyHex:     "0x59c6995e998f97a5a0044966f0945389dc9e86dae88c7a8412f4603b6b78690d",
+	EntryPointAddress: common.HexToAddress("0x0000000071727De22E5E9d8BAf0edaC6f37da032"),
+	ChainID:           big.NewInt(1337),
+}
+
+// newTestManager returns a container manager backed by a fake runtime whose images are all available locally
+func newTestManager(t *testing.T) (*ContainerManager, *fakeruntime.Runtime) {
+	t.Helper()
+
+	runtime := fakeruntime.New()
+	cm, err := NewContainerManagerWithRuntime(runtime)
+	if err != nil {
+		t.Fatalf("NewContainerManagerWithRuntime() error = %v", err)
+	}
+
+	cm.probe = func(ctx context.Context, url string, readinessProbe ReadinessProbe) error {
+		return runtime.Probe(url)
+	}
+
+	for name, details := range cm.supportedImages {
+		details.ReadinessProbe.Interval = testProbeInterval
+		details.ReadinessProbe.Retries = 20
+		cm.supportedImages[name] = details
+		runtime.AddImage(details.imageName)
+	}
+
+	return cm, runtime
+}
+
+// startEthNode runs the eth node container like the start command and waits for its ready signal
+func startEthNode(t *testing.T, cm *ContainerManager, image string) {
+	t.Helper()
+
+	readyChan := make(chan struct{})
+	errChan := make(chan error, 1)
+	ctx := context.WithValue(context.Background(), EthNodeReady, readyChan)
+
+	go func() {
+		if _, err := cm.RunContainerInTheBackground(ctx, image, "8545"); err != nil {
+			errChan <- err
+		}
+	}()
+
+	select {
+	case <-readyChan:
+	case err := <-errChan:
+		t.Fatalf("RunContainerInTheBackground(%s) error = %v", image, err)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%s never signaled ready", image)
+	}
+}
+
+func TestEthNodeSignalsReadyAfterSlowStart(t *testing.T) {
+	cm, runtime := newTestManager(t)
+	startDelay := 100 * time.Millisecond
+	runtime.Script(cm.supportedImages["anvil"].imageName, fakeruntime.Behavior{StartDelay: startDelay})
+
+	started := time.Now()
+	startEthNode(t, cm, "anvil")
+
+	if elapsed := time.Since(started); elapsed < startDelay {
+		t.Errorf("ready signaled after %s, before the %s start delay", elapsed, startDelay)
+	}
+	if err := runtime.Probe("http://localhost:8545/"); err != nil {
+		t.Errorf("eth node is not ready when signaled: %v", err)
+	}
+	if cm.EthNodeRPCURL != "http://betsy-anvil:8545" {
+		t.Errorf("EthNodeRPCURL = %q, want http://betsy-anvil:8545", cm.EthNodeRPCURL)
+	}
+	if cm.EthNodeSigner.PrivateKeyHex == "" {
+		t.Error("EthNodeSigner has no private key for anvil")
+	}
+}
+
+func TestStartupOrdering(t *testing.T) {
+	cm, runtime := newTestManager(t)
+	runtime.Script(cm.supportedImages["anvil"].imageName, fakeruntime.Behavior{StartDelay: 50 * time.Millisecond})
+
+	startEthNode(t, cm, "anvil")
+
+	ctx := context.WithValue(context.Background(), BundlerNodeWalletDetails, testWalletDetails)
+	if _, err := cm.RunContainerInTheBackground(ctx, "transeptor", "4337"); err != nil {
+		t.Fatalf("RunContainerInTheBackground(transeptor) error = %v", err)
+	}
+
+	events := runtime.History()
+	order := []string{
+		"network create " + networkNamePrefix + cm.SessionID,
+		"create betsy-anvil",
+		"start betsy-anvil",
+		"create betsy-transeptor",
+		"start betsy-transeptor",
+	}
+	last := -1
+	for _, event := range order {
+		index := slices.Index(events, event)
+		if index == -1 {
+			t.Fatalf("event %q not recorded in %v", event, events)
+		}
+		if index < last {
+			t.Fatalf("event %q happened out of order in %v", event, events)
+		}
+		last = index
+	}
+
+	if names := cm.startedImages(); !slices.Equal(names, []string{"anvil", "transeptor"}) {
+		t.Errorf("startedImages() = %v, want eth node first", names)
+	}
+}
+
+func TestBundlerPlaceholderSubstitution(t *testing.T) {
+	cm, runtime := newTestManager(t)
+	startEthNode(t, cm, "anvil")
+
+	ctx := context.WithValue(context.Background(), BundlerNodeWalletDetails, testWalletDetails)
+	if _, err := cm.RunContainerInTheBackground(ctx, "transeptor", "14337"); err != nil {
+		t.Fatalf("RunContainerInTheBackground(transeptor) error = %v", err)
+	}
+
+	bundler, ok := runtime.Container("betsy-transeptor")
+	if !ok {
+		t.Fatal("bundler container was not created")
+	}
+
+	if !slices.Contains(bundler.Config.Cmd, "http://betsy-anvil:8545") {
+		t.Errorf("Cmd = %v, want the eth node rpc url", bundler.Config.Cmd)
+	}
+	// The container listens on its container port, published on the host port
+	if i := slices.Index(bundler.Config.Cmd, "--port"); i < 0 || bundler.Config.Cmd[i+1] != "4337" {
+		t.Errorf("Cmd = %v, want --port 4337", bundler.Config.Cmd)
+	}
+
+	wantEnv := []string{
+		"TRANSEPTOR_MNEMONIC=" + testWalletDetails.Mnemonic,
+		"TRANSEPTOR_BENEFICIARY=" + testWalletDetails.Beneficiary.Hex(),
+		"TRANSEPTOR_ENTRYPOINT_ADDRESS=" + testWalletDetails.EntryPointAddress.Hex(),
+	}
+	if !slices.Equal(bundler.Config.Env, wantEnv) {
+		t.Errorf("Env = %v, want %v", bundler.Config.Env, wantEnv)
+	}
+
+	for _, item := range append(bundler.Config.Cmd, bundler.Config.Env...) {
+		if strings.Contains(item, "$") {
+			t.Errorf("placeholder left unsubstituted in %q", item)
+		}
+	}
+}
+
+func TestBundlerRequiresWalletDetails(t *testing.T) {
+	cm, runtime := newTestManager(t)
+	startEthNode(t, cm, "anvil")
+
+	_, err := cm.RunContainerInTheBackground(context.Background(), "transeptor", "4337")
+	if err == nil {
+		t.Fatal("RunContainerInTheBackground() without wallet details succeeded")
+	}
+
+	if _, ok := runtime.Container("betsy-transeptor"); ok {
+		t.Error("bundler container was created without wallet details")
+	}
+}
+
+func TestAddBundlerInstance(t *testing.T) {
+	cm, runtime := newTestManager(t)
+	startEthNode(t, cm, "anvil")
+
+	instanceName, err := cm.AddBundlerInstance("transeptor", 2)
+	if err != nil {
+		t.Fatalf("AddBundlerInstance() error = %v", err)
+	}
+	if instanceName != "transeptor-2" {
+		t.Errorf("AddBundlerInstance() = %s, want transeptor-2", instanceName)
+	}
+
+	ctx := context.WithValue(context.Background(), BundlerNodeWalletDetails, testWalletDetails)
+	for port, name := range map[string]string{"4337": "transeptor", "4338": instanceName} {
+		if _, err := cm.RunContainerInTheBackground(ctx, name, port); err != nil {
+			t.Fatalf("RunContainerInTheBackground(%s) error = %v", name, err)
+		}
+	}
+
+	for name, port := range map[string]string{"betsy-transeptor": "4337", "betsy-transeptor-2": "4338"} {
+		bundler, ok := runtime.Container(name)
+		if !ok {
+			t.Fatalf("%s container was not created", name)
+		}
+
+		found := false
+		for _, bindings := range bundler.HostConfig.PortBindings {
+			for _, binding := range bindings {
+				found = found || binding.HostPort == port
+			}
+		}
+		if !found {
+			t.Errorf("%s port bindings = %v, want host port %s", name, bundler.HostConfig.PortBindings, port)
+		}
+	}
+
+	if _, err := cm.AddBundlerInstance("anvil", 2); err == nil {
+		t.Error("AddBundlerInstance() of an execution client succeeded")
+	}
+}
+
+func TestSubstituteVariablesMissingValue(t *testing.T) {
+	_, err := substituteVariables([]string{"--rpc", EthNodeRPCURLPlaceHolder}, []string{EthNodeRPCURLPlaceHolder}, map[string]string{})
+	if err == nil {
+		t.Fatal("substituteVariables() with a missing value succeeded")
+	}
+}
+
+func TestCrashedContainerFailsReadiness(t *testing.T) {
+	cm, runtime := newTestManager(t)
+	runtime.Script(cm.supportedImages["anvil"].imageName, fakeruntime.Behavior{
+		StartDelay: time.Hour,
+		CrashAfter: 30 * time.Millisecond,
+		ExitCode:   1,
+		Logs:       "starting anvil\nerror: genesis block mismatch\n",
+	})
+
+	_, err := cm.RunContainerInTheBackground(context.Background(), "anvil", "8545")
+	if err == nil {
+		t.Fatal("RunContainerInTheBackground() succeeded for a crashing container")
+	}
+	if !strings.Contains(err.Error(), "exited with code 1") {
+		t.Errorf("error = %v, want the exit code", err)
+	}
+	if !strings.Contains(err.Error(), "genesis block mismatch") {
+		t.Errorf("error = %v, want the last log lines", err)
+	}
+}
+
+func TestPullRequiredImages(t *testing.T) {
+	cm, _ := newTestManager(t)
+	runtime := fakeruntime.New()
+	cm.client = runtime
+
+	gethImage := cm.supportedImages["geth"].imageName
+	runtime.AddImage(gethImage)
+
+	if _, err := cm.PullRequiredImages(context.Background(), []string{"geth", "transeptor"}, PullMissing); err != nil {
+		t.Fatalf("PullRequiredImages() error = %v", err)
+	}
+
+	events := runtime.History()
+	if slices.Contains(events, "pull "+gethImage) {
+		t.Errorf("local image %s was pulled again: %v", gethImage, events)
+	}
+	if !slices.Contains(events, "pull "+cm.supportedImages["transeptor"].imageName) {
+		t.Errorf("missing bundler image was not pulled: %v", events)
+	}
+}
+
+func TestPullRequiredImagesFailure(t *testing.T) {
+	cm, _ := newTestManager(t)
+	runtime := fakeruntime.New()
+	cm.client = runtime
+
+	pullErr := errors.New("manifest unknown")
+	runtime.Script(cm.supportedImages["alto"].imageName, fakeruntime.Behavior{PullError: pullErr})
+
+	_, err := cm.PullRequiredImages(context.Background(), []string{"geth", "alto"}, PullMissing)
+	if !errors.Is(err, pullErr) {
+		t.Fatalf("PullRequiredImages() error = %v, want %v", err, pullErr)
+	}
+}
+
+func TestKeystoreSigner(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	cm, runtime := newTestManager(t)
+	keystorePath := "/tmp/go-ethereum-keystore123/UTC--2024-06-01T00-00-00.000000000Z--71562b71999873db5b286df957af199ec94617f7"
+	runtime.Script(cm.supportedImages["geth"].imageName, fakeruntime.Behavior{
+		Exec: map[string]fakeruntime.ExecResult{
+			"find /tmp -maxdepth 2 -type f -name UTC--*": {Stdout: keystorePath + "\n"},
+		},
+		Files: map[string][]byte{
+			keystorePath: []byte(`{"address":"71562b71999873db5b286df957af199ec94617f7"}`),
+		},
+	})
+
+	startEthNode(t, cm, "geth")
+
+	if cm.EthNodeSigner.KeystoreFile != filepath.Base(keystorePath) {
+		t.Errorf("KeystoreFile = %q, want %q", cm.EthNodeSigner.KeystoreFile, filepath.Base(keystorePath))
+	}
+
+	content, err := os.ReadFile(filepath.Join("wallet", "tmp", "coinbase", filepath.Base(keystorePath)))
+	if err != nil {
+		t.Fatalf("keystore file was not copied: %v", err)
+	}
+	if !strings.Contains(string(content), "71562b71999873db5b286df957af199ec94617f7") {
+		t.Errorf("copied keystore = %s", content)
+	}
+}
+
+func TestKeystoreSignerNotFound(t *testing.T) {
+	cm, _ := newTestManager(t)
+
+	_, err := cm.RunContainerInTheBackground(context.Background(), "geth", "8545")
+	if err == nil {
+		t.Fatal("RunContainerInTheBackground() succeeded without a coinbase keystore")
+	}
+}
+
+func TestStopAndRemoveRunningContainers(t *testing.T) {
+	cm, runtime := newTestManager(t)
+	startEthNode(t, cm, "anvil")
+
+	ctx := context.WithValue(context.Background(), BundlerNodeWalletDetails, testWalletDetails)
+	if _, err := cm.RunContainerInTheBackground(ctx, "transeptor", "4337"); err != nil {
+		t.Fatalf("RunContainerInTheBackground(transeptor) error = %v", err)
+	}
+
+	if _, err := cm.StopAndRemoveRunningContainers(context.Background()); err != nil {
+		t.Fatalf("StopAndRemoveRunningContainers() error = %v", err)
+	}
+
+	if names := runtime.ContainerNames(); len(names) != 0 {
+		t.Errorf("containers left after teardown: %v", names)
+	}
+	if names := runtime.NetworkNames(); len(names) != 0 {
+		t.Errorf("networks left after teardown: %v", names)
+	}
+
+	events := runtime.History()
+	for _, name := range []string{"betsy-anvil", "betsy-transeptor"} {
+		stop := slices.Index(events, "stop "+name)
+		remove := slices.Index(events, "remove "+name)
+		if stop == -1 || remove == -1 || stop > remove {
+			t.Errorf("%s was not stopped before being removed: %v", name, events)
+		}
+	}
+	if events[len(events)-1] != "network remove "+networkNamePrefix+cm.SessionID {
+		t.Errorf("network was not removed last: %v", events)
+	}
+}
+
+func TestTearDownSessionIgnoresMissingContainers(t *testing.T) {
+	cm, runtime := newTestManager(t)
+	startEthNode(t, cm, "anvil")
+
+	state := &session.State{NetworkName: cm.NetworkName, Containers: cm.SessionContainers()}
+	if err := runtime.Crash("betsy-anvil", 137); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cm.TearDownSession(context.Background(), state); err != nil {
+		t.Fatalf("TearDownSession() error = %v", err)
+	}
+	if err := cm.TearDownSession(context.Background(), state); err != nil {
+		t.Fatalf("TearDownSession() of an already removed session error = %v", err)
+	}
+
+	if names := runtime.ContainerNames(); len(names) != 0 {
+		t.Errorf("containers left after teardown: %v", names)
+	}
+}
+
+func TestIsDockerInstalled(t *testing.T) {
+	cm, runtime := newTestManager(t)
+	if !cm.IsDockerInstalled(context.Background()) {
+		t.Error("IsDockerInstalled() = false for a reachable daemon")
+	}
+
+	runtime.SetPingError(errors.New("Cannot connect to the Docker daemon"))
+	if cm.IsDockerInstalled(context.Background()) {
+		t.Error("IsDockerInstalled() = true for an unreachable daemon")
+	}
+}
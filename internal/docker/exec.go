@@ -0,0 +1,125 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/rs/zerolog/log"
+)
+
+// coinbaseKeystoreSearchDepth limits how deep the keystore directory is searched, geth creates its dev keystore in a temporary sub directory
+const coinbaseKeystoreSearchDepth = "2"
+
+// execInContainer runs a command in a container using the Docker Engine API and returns its stdout
+func (cm *ContainerManager) execInContainer(ctx context.Context, containerID string, cmd []string) (string, error) {
+	execResp, err := cm.client.ContainerExecCreate(ctx, containerID, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	attachResp, err := cm.client.ContainerExecAttach(ctx, execResp.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return "", err
+	}
+	defer attachResp.Close()
+
+	var stdout, stderr bytes.Buffer
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, attachResp.Reader); err != nil {
+		return "", err
+	}
+
+	inspect, err := cm.client.ContainerExecInspect(ctx, execResp.ID)
+	if err != nil {
+		return "", err
+	}
+	if inspect.ExitCode != 0 {
+		return "", fmt.Errorf("command %q exited with code %d: %s", strings.Join(cmd, " "), inspect.ExitCode, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.String(), nil
+}
+
+// findCoinbaseKeystoreFile finds the keystore file of the pre-allocated developer account available and unlocked as eth.coinbase and copies it to the local wallet directory
+func (cm *ContainerManager) findCoinbaseKeystoreFile(ctx context.Context, containerID string, dir string) (string, error) {
+	output, err := cm.execInContainer(ctx, containerID, []string{
+		"find", dir, "-maxdepth", coinbaseKeystoreSearchDepth, "-type", "f", "-name", "UTC--*",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	foundPath := strings.TrimSpace(output)
+	if foundPath == "" {
+		log.Warn().Msgf("Keystore file not found in directory: %s", dir)
+		return "", fmt.Errorf("keystore file not found in directory: %s", dir)
+	}
+
+	// The dev node only creates a single coinbase account
+	foundPath = strings.Split(foundPath, "\n")[0]
+	log.Debug().Msgf("Found keystore file path: %s", foundPath)
+
+	// Copy the found file to local ./wallet/tmp/coinbase directory
+	if err := cm.copyFileFromContainer(ctx, containerID, foundPath, "./wallet/tmp/coinbase"); err != nil {
+		log.Error().Err(err).Msg("Error copying file from container")
+		return "", err
+	}
+
+	return path.Base(foundPath), nil
+}
+
+// copyFileFromContainer copies a file from a Docker container to the local filesystem
+func (cm *ContainerManager) copyFileFromContainer(ctx context.Context, containerID, filePath string, destDir string) error {
+	destLocalFilePath := filepath.Join(destDir, path.Base(filePath))
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		log.Error().Err(err).Msgf("Error creating directory %s", destDir)
+		return err
+	}
+
+	// The file contents are streamed as a tar archive containing a single entry
+	reader, _, err := cm.client.CopyFromContainer(ctx, containerID, filePath)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error reading file %s from container %s", filePath, containerID)
+		return err
+	}
+	defer reader.Close()
+
+	tarReader := tar.NewReader(reader)
+	header, err := tarReader.Next()
+	if err != nil {
+		return fmt.Errorf("failed to read %s from container %s: %w", filePath, containerID, err)
+	}
+	if header.Typeflag != tar.TypeReg {
+		return fmt.Errorf("%s in container %s is not a regular file", filePath, containerID)
+	}
+
+	// Create a new file in local directory
+	destFile, err := os.Create(destLocalFilePath)
+	if err != nil {
+		log.Error().Err(err).Msgf("Error creating file %s", destLocalFilePath)
+		return err
+	}
+	defer destFile.Close()
+
+	// Write the file contents to the local file
+	if _, err := io.Copy(destFile, tarReader); err != nil {
+		log.Error().Err(err).Msgf("Error writing to file %s", destLocalFilePath)
+		return err
+	}
+
+	log.Debug().Msgf("Copied file %s from container %s to %s", filePath, containerID, destLocalFilePath)
+	return nil
+}
@@ -0,0 +1,148 @@
+package docker
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Ways an execution client provides the funded account used to fund the dev accounts
+const (
+	SignerFromKeystore   = "keystore"   // the unlocked dev coinbase keystore file is copied from the container
+	SignerFromPrivateKey = "privateKey" // the client pre-funds a well-known dev private key
+)
+
+// SignerDefinition describes how to obtain the funded signer of an execution client
+type SignerDefinition struct {
+	Source        string
+	KeystoreDir   string
+	PrivateKeyHex string
+}
+
+// MiningDefinition describes the block production modes supported by an execution client
+type MiningDefinition struct {
+	IntervalArgs   func(blockTime time.Duration) []string // args mining a block every blockTime, nil when interval mining is not supported
+	AutomineMethod string                                 // JSON-RPC method toggling automatic mining, used to switch to manual mining once the environment is set up
+	MineMethod     string                                 // JSON-RPC method mining a block on demand
+	MineWithTx     bool                                   // a block is mined on demand by sending a transaction from the unlocked dev account, the client mines one block per transaction in auto mode
+}
+
+// lastDevGenesisKey is the private key of account 19 of the test test ... junk mnemonic, the last account pre-funded by the reth dev genesis and anvil with --accounts 20
+const lastDevGenesisKey = "0xdf57089febbacf7ba0bc227dafbffa9fc08a93fdc68e1e42411a14efcf23656e"
+
+// seconds formats a block time as whole seconds
+func seconds(blockTime time.Duration) string {
+	return strconv.FormatInt(int64(blockTime/time.Second), 10)
+}
+
+// executionClientDefinitions contains the registry of supported execution clients keyed by the name used with the --eth.client flag
+var executionClientDefinitions = map[string]ContainerDetails{
+	"geth": {
+		containerName: "betsy-geth",
+		imageName:     "ethereum/client-go:v1.14.5", // Bothros - https://github.com/ethereum/go-ethereum/releases/tag/v1.14.5
+		Binary:        []string{"geth"},
+		Cmd: []string{
+			"--dev",
+			"--nodiscover",
+			"--http",
+			"--dev.gaslimit", "30000000",
+			"--http.api", "eth,net,web3,debug",
+			"--http.corsdomain", "*://localhost:*",
+			"--http.vhosts", "*,localhost,betsy-geth",
+			"--http.addr", "0.0.0.0",
+			"--http.port", ListenPortPlaceHolder,
+			"--networkid", "1337",
+			"--verbosity", "2",
+			"--maxpeers", "0",
+			"--allow-insecure-unlock",
+			"--rpc.allow-unprotected-txs",
+		},
+		Env:            []string{},
+		Variables:      []string{ListenPortPlaceHolder},
+		ContainerPort:  "8545",
+		RPCPath:        "/",
+		ReadinessProbe: ReadinessProbe{Method: "eth_chainId", Retries: 30},
+		Signer:         SignerDefinition{Source: SignerFromKeystore, KeystoreDir: "/tmp"},
+		NodeType:       "eth",
+		DataDir:        "/data", // mounted from a volume with --persist
+		DebugTracer:    true,
+		Mining: MiningDefinition{
+			IntervalArgs: func(blockTime time.Duration) []string {
+				return []string{"--dev.period", seconds(blockTime)}
+			},
+			// geth --dev has no RPC method mining a block nor turning off automatic mining
+			MineWithTx: true,
+		},
+	},
+	"anvil": {
+		containerName: "betsy-anvil",
+		imageName:     "ghcr.io/foundry-rs/foundry:nightly-5ac78a9cd4b94dc53d1fe5e0f42372b28b5a7559", // https://github.com/foundry-rs/foundry
+		Binary:        []string{"/bin/sh", "-c"},
+		// The foundry image runs its command with /bin/sh -c
+		Cmd: []string{
+			"anvil --host 0.0.0.0 --port " + ListenPortPlaceHolder + " --chain-id 1337 --gas-limit 30000000 --steps-tracing --accounts 20",
+		},
+		Env:            []string{},
+		Variables:      []string{ListenPortPlaceHolder},
+		ContainerPort:  "8545",
+		RPCPath:        "/",
+		ReadinessProbe: ReadinessProbe{Method: "eth_chainId", Retries: 30},
+		// Account 19 of the default anvil mnemonic, the first accounts are the dev accounts signing for the bundler and deploying the contracts
+		Signer:       SignerDefinition{Source: SignerFromPrivateKey, PrivateKeyHex: lastDevGenesisKey},
+		NodeType:     "eth",
+		ShellCommand: true,
+		Mining: MiningDefinition{
+			IntervalArgs: func(blockTime time.Duration) []string {
+				return []string{"--block-time", seconds(blockTime)}
+			},
+			AutomineMethod: "evm_setAutomine",
+			MineMethod:     "evm_mine",
+		},
+	},
+	"reth": {
+		containerName: "betsy-reth",
+		imageName:     "ghcr.io/paradigmxyz/reth:v1.0.0", // https://github.com/paradigmxyz/reth
+		Binary:        []string{"reth"},
+		Cmd: []string{
+			"node",
+			"--dev",
+			"--http",
+			"--http.addr", "0.0.0.0",
+			"--http.port", ListenPortPlaceHolder,
+			"--http.api", "eth,net,web3,debug,trace",
+			"--http.corsdomain", "*",
+		},
+		Env:            []string{},
+		Variables:      []string{ListenPortPlaceHolder},
+		ContainerPort:  "8545",
+		RPCPath:        "/",
+		ReadinessProbe: ReadinessProbe{Method: "eth_chainId", Retries: 30},
+		// Account 19 of the reth --dev genesis mnemonic, the first accounts are the dev accounts signing for the bundler and deploying the contracts
+		Signer:   SignerDefinition{Source: SignerFromPrivateKey, PrivateKeyHex: lastDevGenesisKey},
+		NodeType: "eth",
+		Mining: MiningDefinition{
+			IntervalArgs: func(blockTime time.Duration) []string {
+				// reth parses human readable durations, Go formats such as 1m0s are not
+				return []string{"--dev.block-time", fmt.Sprintf("%dms", blockTime.Milliseconds())}
+			},
+		},
+	},
+}
+
+// SupportedExecutionClients returns the sorted names of all execution clients in the registry
+func SupportedExecutionClients() []string {
+	names := make([]string, 0, len(executionClientDefinitions))
+	for name := range executionClientDefinitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// IsSupportedExecutionClient checks if an execution client is defined in the registry
+func IsSupportedExecutionClient(name string) bool {
+	_, ok := executionClientDefinitions[name]
+	return ok
+}
@@ -0,0 +1,76 @@
+package docker
+
+import (
+	"context"
+	"strings"
+
+	"github.com/transeptorlabs/betsy/internal/session"
+)
+
+// ExportedContainer contains the resolved definition of a container of a running session, with its variables substituted
+type ExportedContainer struct {
+	Name           string
+	NodeType       string
+	ContainerName  string
+	Image          string
+	Cmd            []string
+	Env            []string
+	ContainerPort  string
+	HostPort       string
+	RPCPath        string
+	ReadinessProbe ReadinessProbe
+	Signer         SignerDefinition
+}
+
+// ExportContainers returns the resolved definitions of the containers of a running session, eth nodes first
+func (cm *ContainerManager) ExportContainers(ctx context.Context, state *session.State) ([]ExportedContainer, error) {
+	exported := make([]ExportedContainer, 0, len(state.Containers))
+	for _, sessionContainer := range state.Containers {
+		containerJSON, err := cm.client.ContainerInspect(ctx, sessionContainer.ContainerID)
+		if err != nil {
+			return nil, err
+		}
+
+		details := cm.sessionContainerDetails(state, sessionContainer)
+
+		containerPort := details.ContainerPort
+		for port := range containerJSON.Config.ExposedPorts {
+			containerPort = port.Port()
+		}
+
+		exported = append(exported, ExportedContainer{
+			Name:           sessionContainer.Name,
+			NodeType:       sessionContainer.NodeType,
+			ContainerName:  strings.TrimPrefix(containerJSON.Name, "/"),
+			Image:          containerJSON.Config.Image,
+			Cmd:            containerJSON.Config.Cmd,
+			Env:            definedEnv(containerJSON.Config.Env, details.Env),
+			ContainerPort:  containerPort,
+			HostPort:       sessionContainer.HostPort,
+			RPCPath:        details.RPCPath,
+			ReadinessProbe: details.ReadinessProbe,
+			Signer:         details.Signer,
+		})
+	}
+
+	return exported, nil
+}
+
+// definedEnv returns the variables of env declared by the container definition, leaving out the ones inherited from the image
+func definedEnv(env []string, definition []string) []string {
+	keys := make(map[string]bool)
+	for _, item := range definition {
+		key, _, _ := strings.Cut(item, "=")
+		keys[key] = true
+	}
+
+	result := make([]string, 0, len(definition))
+	for _, item := range env {
+		key, _, _ := strings.Cut(item, "=")
+		if keys[key] {
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,57 @@
+package docker
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/transeptorlabs/betsy/internal/session"
+)
+
+func TestExportContainers(t *testing.T) {
+	cm, _ := newTestManager(t)
+	startEthNode(t, cm, "anvil")
+
+	ctx := context.WithValue(context.Background(), BundlerNodeWalletDetails, testWalletDetails)
+	if _, err := cm.RunContainerInTheBackground(ctx, "transeptor", "4337"); err != nil {
+		t.Fatalf("RunContainerInTheBackground(transeptor) error = %v", err)
+	}
+
+	exported, err := cm.ExportContainers(context.Background(), &session.State{
+		SessionID:  cm.SessionID,
+		Containers: cm.SessionContainers(),
+	})
+	if err != nil {
+		t.Fatalf("ExportContainers() error = %v", err)
+	}
+
+	if len(exported) != 2 || exported[0].NodeType != "eth" || exported[1].NodeType != "bundler" {
+		t.Fatalf("ExportContainers() = %v, want the eth node and the bundler", exported)
+	}
+
+	bundler := exported[1]
+	if bundler.ContainerName != "betsy-transeptor" || bundler.ContainerPort != "4337" || bundler.HostPort != "4337" {
+		t.Errorf("bundler = %+v, want betsy-transeptor exposing 4337", bundler)
+	}
+
+	wantEnv := "TRANSEPTOR_ENTRYPOINT_ADDRESS=" + testWalletDetails.EntryPointAddress.Hex()
+	if !slices.Contains(bundler.Env, wantEnv) {
+		t.Errorf("bundler env = %v, want it to contain %s", bundler.Env, wantEnv)
+	}
+
+	for _, arg := range bundler.Cmd {
+		if strings.HasPrefix(arg, "$") {
+			t.Errorf("bundler cmd = %v, want the variables substituted", bundler.Cmd)
+		}
+	}
+}
+
+func TestDefinedEnvLeavesOutImageEnv(t *testing.T) {
+	env := []string{"PATH=/usr/bin", "TRANSEPTOR_MNEMONIC=test", "HOME=/root"}
+	got := definedEnv(env, []string{"TRANSEPTOR_MNEMONIC=" + BundlerNodeMnemonicPlaceHolder})
+
+	if want := []string{"TRANSEPTOR_MNEMONIC=test"}; !slices.Equal(got, want) {
+		t.Errorf("definedEnv() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,959 @@
+// Package fakeruntime provides a scriptable in-memory container runtime used to test the container manager without a Docker daemon
+package fakeruntime
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Behavior scripts how the containers of an image behave
+type Behavior struct {
+	PullError  error                 // returned when the image is pulled
+	StartDelay time.Duration         // time a started container needs before its readiness probe passes
+	CrashAfter time.Duration         // the container exits with ExitCode after running for this long, 0 never crashes
+	ExitCode   int                   // exit code used when the container crashes
+	Logs       string                // output returned by ContainerLogs
+	Exec       map[string]ExecResult // results of the commands run with exec keyed by the space joined command
+	Files      map[string][]byte     // files returned by CopyFromContainer keyed by their absolute path
+	Commands   map[string]ExecResult // one-shot containers keyed by the space joined entrypoint and command, they exit with the result as soon as they start
+}
+
+// ExecResult contains the result of a command run in a container
+type ExecResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Container contains the state of a fake container
+type Container struct {
+	ID               string
+	Name             string
+	Config           *container.Config
+	HostConfig       *container.HostConfig
+	NetworkingConfig *network.NetworkingConfig
+	Running          bool
+	ExitCode         int
+	OOMKilled        bool
+	StartedAt        time.Time
+}
+
+type imageManifest struct {
+	RepoTags []string
+}
+
+type execInstance struct {
+	containerID string
+	cmd         []string
+	result      *ExecResult
+}
+
+// Runtime is an in-memory implementation of the docker.Runtime interface
+type Runtime struct {
+	mu         sync.Mutex
+	behaviors  map[string]Behavior
+	images     map[string]image.Summary
+	containers map[string]*Container
+	networks   map[string]network.Summary
+	volumes    map[string]*volume.Volume
+	execs      map[string]*execInstance
+	events     []string
+	watchers   []*watcher
+	pingErr    error
+	nextID     int
+}
+
+// watcher is a subscriber of the container events stream
+type watcher struct {
+	filters filters.Args
+	ch      chan events.Message
+}
+
+// New creates an empty fake runtime without any local image
+func New() *Runtime {
+	return &Runtime{
+		behaviors:  map[string]Behavior{},
+		images:     map[string]image.Summary{},
+		containers: map[string]*Container{},
+		networks:   map[string]network.Summary{},
+		volumes:    map[string]*volume.Volume{},
+		execs:      map[string]*execInstance{},
+	}
+}
+
+// Script sets the behavior of the containers created from an image
+func (r *Runtime) Script(imageName string, behavior Behavior) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.behaviors[imageName] = behavior
+}
+
+// AddImage makes an image available locally so it does not need to be pulled
+func (r *Runtime) AddImage(imageName string) {
+	r.AddImageSummary(image.Summary{ID: "sha256:" + imageName, RepoTags: []string{imageName}})
+}
+
+// AddImageSummary makes an image available locally with the given ID, tags and digests, e.g. an untagged image
+func (r *Runtime) AddImageSummary(summary image.Summary) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.images[summary.ID] = summary
+}
+
+// HasImage checks if an image is available locally by ID, tag or digest
+func (r *Runtime) HasImage(imageName string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.hasImage(imageName)
+}
+
+// AddVolume creates a volume with the given labels
+func (r *Runtime) AddVolume(name string, labels map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.volumes[name] = &volume.Volume{Name: name, Labels: labels}
+}
+
+// SetPingError makes Ping fail with err to simulate an unreachable daemon
+func (r *Runtime) SetPingError(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pingErr = err
+}
+
+// Crash stops a running container with the given exit code
+func (r *Runtime) Crash(containerName string, exitCode int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.findByName(containerName)
+	if err != nil {
+		return err
+	}
+
+	c.Running = false
+	c.ExitCode = exitCode
+	r.record("crash", c.Name)
+	r.publish(c, events.ActionDie)
+	return nil
+}
+
+// OOMKill stops a running container like the kernel OOM killer does
+func (r *Runtime) OOMKill(containerName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.findByName(containerName)
+	if err != nil {
+		return err
+	}
+
+	c.Running = false
+	c.ExitCode = 137
+	c.OOMKilled = true
+	r.record("oom", c.Name)
+	r.publish(c, events.ActionOOM)
+	r.publish(c, events.ActionDie)
+	return nil
+}
+
+// History returns the recorded operations (e.g. "pull <image>", "create <name>", "start <name>") in the order they happened
+func (r *Runtime) History() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := make([]string, len(r.events))
+	copy(events, r.events)
+	return events
+}
+
+// Subscribers returns the number of open container events streams
+func (r *Runtime) Subscribers() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.watchers)
+}
+
+// Container returns a copy of a container that has not been removed
+func (r *Runtime) Container(containerName string) (Container, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.findByName(containerName)
+	if err != nil {
+		return Container{}, false
+	}
+
+	return *c, true
+}
+
+// ContainerNames returns the sorted names of the containers that have not been removed
+func (r *Runtime) ContainerNames() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.containers))
+	for _, c := range r.containers {
+		names = append(names, c.Name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// NetworkNames returns the sorted names of the networks that have not been removed
+func (r *Runtime) NetworkNames() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.networks))
+	for _, item := range r.networks {
+		names = append(names, item.Name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Probe checks the readiness of the container publishing the host port of url, it can replace the JSON-RPC readiness probe in tests
+func (r *Runtime) Probe(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, c := range r.containers {
+		if !publishesPort(c, parsed.Port()) {
+			continue
+		}
+
+		r.refresh(c)
+		if !c.Running {
+			return fmt.Errorf("container %s is not running", c.Name)
+		}
+		if time.Since(c.StartedAt) < r.behaviors[c.Config.Image].StartDelay {
+			return fmt.Errorf("container %s is still starting", c.Name)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("connection refused on port %s", parsed.Port())
+}
+
+// Ping checks that the fake daemon is reachable
+func (r *Runtime) Ping(ctx context.Context) (types.Ping, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pingErr != nil {
+		return types.Ping{}, r.pingErr
+	}
+
+	return types.Ping{APIVersion: "1.46", OSType: "linux"}, nil
+}
+
+// Close does nothing
+func (r *Runtime) Close() error {
+	return nil
+}
+
+// ImagePull makes an image available locally unless its behavior scripts a pull failure
+func (r *Runtime) ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.record("pull", refStr)
+	if err := r.behaviors[refStr].PullError; err != nil {
+		return nil, err
+	}
+
+	r.images["sha256:"+refStr] = image.Summary{ID: "sha256:" + refStr, RepoTags: []string{refStr}}
+	return io.NopCloser(strings.NewReader(`{"status":"Downloaded newer image for ` + refStr + `"}` + "\n")), nil
+}
+
+// ImageList lists the local images
+func (r *Runtime) ImageList(ctx context.Context, options image.ListOptions) ([]image.Summary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	summaries := make([]image.Summary, 0, len(r.images))
+	for _, summary := range r.images {
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+// ImageSave exports local images to a tarball containing a Docker style manifest.json
+func (r *Runtime) ImageSave(ctx context.Context, imageIDs []string) (io.ReadCloser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	manifest := make([]imageManifest, 0, len(imageIDs))
+	for _, imageID := range imageIDs {
+		if !r.hasImage(imageID) {
+			return nil, errdefs.NotFound(fmt.Errorf("reference does not exist: %s", imageID))
+		}
+		manifest = append(manifest, imageManifest{RepoTags: []string{imageID}})
+		r.record("save", imageID)
+	}
+
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := tarFile("manifest.json", content)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(archive), nil
+}
+
+// ImageLoad imports the images listed in the manifest.json of a tarball created with ImageSave
+func (r *Runtime) ImageLoad(ctx context.Context, input io.Reader, quiet bool) (image.LoadResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tarReader := tar.NewReader(input)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return image.LoadResponse{}, errdefs.InvalidParameter(errors.New("invalid tar file: manifest.json not found"))
+		}
+		if err != nil {
+			return image.LoadResponse{}, errdefs.InvalidParameter(err)
+		}
+		if header.Name == "manifest.json" {
+			break
+		}
+	}
+
+	var manifest []imageManifest
+	if err := json.NewDecoder(tarReader).Decode(&manifest); err != nil {
+		return image.LoadResponse{}, errdefs.InvalidParameter(err)
+	}
+
+	var output bytes.Buffer
+	for _, item := range manifest {
+		for _, tag := range item.RepoTags {
+			r.images["sha256:"+tag] = image.Summary{ID: "sha256:" + tag, RepoTags: []string{tag}}
+			r.record("load", tag)
+			fmt.Fprintf(&output, `{"stream":"Loaded image: %s\n"}`+"\n", tag)
+		}
+	}
+
+	return image.LoadResponse{Body: io.NopCloser(&output), JSON: true}, nil
+}
+
+// ContainerCreate creates a container from a local image
+func (r *Runtime) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.hasImage(config.Image) {
+		return container.CreateResponse{}, errdefs.NotFound(fmt.Errorf("No such image: %s", config.Image))
+	}
+	if _, err := r.findByName(containerName); err == nil {
+		return container.CreateResponse{}, errdefs.Conflict(fmt.Errorf("The container name %q is already in use", "/"+containerName))
+	}
+	if networkingConfig != nil {
+		for networkName := range networkingConfig.EndpointsConfig {
+			if _, err := r.findNetwork(networkName); err != nil {
+				return container.CreateResponse{}, err
+			}
+		}
+	}
+
+	c := &Container{
+		ID:               r.newID("container"),
+		Name:             containerName,
+		Config:           config,
+		HostConfig:       hostConfig,
+		NetworkingConfig: networkingConfig,
+	}
+	r.containers[c.ID] = c
+	r.record("create", containerName)
+
+	return container.CreateResponse{ID: c.ID}, nil
+}
+
+// ContainerStart starts a created or stopped container
+func (r *Runtime) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.find(containerID)
+	if err != nil {
+		return err
+	}
+
+	c.Running = true
+	c.ExitCode = 0
+	c.OOMKilled = false
+	c.StartedAt = time.Now()
+	r.record("start", c.Name)
+	r.publish(c, events.ActionStart)
+
+	command := strings.Join(append(append([]string{}, c.Config.Entrypoint...), c.Config.Cmd...), " ")
+	if result, ok := r.behaviors[c.Config.Image].Commands[command]; ok {
+		c.Running = false
+		c.ExitCode = result.ExitCode
+		r.publish(c, events.ActionDie)
+	}
+
+	return nil
+}
+
+// ContainerInspect returns the state of a container
+func (r *Runtime) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.find(containerID)
+	if err != nil {
+		return types.ContainerJSON{}, err
+	}
+	r.refresh(c)
+
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:         c.ID,
+			Name:       "/" + c.Name,
+			Image:      c.Config.Image,
+			HostConfig: c.HostConfig,
+			State: &types.ContainerState{
+				Status:    status(c),
+				Running:   c.Running,
+				ExitCode:  c.ExitCode,
+				OOMKilled: c.OOMKilled,
+			},
+		},
+		Config: c.Config,
+	}, nil
+}
+
+// ContainerList lists the running containers, or all of them with options.All, matching the label filters
+func (r *Runtime) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	containers := make([]types.Container, 0)
+	for _, c := range r.containers {
+		r.refresh(c)
+		if !c.Running && !options.All {
+			continue
+		}
+		if !options.Filters.MatchKVList("label", c.Config.Labels) {
+			continue
+		}
+
+		containers = append(containers, types.Container{
+			ID:     c.ID,
+			Names:  []string{"/" + c.Name},
+			Image:  c.Config.Image,
+			Labels: c.Config.Labels,
+			State:  status(c),
+		})
+	}
+
+	return containers, nil
+}
+
+// ContainerStop stops a container
+func (r *Runtime) ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.find(containerID)
+	if err != nil {
+		return err
+	}
+
+	wasRunning := c.Running
+	c.Running = false
+	r.record("stop", c.Name)
+	if wasRunning {
+		r.publish(c, events.ActionKill)
+		r.publish(c, events.ActionDie)
+	}
+	r.publish(c, events.ActionStop)
+
+	return nil
+}
+
+// ContainerRemove removes a stopped container, or a running one with options.Force
+func (r *Runtime) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.find(containerID)
+	if err != nil {
+		return err
+	}
+
+	r.refresh(c)
+	if c.Running && !options.Force {
+		return errdefs.Conflict(fmt.Errorf("cannot remove container %q: container is running", "/"+c.Name))
+	}
+
+	delete(r.containers, c.ID)
+	r.record("remove", c.Name)
+	r.publish(c, events.ActionDestroy)
+
+	return nil
+}
+
+// ContainerLogs returns the scripted logs of a container multiplexed like the Docker logs stream
+func (r *Runtime) ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.find(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.SplitAfter(r.behaviors[c.Config.Image].Logs, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if tail, err := strconv.Atoi(options.Tail); err == nil && tail < len(lines) {
+		lines = lines[len(lines)-tail:]
+	}
+
+	var output bytes.Buffer
+	if _, err := stdcopy.NewStdWriter(&output, stdcopy.Stdout).Write([]byte(strings.Join(lines, ""))); err != nil {
+		return nil, err
+	}
+
+	return io.NopCloser(&output), nil
+}
+
+// ContainerExecCreate creates a command to run in a running container
+func (r *Runtime) ContainerExecCreate(ctx context.Context, containerID string, options container.ExecOptions) (types.IDResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.find(containerID)
+	if err != nil {
+		return types.IDResponse{}, err
+	}
+
+	r.refresh(c)
+	if !c.Running {
+		return types.IDResponse{}, errdefs.Conflict(fmt.Errorf("container %s is not running", c.ID))
+	}
+
+	id := r.newID("exec")
+	r.execs[id] = &execInstance{containerID: c.ID, cmd: options.Cmd}
+
+	return types.IDResponse{ID: id}, nil
+}
+
+// ContainerExecAttach runs a created command and returns its multiplexed output, unscripted commands exit with code 127
+func (r *Runtime) ContainerExecAttach(ctx context.Context, execID string, config container.ExecAttachOptions) (types.HijackedResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	instance, ok := r.execs[execID]
+	if !ok {
+		return types.HijackedResponse{}, errdefs.NotFound(fmt.Errorf("No such exec instance: %s", execID))
+	}
+
+	c, err := r.find(instance.containerID)
+	if err != nil {
+		return types.HijackedResponse{}, err
+	}
+
+	command := strings.Join(instance.cmd, " ")
+	result, ok := r.behaviors[c.Config.Image].Exec[command]
+	if !ok {
+		result = ExecResult{Stderr: command + ": not found\n", ExitCode: 127}
+	}
+	instance.result = &result
+	r.record("exec", c.Name+" "+command)
+
+	var output bytes.Buffer
+	if _, err := stdcopy.NewStdWriter(&output, stdcopy.Stdout).Write([]byte(result.Stdout)); err != nil {
+		return types.HijackedResponse{}, err
+	}
+	if _, err := stdcopy.NewStdWriter(&output, stdcopy.Stderr).Write([]byte(result.Stderr)); err != nil {
+		return types.HijackedResponse{}, err
+	}
+
+	conn, peer := net.Pipe()
+	peer.Close()
+
+	return types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(&output)}, nil
+}
+
+// ContainerExecInspect returns the exit code of an attached command
+func (r *Runtime) ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	instance, ok := r.execs[execID]
+	if !ok {
+		return container.ExecInspect{}, errdefs.NotFound(fmt.Errorf("No such exec instance: %s", execID))
+	}
+
+	inspect := container.ExecInspect{ExecID: execID, ContainerID: instance.containerID, Running: instance.result == nil}
+	if instance.result != nil {
+		inspect.ExitCode = instance.result.ExitCode
+	}
+
+	return inspect, nil
+}
+
+// CopyFromContainer returns a scripted file of a container as a tar stream
+func (r *Runtime) CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, container.PathStat, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.find(containerID)
+	if err != nil {
+		return nil, container.PathStat{}, err
+	}
+
+	content, ok := r.behaviors[c.Config.Image].Files[srcPath]
+	if !ok {
+		return nil, container.PathStat{}, errdefs.NotFound(fmt.Errorf("Could not find the file %s in container %s", srcPath, c.Name))
+	}
+
+	archive, err := tarFile(path.Base(srcPath), content)
+	if err != nil {
+		return nil, container.PathStat{}, err
+	}
+
+	return io.NopCloser(archive), container.PathStat{Name: path.Base(srcPath), Size: int64(len(content)), Mode: 0600}, nil
+}
+
+// NetworkCreate creates a network
+func (r *Runtime) NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.findNetwork(name); err == nil {
+		return network.CreateResponse{}, errdefs.Conflict(fmt.Errorf("network with name %s already exists", name))
+	}
+
+	id := r.newID("network")
+	r.networks[id] = network.Summary{ID: id, Name: name, Driver: options.Driver, Labels: options.Labels}
+	r.record("network create", name)
+
+	return network.CreateResponse{ID: id}, nil
+}
+
+// NetworkList lists the networks matching the label filters
+func (r *Runtime) NetworkList(ctx context.Context, options network.ListOptions) ([]network.Summary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	networks := make([]network.Summary, 0)
+	for _, item := range r.networks {
+		if options.Filters.MatchKVList("label", item.Labels) {
+			networks = append(networks, item)
+		}
+	}
+
+	return networks, nil
+}
+
+// NetworkRemove removes a network by ID or name, it fails while containers are still attached
+func (r *Runtime) NetworkRemove(ctx context.Context, networkID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, err := r.findNetwork(networkID)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range r.containers {
+		if c.NetworkingConfig == nil {
+			continue
+		}
+		if _, ok := c.NetworkingConfig.EndpointsConfig[item.Name]; ok {
+			return errdefs.Forbidden(fmt.Errorf("error while removing network: network %s has active endpoints", item.Name))
+		}
+	}
+
+	delete(r.networks, item.ID)
+	r.record("network remove", item.Name)
+
+	return nil
+}
+
+// VolumeList lists the volumes matching the label filters
+func (r *Runtime) VolumeList(ctx context.Context, options volume.ListOptions) (volume.ListResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	volumes := make([]*volume.Volume, 0)
+	for _, item := range r.volumes {
+		if options.Filters.MatchKVList("label", item.Labels) {
+			volumes = append(volumes, item)
+		}
+	}
+
+	return volume.ListResponse{Volumes: volumes}, nil
+}
+
+// VolumeCreate creates a named volume, creating an existing volume returns it unchanged
+func (r *Runtime) VolumeCreate(ctx context.Context, options volume.CreateOptions) (volume.Volume, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if item, ok := r.volumes[options.Name]; ok {
+		return *item, nil
+	}
+
+	item := &volume.Volume{Name: options.Name, Labels: options.Labels}
+	r.volumes[options.Name] = item
+	r.record("volume create", options.Name)
+
+	return *item, nil
+}
+
+// VolumeInspect returns a volume by name
+func (r *Runtime) VolumeInspect(ctx context.Context, volumeID string) (volume.Volume, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	item, ok := r.volumes[volumeID]
+	if !ok {
+		return volume.Volume{}, errdefs.NotFound(fmt.Errorf("get %s: no such volume", volumeID))
+	}
+
+	return *item, nil
+}
+
+// VolumeRemove removes a volume
+func (r *Runtime) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.volumes[volumeID]; !ok {
+		return errdefs.NotFound(fmt.Errorf("get %s: no such volume", volumeID))
+	}
+
+	delete(r.volumes, volumeID)
+	r.record("volume remove", volumeID)
+
+	return nil
+}
+
+// hasImage checks if an image is available locally by ID, tag or digest
+func (r *Runtime) hasImage(imageName string) bool {
+	for _, summary := range r.images {
+		if summary.ID == imageName || slices.Contains(summary.RepoTags, imageName) || slices.Contains(summary.RepoDigests, imageName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newID returns a unique identifier for a new resource
+func (r *Runtime) newID(kind string) string {
+	r.nextID++
+	return fmt.Sprintf("%s-%d", kind, r.nextID)
+}
+
+// Events streams the container events matching the filters until ctx is done
+func (r *Runtime) Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error) {
+	messages := make(chan events.Message, 100)
+	errs := make(chan error, 1)
+
+	w := &watcher{filters: options.Filters, ch: messages}
+	r.mu.Lock()
+	r.watchers = append(r.watchers, w)
+	r.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		r.mu.Lock()
+		r.watchers = slices.DeleteFunc(r.watchers, func(item *watcher) bool {
+			return item == w
+		})
+		r.mu.Unlock()
+
+		errs <- ctx.Err()
+	}()
+
+	return messages, errs
+}
+
+// publish sends a container event to the watchers whose filters match the container, full watchers miss the event
+func (r *Runtime) publish(c *Container, action events.Action) {
+	attributes := map[string]string{
+		"name":  c.Name,
+		"image": c.Config.Image,
+	}
+	for key, value := range c.Config.Labels {
+		attributes[key] = value
+	}
+	if action == events.ActionDie {
+		attributes["exitCode"] = strconv.Itoa(c.ExitCode)
+	}
+
+	message := events.Message{
+		Type:     events.ContainerEventType,
+		Action:   action,
+		Actor:    events.Actor{ID: c.ID, Attributes: attributes},
+		TimeNano: time.Now().UnixNano(),
+	}
+
+	for _, w := range r.watchers {
+		if !w.filters.MatchKVList("label", c.Config.Labels) {
+			continue
+		}
+		if w.filters.Contains("type") && !w.filters.ExactMatch("type", string(events.ContainerEventType)) {
+			continue
+		}
+		if w.filters.Contains("event") && !w.filters.ExactMatch("event", string(action)) {
+			continue
+		}
+
+		select {
+		case w.ch <- message:
+		default:
+		}
+	}
+}
+
+// record appends an operation to the event history
+func (r *Runtime) record(operation string, target string) {
+	r.events = append(r.events, operation+" "+target)
+}
+
+// find returns a container by ID or name
+func (r *Runtime) find(containerID string) (*Container, error) {
+	if c, ok := r.containers[containerID]; ok {
+		return c, nil
+	}
+
+	return r.findByName(containerID)
+}
+
+// findByName returns a container by name
+func (r *Runtime) findByName(containerName string) (*Container, error) {
+	for _, c := range r.containers {
+		if c.Name == strings.TrimPrefix(containerName, "/") {
+			return c, nil
+		}
+	}
+
+	return nil, errdefs.NotFound(fmt.Errorf("No such container: %s", containerName))
+}
+
+// findNetwork returns a network by ID or name
+func (r *Runtime) findNetwork(networkID string) (network.Summary, error) {
+	if item, ok := r.networks[networkID]; ok {
+		return item, nil
+	}
+
+	for _, item := range r.networks {
+		if item.Name == networkID {
+			return item, nil
+		}
+	}
+
+	return network.Summary{}, errdefs.NotFound(fmt.Errorf("network %s not found", networkID))
+}
+
+// refresh applies the scripted crash of a running container once its time has come
+func (r *Runtime) refresh(c *Container) {
+	behavior := r.behaviors[c.Config.Image]
+	if c.Running && behavior.CrashAfter > 0 && time.Since(c.StartedAt) >= behavior.CrashAfter {
+		c.Running = false
+		c.ExitCode = behavior.ExitCode
+		r.record("crash", c.Name)
+		r.publish(c, events.ActionDie)
+	}
+}
+
+// tarFile returns a tar archive containing a single regular file
+func tarFile(name string, content []byte) (*bytes.Buffer, error) {
+	var archive bytes.Buffer
+	writer := tar.NewWriter(&archive)
+	header := &tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg}
+	if err := writer.WriteHeader(header); err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(content); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return &archive, nil
+}
+
+// status returns the Docker state name of a container
+func status(c *Container) string {
+	switch {
+	case c.Running:
+		return "running"
+	case c.StartedAt.IsZero():
+		return "created"
+	default:
+		return "exited"
+	}
+}
+
+// publishesPort checks if a container binds the given host port
+func publishesPort(c *Container, hostPort string) bool {
+	if c.HostConfig == nil {
+		return false
+	}
+
+	for _, bindings := range c.HostConfig.PortBindings {
+		for _, binding := range bindings {
+			if binding.HostPort == hostPort {
+				return true
+			}
+		}
+	}
+
+	return false
+}
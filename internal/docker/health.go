@@ -0,0 +1,206 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/rs/zerolog/log"
+	"github.com/transeptorlabs/betsy/internal/session"
+)
+
+const (
+	defaultProbeInterval = 1 * time.Second
+	defaultProbeTimeout  = 2 * time.Second
+	defaultProbeRetries  = 60
+	failureLogLines      = "20"
+)
+
+// ComponentStatus contains the live readiness state of a Betsy component
+type ComponentStatus struct {
+	Name        string `json:"name"`
+	NodeType    string `json:"nodeType"`
+	ContainerID string `json:"containerId"`
+	State       string `json:"state"`
+	Ready       bool   `json:"ready"`
+	Error       string `json:"error,omitempty"`
+}
+
+// jsonrpcProbeRes is the response struct for a readiness probe json rpc call
+type jsonrpcProbeRes struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// probeURL returns the host url used to reach the JSON-RPC endpoint of a container
+func probeURL(details ContainerDetails) string {
+	return "http://localhost:" + details.HostPort + details.RPCPath
+}
+
+// probe sends the readiness probe json rpc call once
+func probe(ctx context.Context, url string, readinessProbe ReadinessProbe) error {
+	timeout := readinessProbe.Timeout
+	if timeout == 0 {
+		timeout = defaultProbeTimeout
+	}
+
+	params := readinessProbe.Params
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	jsonBody, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  readinessProbe.Method,
+		"params":  params,
+	})
+
+	requestCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(requestCtx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s probe failed with status code: %d", readinessProbe.Method, res.StatusCode)
+	}
+
+	resJsonBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+
+	var data jsonrpcProbeRes
+	if err := json.Unmarshal(resJsonBody, &data); err != nil {
+		return err
+	}
+
+	if data.Error != nil {
+		return fmt.Errorf("%s probe failed: %s", readinessProbe.Method, data.Error.Message)
+	}
+
+	if len(data.Result) == 0 || string(data.Result) == "null" {
+		return fmt.Errorf("%s probe returned an empty result", readinessProbe.Method)
+	}
+
+	return nil
+}
+
+// waitUntilReady runs the readiness probe of a container until it passes, the retries are exhausted or the container exits
+func (cm *ContainerManager) waitUntilReady(ctx context.Context, name string, details ContainerDetails) error {
+	interval := details.ReadinessProbe.Interval
+	if interval == 0 {
+		interval = defaultProbeInterval
+	}
+
+	retries := details.ReadinessProbe.Retries
+	if retries == 0 {
+		retries = defaultProbeRetries
+	}
+
+	url := probeURL(details)
+	log.Info().Msgf("Waiting for %s container to become ready...", name)
+
+	var lastErr error
+	for attempt := 1; attempt <= retries; attempt++ {
+		containerJSON, err := cm.client.ContainerInspect(ctx, details.ContainerID)
+		if err != nil {
+			return err
+		}
+
+		if containerJSON.State.Status == "exited" || containerJSON.State.Status == "dead" {
+			return cm.withLastLogLines(ctx, details.ContainerID, fmt.Errorf("%s container exited with code %d", name, containerJSON.State.ExitCode))
+		}
+
+		lastErr = cm.probe(ctx, url, details.ReadinessProbe)
+		if lastErr == nil {
+			log.Debug().Msgf("%s container passed %s readiness probe (attempt %d)", name, details.ReadinessProbe.Method, attempt)
+			return nil
+		}
+		log.Debug().Err(lastErr).Msgf("%s container not ready yet (attempt %d/%d)", name, attempt, retries)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+
+	return cm.withLastLogLines(ctx, details.ContainerID, fmt.Errorf("%s container never passed the %s readiness probe: %w", name, details.ReadinessProbe.Method, lastErr))
+}
+
+// withLastLogLines appends the last log lines of a container to err
+func (cm *ContainerManager) withLastLogLines(ctx context.Context, containerID string, err error) error {
+	reader, logsErr := cm.client.ContainerLogs(ctx, containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       failureLogLines,
+	})
+	if logsErr != nil {
+		return errors.Join(err, logsErr)
+	}
+	defer reader.Close()
+
+	var output bytes.Buffer
+	if _, copyErr := stdcopy.StdCopy(&output, &output, reader); copyErr != nil {
+		return errors.Join(err, copyErr)
+	}
+
+	return fmt.Errorf("%w\nlast container log lines:\n%s", err, strings.TrimSpace(output.String()))
+}
+
+// Readiness returns the live readiness state of every container started by the manager
+func (cm *ContainerManager) Readiness(ctx context.Context) []ComponentStatus {
+	statuses := make([]ComponentStatus, 0)
+	for _, container := range cm.SessionContainers() {
+		statuses = append(statuses, cm.StatusOf(ctx, container))
+	}
+
+	return statuses
+}
+
+// StatusOf inspects a session container and runs its readiness probe
+func (cm *ContainerManager) StatusOf(ctx context.Context, sessionContainer session.Container) ComponentStatus {
+	status := ComponentStatus{
+		Name:        sessionContainer.Name,
+		NodeType:    sessionContainer.NodeType,
+		ContainerID: sessionContainer.ContainerID,
+	}
+
+	containerJSON, err := cm.client.ContainerInspect(ctx, sessionContainer.ContainerID)
+	if err != nil {
+		status.State = "unknown"
+		status.Error = err.Error()
+		return status
+	}
+
+	status.State = containerJSON.State.Status
+	if err := cm.probe(ctx, sessionContainer.RPCURL, ReadinessProbe{Method: sessionContainer.ProbeMethod}); err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Ready = true
+	}
+
+	return status
+}
@@ -0,0 +1,250 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/docker/docker/api/types/image"
+	"github.com/rs/zerolog/log"
+)
+
+// Pull policies used to provision the required images
+const (
+	PullAlways  = "always"  // always pull the images from their registry
+	PullMissing = "missing" // pull the images that are not available locally, latest tags are always pulled
+	PullNever   = "never"   // only use local images, e.g. loaded with betsy images load
+)
+
+// PullPolicies returns the supported pull policies
+func PullPolicies() []string {
+	return []string{PullAlways, PullMissing, PullNever}
+}
+
+// jsonMessage is a message of the JSON stream returned by the image pull and load endpoints
+type jsonMessage struct {
+	Stream   string `json:"stream"`
+	Status   string `json:"status"`
+	ID       string `json:"id"`
+	Progress *struct {
+		Current int64 `json:"current"`
+	} `json:"progressDetail"`
+	ErrorDetail *struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+	Error string `json:"error"`
+}
+
+// PullRequiredImages checks if required images are available locally and pulls them according to the pull policy
+func (cm *ContainerManager) PullRequiredImages(ctx context.Context, requiredImages []string, pullPolicy string) (bool, error) {
+	if pullPolicy != PullAlways && pullPolicy != PullMissing && pullPolicy != PullNever {
+		return false, fmt.Errorf("Pull policy %s is not supported, choose one of: %s", pullPolicy, strings.Join(PullPolicies(), ", "))
+	}
+
+	requiredImageNames, err := cm.ImageNames(requiredImages)
+	if err != nil {
+		return false, err
+	}
+
+	localImages, err := cm.client.ImageList(ctx, image.ListOptions{})
+	if err != nil {
+		return false, err
+	}
+
+	missing := make([]string, 0)
+	for _, requiredImageName := range requiredImageNames {
+		found := false
+		for _, localImage := range localImages {
+			if imageMatches(localImage, requiredImageName) {
+				found = true
+				break
+			}
+		}
+
+		switch {
+		case pullPolicy == PullNever && !found:
+			missing = append(missing, requiredImageName)
+		case pullPolicy == PullNever:
+			log.Debug().Msgf("Using local image: %s", requiredImageName)
+		case pullPolicy == PullAlways || !found || isLatestTag(requiredImageName):
+			_, err := cm.doPullImage(ctx, requiredImageName)
+			if err != nil {
+				return false, err
+			}
+		default:
+			log.Debug().Msgf("Using local image: %s", requiredImageName)
+		}
+	}
+
+	if len(missing) > 0 {
+		return false, fmt.Errorf("images %s are not available locally and the pull policy is %s, load them with betsy images load", strings.Join(missing, ", "), PullNever)
+	}
+
+	return true, nil
+}
+
+// doPullImage pulls a Docker image given its name
+func (cm *ContainerManager) doPullImage(ctx context.Context, imageName string) (bool, error) {
+	log.Info().Msgf("Attempting to pull image: %s", imageName)
+	reader, err := cm.client.ImagePull(ctx, imageName, image.PullOptions{})
+	if err != nil {
+		return false, err
+	}
+	defer reader.Close()
+
+	if err := readJSONMessages(reader, os.Stdout); err != nil {
+		return false, fmt.Errorf("failed to pull image %s: %w", imageName, err)
+	}
+
+	log.Info().Msgf("Successfully pulled image: %s", imageName)
+	return true, nil
+}
+
+// SaveImages exports the images of the given supported images to a tarball written to w
+func (cm *ContainerManager) SaveImages(ctx context.Context, images []string, w io.Writer) error {
+	imageNames, err := cm.ImageNames(images)
+	if err != nil {
+		return err
+	}
+
+	reader, err := cm.client.ImageSave(ctx, imageNames)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	if _, err := io.Copy(w, reader); err != nil {
+		return err
+	}
+
+	log.Debug().Msgf("Saved images: %s", strings.Join(imageNames, ", "))
+	return nil
+}
+
+// LoadImages loads the images of a tarball created with SaveImages and writes the load progress to out
+func (cm *ContainerManager) LoadImages(ctx context.Context, r io.Reader, out io.Writer) error {
+	resp, err := cm.client.ImageLoad(ctx, r, false)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if !resp.JSON {
+		_, err := io.Copy(out, resp.Body)
+		return err
+	}
+
+	return readJSONMessages(resp.Body, out)
+}
+
+// ImageNames returns the deduplicated image names of the given supported images
+func (cm *ContainerManager) ImageNames(images []string) ([]string, error) {
+	imageNames := make([]string, 0, len(images))
+	seen := make(map[string]bool)
+	for _, name := range images {
+		imageFound, ok := cm.supportedImages[name]
+		if !ok {
+			return nil, fmt.Errorf("Image %s is not supported", name)
+		}
+
+		if !seen[imageFound.imageName] {
+			seen[imageFound.imageName] = true
+			imageNames = append(imageNames, imageFound.imageName)
+		}
+	}
+
+	return imageNames, nil
+}
+
+// imageMatches checks if a local image is the one referenced by imageName, comparing every tag and digest of the image
+func imageMatches(localImage image.Summary, imageName string) bool {
+	if localImage.ID == imageName || localImage.ID == "sha256:"+imageName {
+		return true
+	}
+
+	ref, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		return false
+	}
+
+	if digested, ok := ref.(reference.Digested); ok {
+		for _, repoDigest := range localImage.RepoDigests {
+			candidate, err := reference.ParseNormalizedNamed(repoDigest)
+			if err != nil {
+				continue
+			}
+
+			if candidateDigested, ok := candidate.(reference.Digested); ok && candidate.Name() == ref.Name() && candidateDigested.Digest() == digested.Digest() {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	ref = reference.TagNameOnly(ref)
+	for _, repoTag := range localImage.RepoTags {
+		candidate, err := reference.ParseNormalizedNamed(repoTag)
+		if err != nil {
+			continue
+		}
+
+		if reference.TagNameOnly(candidate).String() == ref.String() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isLatestTag checks if an image reference uses the latest tag, explicitly or by default
+func isLatestTag(imageName string) bool {
+	ref, err := reference.ParseNormalizedNamed(imageName)
+	if err != nil {
+		return false
+	}
+
+	if _, ok := ref.(reference.Digested); ok {
+		return false
+	}
+
+	tagged, ok := reference.TagNameOnly(ref).(reference.Tagged)
+	return ok && tagged.Tag() == "latest"
+}
+
+// readJSONMessages writes the progress of a JSON message stream to out and returns the first error message of the stream
+func readJSONMessages(r io.Reader, out io.Writer) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var message jsonMessage
+		if err := decoder.Decode(&message); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		if message.ErrorDetail != nil {
+			return errors.New(message.ErrorDetail.Message)
+		}
+		if message.Error != "" {
+			return errors.New(message.Error)
+		}
+
+		switch {
+		case message.Progress != nil && message.Progress.Current > 0:
+			// skip the download and extraction progress updates of each layer
+		case message.Stream != "":
+			fmt.Fprint(out, message.Stream)
+		case message.ID != "":
+			fmt.Fprintf(out, "%s: %s\n", message.ID, message.Status)
+		case message.Status != "":
+			fmt.Fprintln(out, message.Status)
+		}
+	}
+}
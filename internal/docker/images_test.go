@@ -0,0 +1,136 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/image"
+	"github.com/transeptorlabs/betsy/internal/docker/fakeruntime"
+)
+
+func TestImageMatches(t *testing.T) {
+	localImage := image.Summary{
+		ID:          "sha256:4b2a4b2a",
+		RepoTags:    []string{"ethereum/client-go:stable", "ethereum/client-go:v1.14.5"},
+		RepoDigests: []string{"ethereum/client-go@sha256:aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11"},
+	}
+
+	tests := []struct {
+		imageName string
+		want      bool
+	}{
+		{"ethereum/client-go:v1.14.5", true},
+		{"ethereum/client-go:stable", true},
+		{"docker.io/ethereum/client-go:v1.14.5", true},
+		{"ethereum/client-go@sha256:aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11", true},
+		{"ethereum/client-go:v1.14.5@sha256:aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11aa11", true},
+		{"sha256:4b2a4b2a", true},
+		{"ethereum/client-go:v1.14.4", false},
+		{"ethereum/client-go", false},
+		{"ethereum/client-go@sha256:bb22bb22bb22bb22bb22bb22bb22bb22bb22bb22bb22bb22bb22bb22bb22bb22", false},
+	}
+
+	for _, tt := range tests {
+		if got := imageMatches(localImage, tt.imageName); got != tt.want {
+			t.Errorf("imageMatches(%q) = %v, want %v", tt.imageName, got, tt.want)
+		}
+	}
+}
+
+func TestImageMatchesUntaggedImage(t *testing.T) {
+	untagged := image.Summary{ID: "sha256:0f0f0f0f"}
+	if imageMatches(untagged, "ethereum/client-go:v1.14.5") {
+		t.Error("imageMatches() matched an untagged image by name")
+	}
+}
+
+func TestPullRequiredImagesWithUntaggedLocalImages(t *testing.T) {
+	cm, _ := newTestManager(t)
+	runtime := fakeruntime.New()
+	cm.client = runtime
+	runtime.AddImageSummary(image.Summary{ID: "sha256:0f0f0f0f"})
+
+	if _, err := cm.PullRequiredImages(context.Background(), []string{"geth"}, PullMissing); err != nil {
+		t.Fatalf("PullRequiredImages() error = %v", err)
+	}
+}
+
+func TestPullRequiredImagesAlways(t *testing.T) {
+	cm, _ := newTestManager(t)
+	runtime := fakeruntime.New()
+	cm.client = runtime
+
+	gethImage := cm.supportedImages["geth"].imageName
+	runtime.AddImage(gethImage)
+
+	if _, err := cm.PullRequiredImages(context.Background(), []string{"geth"}, PullAlways); err != nil {
+		t.Fatalf("PullRequiredImages() error = %v", err)
+	}
+
+	if !slices.Contains(runtime.History(), "pull "+gethImage) {
+		t.Errorf("local image %s was not pulled with the always policy: %v", gethImage, runtime.History())
+	}
+}
+
+func TestPullRequiredImagesNever(t *testing.T) {
+	cm, _ := newTestManager(t)
+	runtime := fakeruntime.New()
+	cm.client = runtime
+
+	gethImage := cm.supportedImages["geth"].imageName
+	runtime.AddImage(gethImage)
+
+	_, err := cm.PullRequiredImages(context.Background(), []string{"geth", "transeptor"}, PullNever)
+	if err == nil {
+		t.Fatal("PullRequiredImages() succeeded with a missing image and the never policy")
+	}
+	if !strings.Contains(err.Error(), cm.supportedImages["transeptor"].imageName) {
+		t.Errorf("error = %v, want the missing image name", err)
+	}
+
+	for _, event := range runtime.History() {
+		if strings.HasPrefix(event, "pull ") {
+			t.Errorf("image pulled with the never policy: %s", event)
+		}
+	}
+}
+
+func TestPullRequiredImagesUnknownPolicy(t *testing.T) {
+	cm, _ := newTestManager(t)
+	if _, err := cm.PullRequiredImages(context.Background(), []string{"geth"}, "sometimes"); err == nil {
+		t.Fatal("PullRequiredImages() succeeded with an unknown pull policy")
+	}
+}
+
+func TestSaveAndLoadImages(t *testing.T) {
+	cm, _ := newTestManager(t)
+	images := []string{"geth", "transeptor"}
+
+	var archive bytes.Buffer
+	if err := cm.SaveImages(context.Background(), images, &archive); err != nil {
+		t.Fatalf("SaveImages() error = %v", err)
+	}
+
+	// Load the tarball into an air-gapped daemon without any image
+	offline := fakeruntime.New()
+	cm.client = offline
+
+	var output bytes.Buffer
+	if err := cm.LoadImages(context.Background(), &archive, &output); err != nil {
+		t.Fatalf("LoadImages() error = %v", err)
+	}
+
+	if _, err := cm.PullRequiredImages(context.Background(), images, PullNever); err != nil {
+		t.Fatalf("PullRequiredImages() after load error = %v", err)
+	}
+
+	for _, name := range images {
+		imageName := cm.supportedImages[name].imageName
+		if !strings.Contains(output.String(), "Loaded image: "+imageName) {
+			t.Errorf("load output %q does not mention %s", output.String(), imageName)
+		}
+	}
+}
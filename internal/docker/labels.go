@@ -0,0 +1,159 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/rs/zerolog/log"
+	"github.com/transeptorlabs/betsy/internal/utils"
+)
+
+// Labels added to every Docker resource created by Betsy
+const (
+	LabelManaged = "io.betsy.managed"
+	LabelSession = "io.betsy.session"
+	LabelPID     = "io.betsy.pid"
+)
+
+// Orphans contains the Docker resources left behind by Betsy sessions whose process is gone
+type Orphans struct {
+	Containers []string
+	Networks   []string
+}
+
+// PruneReport contains the Docker resources removed by Prune
+type PruneReport struct {
+	Containers []string
+	Networks   []string
+	Volumes    []string
+}
+
+// IsEmpty checks if no orphaned resources were found
+func (o Orphans) IsEmpty() bool {
+	return len(o.Containers) == 0 && len(o.Networks) == 0
+}
+
+// labels returns the labels identifying the resources of the current session
+func (cm *ContainerManager) labels() map[string]string {
+	return map[string]string{
+		LabelManaged: "true",
+		LabelSession: cm.SessionID,
+		LabelPID:     strconv.Itoa(os.Getpid()),
+	}
+}
+
+// managedFilter returns the filter matching every Betsy-owned resource
+func managedFilter() filters.Args {
+	return filters.NewArgs(filters.Arg("label", LabelManaged+"=true"))
+}
+
+// isDeadSession checks if the resource labels belong to another session whose process is no longer running
+func (cm *ContainerManager) isDeadSession(labels map[string]string) bool {
+	if labels[LabelSession] == cm.SessionID {
+		return false
+	}
+
+	pid, err := strconv.Atoi(labels[LabelPID])
+	if err != nil {
+		return true
+	}
+
+	return !utils.IsProcessAlive(pid)
+}
+
+// FindOrphans lists the containers and networks left behind by dead Betsy sessions
+func (cm *ContainerManager) FindOrphans(ctx context.Context) (Orphans, error) {
+	orphans := Orphans{}
+
+	containers, err := cm.client.ContainerList(ctx, container.ListOptions{All: true, Filters: managedFilter()})
+	if err != nil {
+		return orphans, err
+	}
+	for _, item := range containers {
+		if cm.isDeadSession(item.Labels) {
+			orphans.Containers = append(orphans.Containers, item.ID)
+		}
+	}
+
+	networks, err := cm.client.NetworkList(ctx, network.ListOptions{Filters: managedFilter()})
+	if err != nil {
+		return orphans, err
+	}
+	for _, item := range networks {
+		if cm.isDeadSession(item.Labels) {
+			orphans.Networks = append(orphans.Networks, item.ID)
+		}
+	}
+
+	return orphans, nil
+}
+
+// RemoveOrphans force removes the given orphaned containers and networks
+func (cm *ContainerManager) RemoveOrphans(ctx context.Context, orphans Orphans) error {
+	for _, containerID := range orphans.Containers {
+		err := cm.client.ContainerRemove(ctx, containerID, container.RemoveOptions{Force: true})
+		if err != nil && !client.IsErrNotFound(err) {
+			return err
+		}
+		log.Debug().Msgf("Removed orphaned container %s", containerID)
+	}
+
+	for _, networkID := range orphans.Networks {
+		err := cm.client.NetworkRemove(ctx, networkID)
+		if err != nil && !client.IsErrNotFound(err) {
+			return err
+		}
+		log.Debug().Msgf("Removed orphaned network %s", networkID)
+	}
+
+	return nil
+}
+
+// Prune removes every Betsy-owned container, network and volume regardless of the session they belong to
+func (cm *ContainerManager) Prune(ctx context.Context) (PruneReport, error) {
+	report := PruneReport{}
+
+	containers, err := cm.client.ContainerList(ctx, container.ListOptions{All: true, Filters: managedFilter()})
+	if err != nil {
+		return report, err
+	}
+	for _, item := range containers {
+		err := cm.client.ContainerRemove(ctx, item.ID, container.RemoveOptions{Force: true})
+		if err != nil && !client.IsErrNotFound(err) {
+			return report, err
+		}
+		report.Containers = append(report.Containers, item.ID)
+	}
+
+	networks, err := cm.client.NetworkList(ctx, network.ListOptions{Filters: managedFilter()})
+	if err != nil {
+		return report, err
+	}
+	for _, item := range networks {
+		err := cm.client.NetworkRemove(ctx, item.ID)
+		if err != nil && !client.IsErrNotFound(err) {
+			return report, err
+		}
+		report.Networks = append(report.Networks, item.Name)
+	}
+
+	volumes, err := cm.client.VolumeList(ctx, volume.ListOptions{Filters: managedFilter()})
+	if err != nil {
+		return report, err
+	}
+	for _, item := range volumes.Volumes {
+		err := cm.client.VolumeRemove(ctx, item.Name, true)
+		if err != nil && !client.IsErrNotFound(err) {
+			return report, err
+		}
+		report.Volumes = append(report.Volumes, item.Name)
+	}
+
+	return report, nil
+}
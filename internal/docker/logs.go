@@ -0,0 +1,92 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/rs/zerolog/log"
+	"github.com/transeptorlabs/betsy/internal/session"
+)
+
+// LogOptions contains the options used to read container logs
+type LogOptions struct {
+	Follow     bool
+	Since      string // timestamp or relative duration (e.g. 10m)
+	Tail       int    // number of lines from the end, 0 for all
+	Timestamps bool
+}
+
+// StreamLogs copies the stdout and stderr of a container to the given writers until the logs end or ctx is canceled
+func (cm *ContainerManager) StreamLogs(ctx context.Context, containerID string, options LogOptions, stdout io.Writer, stderr io.Writer) error {
+	tail := "all"
+	if options.Tail > 0 {
+		tail = strconv.Itoa(options.Tail)
+	}
+
+	reader, err := cm.client.ContainerLogs(ctx, containerID, container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     options.Follow,
+		Since:      options.Since,
+		Tail:       tail,
+		Timestamps: options.Timestamps,
+	})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	_, err = stdcopy.StdCopy(stdout, stderr, reader)
+	if err != nil && ctx.Err() != nil {
+		return nil
+	}
+
+	return err
+}
+
+// ReadLogs returns the logs of a running component (container name, geth or bundler)
+func (cm *ContainerManager) ReadLogs(ctx context.Context, component string, options LogOptions) (string, error) {
+	sessionContainer, err := session.FindContainer(cm.SessionContainers(), component)
+	if err != nil {
+		return "", err
+	}
+
+	options.Follow = false
+	var output bytes.Buffer
+	if err := cm.StreamLogs(ctx, sessionContainer.ContainerID, options, &output, &output); err != nil {
+		return "", err
+	}
+
+	return output.String(), nil
+}
+
+// SaveLogs writes the full logs of every running container to dir
+func (cm *ContainerManager) SaveLogs(ctx context.Context, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, sessionContainer := range cm.SessionContainers() {
+		path := filepath.Join(dir, sessionContainer.Name+".log")
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+
+		err = cm.StreamLogs(ctx, sessionContainer.ContainerID, LogOptions{Timestamps: true}, file, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+
+		log.Info().Msgf("Saved %s container logs to %s", sessionContainer.Name, path)
+	}
+
+	return nil
+}
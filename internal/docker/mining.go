@@ -0,0 +1,91 @@
+package docker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Block production modes of the dev chain
+const (
+	MiningAuto     = "auto"     // a block is mined for every transaction
+	MiningInterval = "interval" // a block is mined every block time
+	MiningManual   = "manual"   // blocks are only mined on demand through the Betsy API, transactions wait for them
+)
+
+// MiningModes returns the supported block production modes
+func MiningModes() []string {
+	return []string{MiningAuto, MiningInterval, MiningManual}
+}
+
+// ConfigureMining checks that an execution client supports the block production mode and adds the args it needs to the container command
+func (cm *ContainerManager) ConfigureMining(image string, mode string, blockTime time.Duration) error {
+	imageFound, ok := cm.supportedImages[image]
+	if !ok {
+		return fmt.Errorf("Image %s is not supported", image)
+	}
+
+	switch mode {
+	case MiningAuto:
+		return nil
+	case MiningInterval:
+		if imageFound.Mining.IntervalArgs == nil {
+			return fmt.Errorf("execution client %s does not support interval mining", image)
+		}
+		if blockTime <= 0 {
+			return fmt.Errorf("interval mining needs a block time")
+		}
+		imageFound.Cmd = appendArgs(imageFound.Cmd, imageFound.ShellCommand, imageFound.Mining.IntervalArgs(blockTime))
+	case MiningManual:
+		if !imageFound.Mining.SupportsManual() {
+			return fmt.Errorf("execution client %s does not support manual mining, use one of: %s", image, strings.Join(cm.ManualMiningClients(), ", "))
+		}
+	default:
+		return fmt.Errorf("Mining mode %s is not supported, choose one of: %s", mode, strings.Join(MiningModes(), ", "))
+	}
+
+	cm.supportedImages[image] = imageFound
+	return nil
+}
+
+// SupportsManual checks if the client can hold the transactions until blocks are mined on demand
+func (m MiningDefinition) SupportsManual() bool {
+	return m.AutomineMethod != "" && m.MineMethod != ""
+}
+
+// ManualMiningClients returns the sorted execution clients supporting manual mining
+func (cm *ContainerManager) ManualMiningClients() []string {
+	clients := make([]string, 0)
+	for _, name := range SupportedExecutionClients() {
+		if cm.supportedImages[name].Mining.SupportsManual() {
+			clients = append(clients, name)
+		}
+	}
+
+	return clients
+}
+
+// GetMiningDefinition returns the block production modes supported by an execution client
+func (cm *ContainerManager) GetMiningDefinition(image string) (MiningDefinition, error) {
+	imageFound, ok := cm.supportedImages[image]
+	if !ok {
+		return MiningDefinition{}, fmt.Errorf("Image %s is not supported", image)
+	}
+
+	return imageFound.Mining, nil
+}
+
+// appendArgs appends args to a container command, shell commands get them appended to their command line
+func appendArgs(cmd []string, shellCommand bool, args []string) []string {
+	result := append([]string{}, cmd...)
+	if len(args) == 0 {
+		return result
+	}
+
+	if shellCommand && len(result) > 0 {
+		result[0] = result[0] + " " + strings.Join(args, " ")
+		return result
+	}
+
+	return append(result, args...)
+}
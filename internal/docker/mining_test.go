@@ -0,0 +1,95 @@
+package docker
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigureIntervalMining(t *testing.T) {
+	cm, _ := newTestManager(t)
+
+	if err := cm.ConfigureMining("geth", MiningInterval, 5*time.Second); err != nil {
+		t.Fatalf("ConfigureMining(geth) error = %v", err)
+	}
+	gethCmd := cm.supportedImages["geth"].Cmd
+	if !slices.Equal(gethCmd[len(gethCmd)-2:], []string{"--dev.period", "5"}) {
+		t.Errorf("geth Cmd = %v, want --dev.period 5 appended", gethCmd)
+	}
+
+	// anvil runs its command line with /bin/sh -c, the args must be part of it
+	if err := cm.ConfigureMining("anvil", MiningInterval, 2*time.Second); err != nil {
+		t.Fatalf("ConfigureMining(anvil) error = %v", err)
+	}
+	anvilCmd := cm.supportedImages["anvil"].Cmd
+	if len(anvilCmd) != 1 || !strings.HasSuffix(anvilCmd[0], " --block-time 2") {
+		t.Errorf("anvil Cmd = %v, want --block-time in the command line", anvilCmd)
+	}
+
+	for blockTime, want := range map[time.Duration]string{time.Minute: "60000ms", 1500 * time.Millisecond: "1500ms"} {
+		cm, _ := newTestManager(t)
+		if err := cm.ConfigureMining("reth", MiningInterval, blockTime); err != nil {
+			t.Fatalf("ConfigureMining(reth) error = %v", err)
+		}
+		rethCmd := cm.supportedImages["reth"].Cmd
+		if !slices.Equal(rethCmd[len(rethCmd)-2:], []string{"--dev.block-time", want}) {
+			t.Errorf("reth Cmd = %v, want --dev.block-time %s appended", rethCmd, want)
+		}
+	}
+}
+
+func TestConfigureMiningUnsupportedModes(t *testing.T) {
+	cm, _ := newTestManager(t)
+	gethCmd := cm.supportedImages["geth"].Cmd
+
+	tests := []struct {
+		image string
+		mode  string
+	}{
+		{"geth", MiningManual},
+		{"reth", MiningManual},
+		{"geth", "sometimes"},
+	}
+	for _, test := range tests {
+		if err := cm.ConfigureMining(test.image, test.mode, time.Second); err == nil {
+			t.Errorf("ConfigureMining(%s, %s) succeeded", test.image, test.mode)
+		}
+	}
+
+	if err := cm.ConfigureMining("anvil", MiningManual, 0); err != nil {
+		t.Errorf("ConfigureMining(anvil, manual) error = %v", err)
+	}
+	if err := cm.ConfigureMining("geth", MiningAuto, 0); err != nil {
+		t.Errorf("ConfigureMining(geth, auto) error = %v", err)
+	}
+	if !slices.Equal(cm.supportedImages["geth"].Cmd, gethCmd) {
+		t.Errorf("geth Cmd changed to %v without interval mining", cm.supportedImages["geth"].Cmd)
+	}
+}
+
+func TestConfigureGethMining(t *testing.T) {
+	cm, _ := newTestManager(t)
+	gethCmd := cm.supportedImages["geth"].Cmd
+
+	// geth --dev cannot hold transactions, manual mining points to the clients that can
+	err := cm.ConfigureMining("geth", MiningManual, 0)
+	if err == nil || !strings.Contains(err.Error(), "use one of: anvil") {
+		t.Errorf("ConfigureMining(geth, manual) error = %v, want the manual mining clients", err)
+	}
+	if !slices.Equal(cm.supportedImages["geth"].Cmd, gethCmd) {
+		t.Errorf("geth Cmd changed to %v by a rejected mode", cm.supportedImages["geth"].Cmd)
+	}
+
+	// In auto mode geth mines blocks on demand with a transaction from its dev account
+	if err := cm.ConfigureMining("geth", MiningAuto, 0); err != nil {
+		t.Fatalf("ConfigureMining(geth, auto) error = %v", err)
+	}
+	definition, err := cm.GetMiningDefinition("geth")
+	if err != nil {
+		t.Fatalf("GetMiningDefinition(geth) error = %v", err)
+	}
+	if !definition.MineWithTx || definition.SupportsManual() {
+		t.Errorf("geth mining = %+v, want blocks mined with a transaction and no manual mode", definition)
+	}
+}
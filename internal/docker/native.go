@@ -0,0 +1,116 @@
+package docker
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/docker/docker/client"
+	"github.com/rs/zerolog/log"
+	"github.com/transeptorlabs/betsy/internal/docker/nativeruntime"
+)
+
+// Runtimes running the Betsy nodes
+const (
+	RuntimeDocker = "docker" // containers of the Docker daemon
+	RuntimeNative = "native" // processes of the binaries installed on the host
+)
+
+// Runtimes returns the supported runtimes
+func Runtimes() []string {
+	return []string{RuntimeDocker, RuntimeNative}
+}
+
+// UseRuntime switches the runtime running the nodes, the native runtime keeps the state of the processes in dir
+func (cm *ContainerManager) UseRuntime(name string, dir string) error {
+	if name == cm.runtimeName {
+		return nil
+	}
+
+	var runtime Runtime
+	switch name {
+	case RuntimeDocker:
+		cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+		if err != nil {
+			return err
+		}
+		runtime = cli
+	case RuntimeNative:
+		runtime = nativeruntime.New(dir)
+	default:
+		return fmt.Errorf("Runtime %s is not supported, choose one of: %s", name, strings.Join(Runtimes(), ", "))
+	}
+
+	if err := cm.client.Close(); err != nil {
+		return err
+	}
+
+	log.Debug().Msgf("Using the %s runtime", name)
+	cm.client = runtime
+	cm.runtimeName = name
+	return nil
+}
+
+// RuntimeName returns the runtime running the nodes, docker or native
+func (cm *ContainerManager) RuntimeName() string {
+	return cm.runtimeName
+}
+
+// OverrideBinary replaces the binary running a supported image with the native runtime, e.g. a path outside of PATH
+func (cm *ContainerManager) OverrideBinary(image string, binary string) error {
+	imageFound, ok := cm.supportedImages[image]
+	if !ok {
+		return fmt.Errorf("Image %s is not supported", image)
+	}
+
+	if imageFound.ShellCommand {
+		// The binary is the first word of the shell command
+		cmd := append([]string{}, imageFound.Cmd...)
+		words := strings.SplitN(cmd[0], " ", 2)
+		words[0] = binary
+		cmd[0] = strings.Join(words, " ")
+		imageFound.Cmd = cmd
+	} else {
+		imageFound.Binary = []string{binary}
+	}
+
+	cm.supportedImages[image] = imageFound
+	return nil
+}
+
+// nativeBinary returns the binary run by a supported image with the native runtime
+func nativeBinary(details ContainerDetails) string {
+	if details.ShellCommand && len(details.Cmd) > 0 {
+		return strings.SplitN(details.Cmd[0], " ", 2)[0]
+	}
+	if len(details.Binary) == 0 {
+		return ""
+	}
+
+	return details.Binary[0]
+}
+
+// CheckNativeBinaries checks that the binaries of the images are installed on the host, it replaces pulling the images with the native runtime
+func (cm *ContainerManager) CheckNativeBinaries(images []string) error {
+	missing := make([]string, 0)
+	for _, image := range images {
+		imageFound, ok := cm.supportedImages[image]
+		if !ok {
+			return fmt.Errorf("Image %s is not supported", image)
+		}
+
+		binary := nativeBinary(imageFound)
+		if binary == "" {
+			return fmt.Errorf("%s cannot run with the native runtime", image)
+		}
+		if _, err := exec.LookPath(binary); err != nil {
+			missing = append(missing, fmt.Sprintf("%s (%s)", binary, image))
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("binaries not found on the host: %s", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
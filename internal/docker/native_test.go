@@ -0,0 +1,242 @@
+//go:build unix
+
+package docker
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+	"github.com/docker/go-connections/nat"
+	"github.com/transeptorlabs/betsy/internal/docker/nativeruntime"
+)
+
+var _ Runtime = (*nativeruntime.Runtime)(nil)
+
+// startShellContainer creates and starts a native container running script with /bin/sh
+func startShellContainer(t *testing.T, runtime *nativeruntime.Runtime, name string, script string, hostConfig *container.HostConfig) string {
+	t.Helper()
+
+	ctx := context.Background()
+	config := &container.Config{
+		Image:      name,
+		Entrypoint: []string{"/bin/sh", "-c"},
+		Cmd:        []string{script},
+		Env:        []string{"GREETING=hello from /data"},
+		Labels:     map[string]string{LabelManaged: "true"},
+	}
+	resp, err := runtime.ContainerCreate(ctx, config, hostConfig, nil, nil, name)
+	if err != nil {
+		t.Fatalf("ContainerCreate(%s) error = %v", name, err)
+	}
+	if err := runtime.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		t.Fatalf("ContainerStart(%s) error = %v", name, err)
+	}
+	t.Cleanup(func() {
+		_ = runtime.ContainerRemove(context.Background(), resp.ID, container.RemoveOptions{Force: true})
+	})
+
+	return resp.ID
+}
+
+// readNativeLogs returns the output of a native container
+func readNativeLogs(t *testing.T, runtime *nativeruntime.Runtime, containerID string) string {
+	t.Helper()
+
+	reader, err := runtime.ContainerLogs(context.Background(), containerID, container.LogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		t.Fatalf("ContainerLogs() error = %v", err)
+	}
+	defer reader.Close()
+
+	var output bytes.Buffer
+	if _, err := stdcopy.StdCopy(&output, &output, reader); err != nil {
+		t.Fatalf("reading the logs error = %v", err)
+	}
+
+	return output.String()
+}
+
+func TestNativeRuntimeTranslatesContainerView(t *testing.T) {
+	runtime := nativeruntime.New(t.TempDir())
+	ctx := context.Background()
+
+	dataVolume, err := runtime.VolumeCreate(ctx, volume.CreateOptions{Name: "betsy-chain"})
+	if err != nil {
+		t.Fatalf("VolumeCreate() error = %v", err)
+	}
+
+	// The peer is only created, its published port is enough to rewrite its address
+	// Other words equal to a port are left as they are, the definitions pass the listen port
+	_, err = runtime.ContainerCreate(ctx, &container.Config{Image: "geth"}, &container.HostConfig{
+		PortBindings: nat.PortMap{"8545/tcp": {{HostPort: "28545"}}},
+	}, nil, nil, "betsy-geth")
+	if err != nil {
+		t.Fatalf("ContainerCreate(betsy-geth) error = %v", err)
+	}
+
+	containerID := startShellContainer(t, runtime, "betsy-transeptor", "echo --port 4337 --chain-id 14337 --network http://betsy-geth:8545 && echo $GREETING", &container.HostConfig{
+		PortBindings: nat.PortMap{"4337/tcp": {{HostPort: "14337"}}},
+		Mounts:       []mount.Mount{{Type: mount.TypeVolume, Source: dataVolume.Name, Target: "/data"}},
+	})
+
+	var logs string
+	waitFor(t, "the container output", func() bool {
+		logs = readNativeLogs(t, runtime, containerID)
+		return strings.Count(logs, "\n") == 2
+	})
+
+	want := "--port 4337 --chain-id 14337 --network http://localhost:28545\nhello from " + dataVolume.Mountpoint + "\n"
+	if logs != want {
+		t.Errorf("logs = %q, want %q", logs, want)
+	}
+}
+
+func TestNativeRuntimeStopAndCrashEvents(t *testing.T) {
+	runtime := nativeruntime.New(t.TempDir())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	messages, _ := runtime.Events(ctx, events.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", LabelManaged+"=true")),
+	})
+
+	crashedID := startShellContainer(t, runtime, "betsy-crash", "exit 3", nil)
+	stoppedID := startShellContainer(t, runtime, "betsy-sleep", "sleep 30", nil)
+
+	timeout := 5
+	if err := runtime.ContainerStop(ctx, stoppedID, container.StopOptions{Timeout: &timeout}); err != nil {
+		t.Fatalf("ContainerStop() error = %v", err)
+	}
+
+	var received []string
+	waitFor(t, "the die events", func() bool {
+		for {
+			select {
+			case message := <-messages:
+				received = append(received, message.Actor.Attributes["name"]+" "+string(message.Action)+" "+message.Actor.Attributes["exitCode"])
+			default:
+				return slices.Contains(received, "betsy-crash die 3") && slices.Contains(received, "betsy-sleep die 143")
+			}
+		}
+	})
+
+	if !slices.Contains(received, "betsy-sleep kill ") {
+		t.Errorf("events = %v, want a kill event before the stopped container dies", received)
+	}
+	if slices.Contains(received, "betsy-crash kill ") {
+		t.Errorf("events = %v, want no kill event for the crashed container", received)
+	}
+
+	for _, containerID := range []string{crashedID, stoppedID} {
+		containerJSON, err := runtime.ContainerInspect(ctx, containerID)
+		if err != nil {
+			t.Fatalf("ContainerInspect() error = %v", err)
+		}
+		if containerJSON.State.Running || containerJSON.State.Status != "exited" {
+			t.Errorf("%s state = %+v, want exited", containerJSON.Name, containerJSON.State)
+		}
+	}
+
+	if err := runtime.ContainerRemove(ctx, stoppedID, container.RemoveOptions{}); err != nil {
+		t.Fatalf("ContainerRemove() error = %v", err)
+	}
+	if _, err := runtime.ContainerInspect(ctx, stoppedID); !client.IsErrNotFound(err) {
+		t.Errorf("ContainerInspect() after remove error = %v, want not found", err)
+	}
+}
+
+func TestNativeRuntimeRemoveRunningContainer(t *testing.T) {
+	dir := t.TempDir()
+	runtime := nativeruntime.New(dir)
+	ctx := context.Background()
+
+	containerID := startShellContainer(t, runtime, "betsy-sleep", "sleep 30", nil)
+
+	if err := runtime.ContainerRemove(ctx, containerID, container.RemoveOptions{}); err == nil {
+		t.Fatal("ContainerRemove() of a running container succeeded without force")
+	}
+
+	// Another Betsy process, e.g. betsy down, removes the processes it did not start
+	if err := nativeruntime.New(dir).ContainerRemove(ctx, containerID, container.RemoveOptions{Force: true}); err != nil {
+		t.Fatalf("ContainerRemove(force) error = %v", err)
+	}
+
+	containers, err := runtime.ContainerList(ctx, container.ListOptions{All: true})
+	if err != nil {
+		t.Fatalf("ContainerList() error = %v", err)
+	}
+	if len(containers) != 0 {
+		t.Errorf("containers = %v, want none after remove", containers)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "containers", "*")); len(matches) != 0 {
+		t.Errorf("container directories %v left after remove", matches)
+	}
+}
+
+func TestRunContainerWithNativeRuntime(t *testing.T) {
+	cm, runtime := newTestManager(t)
+	cm.runtimeName = RuntimeNative
+
+	if err := cm.OverrideBinary("anvil", "/opt/foundry/anvil"); err != nil {
+		t.Fatalf("OverrideBinary(anvil) error = %v", err)
+	}
+	startEthNode(t, cm, "anvil")
+
+	anvil, ok := runtime.Container("betsy-anvil")
+	if !ok {
+		t.Fatal("betsy-anvil container not found")
+	}
+	if !slices.Equal(anvil.Config.Entrypoint, []string{"/bin/sh", "-c"}) {
+		t.Errorf("anvil Entrypoint = %v, want the shell", anvil.Config.Entrypoint)
+	}
+	if !strings.HasPrefix(anvil.Config.Cmd[0], "/opt/foundry/anvil --host") {
+		t.Errorf("anvil Cmd = %v, want the overridden binary", anvil.Config.Cmd)
+	}
+
+	ctx := context.WithValue(context.Background(), BundlerNodeWalletDetails, testWalletDetails)
+	if _, err := cm.RunContainerInTheBackground(ctx, "transeptor", "14337"); err != nil {
+		t.Fatalf("RunContainerInTheBackground(transeptor) error = %v", err)
+	}
+	transeptor, _ := runtime.Container("betsy-transeptor")
+	if !slices.Equal(transeptor.Config.Entrypoint, []string{"transeptor"}) {
+		t.Errorf("transeptor Entrypoint = %v, want [transeptor]", transeptor.Config.Entrypoint)
+	}
+
+	// Processes share the host network, the port flag of the definition carries the host port
+	if i := slices.Index(transeptor.Config.Cmd, "--port"); i < 0 || transeptor.Config.Cmd[i+1] != "14337" {
+		t.Errorf("transeptor Cmd = %v, want --port 14337", transeptor.Config.Cmd)
+	}
+	if _, ok := transeptor.Config.ExposedPorts["14337/tcp"]; !ok {
+		t.Errorf("transeptor ExposedPorts = %v, want the host port", transeptor.Config.ExposedPorts)
+	}
+}
+
+func TestCheckNativeBinaries(t *testing.T) {
+	cm, _ := newTestManager(t)
+
+	if err := cm.OverrideBinary("anvil", "sh"); err != nil {
+		t.Fatalf("OverrideBinary(anvil) error = %v", err)
+	}
+	if err := cm.CheckNativeBinaries([]string{"anvil"}); err != nil {
+		t.Errorf("CheckNativeBinaries(anvil) error = %v", err)
+	}
+
+	if err := cm.OverrideBinary("transeptor", "betsy-missing-bundler"); err != nil {
+		t.Fatalf("OverrideBinary(transeptor) error = %v", err)
+	}
+	err := cm.CheckNativeBinaries([]string{"anvil", "transeptor"})
+	if err == nil || !strings.Contains(err.Error(), "betsy-missing-bundler (transeptor)") {
+		t.Errorf("CheckNativeBinaries() error = %v, want the missing bundler binary", err)
+	}
+}
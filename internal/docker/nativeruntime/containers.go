@@ -0,0 +1,714 @@
+package nativeruntime
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/transeptorlabs/betsy/internal/utils"
+)
+
+// Files of a container directory
+const (
+	recordFile = "container.json"
+	logFile    = "output.log"
+	rootfsDir  = "rootfs" // working directory of the process, its tmp sub directory is used as TMPDIR and replaces /tmp
+)
+
+// defaultStopTimeout is the time given to a process to exit after SIGTERM when no timeout is set, like the Docker default
+const defaultStopTimeout = 10 * time.Second
+
+// stopPollInterval is the interval used to wait for a process owned by another Betsy process to exit
+const stopPollInterval = 100 * time.Millisecond
+
+// record contains the state of a container persisted in its directory
+type record struct {
+	ID         string
+	Name       string
+	Config     *container.Config
+	HostConfig *container.HostConfig
+	PID        int
+	Running    bool
+	Stopping   bool // the process is being stopped through the API, its exit is not a crash
+	ExitCode   int
+	Created    time.Time
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// process is a container process started by this runtime
+type process struct {
+	pid  int
+	done chan struct{}
+}
+
+// execInstance is a command created to run in a container
+type execInstance struct {
+	containerID string
+	cmd         []string
+	exitCode    *int
+}
+
+// ContainerCreate records a container, the image is only used as a label since the process runs the binary of its entrypoint
+func (r *Runtime) ContainerCreate(ctx context.Context, config *container.Config, hostConfig *container.HostConfig, networkingConfig *network.NetworkingConfig, platform *ocispec.Platform, containerName string) (container.CreateResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := os.Stat(r.containerDir(containerName)); err == nil {
+		return container.CreateResponse{}, errdefs.Conflict(fmt.Errorf("The container name %q is already in use", "/"+containerName))
+	}
+	if hostConfig == nil {
+		hostConfig = &container.HostConfig{}
+	}
+
+	c := &record{
+		ID:         newID(),
+		Name:       containerName,
+		Config:     config,
+		HostConfig: hostConfig,
+		Created:    time.Now(),
+	}
+	if err := os.MkdirAll(filepath.Join(r.containerDir(containerName), rootfsDir, "tmp"), 0755); err != nil {
+		return container.CreateResponse{}, err
+	}
+	if err := r.save(c); err != nil {
+		return container.CreateResponse{}, err
+	}
+
+	return container.CreateResponse{ID: c.ID}, nil
+}
+
+// ContainerStart starts the process of a created or stopped container, its output is appended to the container log file
+func (r *Runtime) ContainerStart(ctx context.Context, containerID string, options container.StartOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.find(containerID)
+	if err != nil {
+		return err
+	}
+	if c.Running {
+		return nil
+	}
+
+	args := r.translate(c, append(append([]string{}, c.Config.Entrypoint...), c.Config.Cmd...))
+	if len(args) == 0 {
+		return errdefs.InvalidParameter(fmt.Errorf("container %s has no command to run", c.Name))
+	}
+
+	binary, err := exec.LookPath(args[0])
+	if err != nil {
+		return errdefs.NotFound(fmt.Errorf("binary %s of container %s: %w", args[0], c.Name, err))
+	}
+	if binary, err = filepath.Abs(binary); err != nil {
+		return err
+	}
+
+	output, err := os.OpenFile(filepath.Join(r.containerDir(c.Name), logFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	// The log file is handed to the process so its output outlives the Betsy process that started it
+	cmd := exec.Command(binary, args[1:]...)
+	cmd.Dir = filepath.Join(r.containerDir(c.Name), rootfsDir)
+	cmd.Env = r.environment(c)
+	cmd.Stdout = output
+	cmd.Stderr = output
+	cmd.SysProcAttr = sysProcAttr()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	c.PID = cmd.Process.Pid
+	c.Running = true
+	c.Stopping = false
+	c.ExitCode = 0
+	c.StartedAt = time.Now()
+	c.FinishedAt = time.Time{}
+	if err := r.save(c); err != nil {
+		_ = cmd.Process.Kill()
+		return err
+	}
+
+	p := &process{pid: c.PID, done: make(chan struct{})}
+	r.processes[c.ID] = p
+	r.publishLocked(c, events.ActionStart, nil)
+
+	go r.wait(c.ID, cmd, p)
+	return nil
+}
+
+// wait records the exit of a process started by this runtime and publishes its die event
+func (r *Runtime) wait(containerID string, cmd *exec.Cmd, p *process) {
+	_ = cmd.Wait()
+	exitCode := exitCode(cmd.ProcessState)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer close(p.done)
+
+	c, err := r.load(containerID)
+	if err != nil || c.PID != p.pid {
+		// The container was removed or started again by another Betsy process
+		return
+	}
+
+	c.Running = false
+	c.ExitCode = exitCode
+	c.FinishedAt = time.Now()
+	if err := r.save(c); err != nil {
+		return
+	}
+
+	if c.Stopping {
+		r.publishLocked(c, events.ActionKill, nil)
+	}
+	r.publishLocked(c, events.ActionDie, map[string]string{"exitCode": strconv.Itoa(exitCode)})
+}
+
+// ContainerInspect returns the state of a container
+func (r *Runtime) ContainerInspect(ctx context.Context, containerID string) (types.ContainerJSON, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.find(containerID)
+	if err != nil {
+		return types.ContainerJSON{}, err
+	}
+
+	state := &types.ContainerState{
+		Status:   status(c),
+		Running:  c.Running,
+		Pid:      c.PID,
+		ExitCode: c.ExitCode,
+	}
+	if !c.StartedAt.IsZero() {
+		state.StartedAt = c.StartedAt.Format(time.RFC3339Nano)
+	}
+	if !c.FinishedAt.IsZero() {
+		state.FinishedAt = c.FinishedAt.Format(time.RFC3339Nano)
+	}
+
+	return types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID:         c.ID,
+			Created:    c.Created.Format(time.RFC3339Nano),
+			Name:       "/" + c.Name,
+			Image:      c.Config.Image,
+			LogPath:    filepath.Join(r.containerDir(c.Name), logFile),
+			HostConfig: c.HostConfig,
+			State:      state,
+		},
+		Config: c.Config,
+	}, nil
+}
+
+// ContainerList lists the running containers, or all of them with options.All, matching the label filters
+func (r *Runtime) ContainerList(ctx context.Context, options container.ListOptions) ([]types.Container, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	records, err := r.records()
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]types.Container, 0)
+	for _, c := range records {
+		if !c.Running && !options.All {
+			continue
+		}
+		if !options.Filters.MatchKVList("label", c.Config.Labels) {
+			continue
+		}
+
+		containers = append(containers, types.Container{
+			ID:      c.ID,
+			Names:   []string{"/" + c.Name},
+			Image:   c.Config.Image,
+			Command: strings.Join(append(append([]string{}, c.Config.Entrypoint...), c.Config.Cmd...), " "),
+			Created: c.Created.Unix(),
+			Labels:  c.Config.Labels,
+			State:   status(c),
+		})
+	}
+
+	return containers, nil
+}
+
+// ContainerStop sends SIGTERM to the process group of a container and SIGKILL once the timeout expires, it also stops the processes started by other Betsy processes
+func (r *Runtime) ContainerStop(ctx context.Context, containerID string, options container.StopOptions) error {
+	r.mu.Lock()
+	c, err := r.find(containerID)
+	if err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	if !c.Running {
+		r.mu.Unlock()
+		return nil
+	}
+
+	c.Stopping = true
+	if err := r.save(c); err != nil {
+		r.mu.Unlock()
+		return err
+	}
+	p := r.processes[c.ID]
+	if p != nil && p.pid != c.PID {
+		p = nil
+	}
+	r.mu.Unlock()
+
+	timeout := defaultStopTimeout
+	if options.Timeout != nil {
+		timeout = time.Duration(*options.Timeout) * time.Second
+	}
+
+	exitCode := 143
+	if err := terminate(c.PID); err != nil {
+		return fmt.Errorf("failed to stop container %s: %w", c.Name, err)
+	}
+	if !waitForExit(ctx, c.PID, p, timeout) {
+		exitCode = 137
+		if err := kill(c.PID); err != nil {
+			return fmt.Errorf("failed to kill container %s: %w", c.Name, err)
+		}
+		waitForExit(ctx, c.PID, p, -1)
+	}
+
+	if p != nil {
+		// The waiter of the process recorded its exit
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err = r.load(c.ID)
+	if err != nil {
+		return err
+	}
+	c.Running = false
+	c.ExitCode = exitCode
+	c.FinishedAt = time.Now()
+
+	return r.save(c)
+}
+
+// waitForExit waits until a process exits, a negative timeout waits until ctx is done, it returns false when the process is still running
+func waitForExit(ctx context.Context, pid int, p *process, timeout time.Duration) bool {
+	var expired <-chan time.Time
+	if timeout >= 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	// Processes of other Betsy processes are not children of this process and are polled
+	var done <-chan struct{}
+	if p != nil {
+		done = p.done
+	}
+	ticker := time.NewTicker(stopPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return true
+		case <-ticker.C:
+			if p == nil && !utils.IsProcessAlive(pid) {
+				return true
+			}
+		case <-expired:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// ContainerRemove removes a stopped container and its directory, or a running one with options.Force
+func (r *Runtime) ContainerRemove(ctx context.Context, containerID string, options container.RemoveOptions) error {
+	r.mu.Lock()
+	c, err := r.find(containerID)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if c.Running {
+		if !options.Force {
+			return errdefs.Conflict(fmt.Errorf("cannot remove container %q: container is running", "/"+c.Name))
+		}
+
+		timeout := 0
+		if err := r.ContainerStop(ctx, c.ID, container.StopOptions{Timeout: &timeout}); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := os.RemoveAll(r.containerDir(c.Name)); err != nil {
+		return err
+	}
+	delete(r.processes, c.ID)
+	r.publishLocked(c, events.ActionDestroy, nil)
+
+	return nil
+}
+
+// ContainerLogs returns the output of a container multiplexed like the Docker logs stream, the output is not timestamped so Since is not supported and Timestamps is ignored
+func (r *Runtime) ContainerLogs(ctx context.Context, containerID string, options container.LogsOptions) (io.ReadCloser, error) {
+	if options.Since != "" {
+		return nil, errdefs.InvalidParameter(errors.New("the native runtime does not timestamp the logs, since is not supported"))
+	}
+
+	r.mu.Lock()
+	c, err := r.find(containerID)
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath.Join(r.containerDir(c.Name), logFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return io.NopCloser(&bytes.Buffer{}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := io.ReadAll(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if tail, err := strconv.Atoi(options.Tail); err == nil && tail < len(lines) {
+		lines = lines[len(lines)-tail:]
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		defer file.Close()
+
+		stdout := stdcopy.NewStdWriter(writer, stdcopy.Stdout)
+		if _, err := stdout.Write([]byte(strings.Join(lines, ""))); err != nil {
+			writer.CloseWithError(err)
+			return
+		}
+		if !options.Follow {
+			writer.Close()
+			return
+		}
+
+		writer.CloseWithError(r.follow(ctx, c.ID, file, stdout))
+	}()
+
+	return reader, nil
+}
+
+// follow copies the output appended to the log file until the container exits or ctx is done
+func (r *Runtime) follow(ctx context.Context, containerID string, file *os.File, w io.Writer) error {
+	buffer := make([]byte, 32*1024)
+	for {
+		n, err := file.Read(buffer)
+		if n > 0 {
+			if _, err := w.Write(buffer[:n]); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		r.mu.Lock()
+		c, err := r.find(containerID)
+		r.mu.Unlock()
+		if err != nil || !c.Running {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(stopPollInterval):
+		}
+	}
+}
+
+// ContainerExecCreate creates a command to run on the host for a running container
+func (r *Runtime) ContainerExecCreate(ctx context.Context, containerID string, options container.ExecOptions) (types.IDResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, err := r.find(containerID)
+	if err != nil {
+		return types.IDResponse{}, err
+	}
+	if !c.Running {
+		return types.IDResponse{}, errdefs.Conflict(fmt.Errorf("container %s is not running", c.ID))
+	}
+
+	id := newID()
+	r.execs[id] = &execInstance{containerID: c.ID, cmd: options.Cmd}
+
+	return types.IDResponse{ID: id}, nil
+}
+
+// ContainerExecAttach runs a created command with the paths and addresses of the container translated and returns its multiplexed output, missing commands exit with code 127
+func (r *Runtime) ContainerExecAttach(ctx context.Context, execID string, config container.ExecAttachOptions) (types.HijackedResponse, error) {
+	r.mu.Lock()
+	instance, ok := r.execs[execID]
+	if !ok {
+		r.mu.Unlock()
+		return types.HijackedResponse{}, errdefs.NotFound(fmt.Errorf("No such exec instance: %s", execID))
+	}
+
+	c, err := r.find(instance.containerID)
+	if err != nil {
+		r.mu.Unlock()
+		return types.HijackedResponse{}, err
+	}
+	args := r.translate(c, instance.cmd)
+	env := r.environment(c)
+	r.mu.Unlock()
+
+	var stdout, stderr bytes.Buffer
+	exitCode := 0
+	if len(args) == 0 {
+		return types.HijackedResponse{}, errdefs.InvalidParameter(errors.New("no exec command specified"))
+	}
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = filepath.Join(r.containerDir(c.Name), rootfsDir)
+	cmd.Env = env
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	var exitErr *exec.ExitError
+	switch err := cmd.Run(); {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		exitCode = exitErr.ExitCode()
+	case errors.Is(err, exec.ErrNotFound):
+		stderr.WriteString(args[0] + ": not found\n")
+		exitCode = 127
+	default:
+		return types.HijackedResponse{}, err
+	}
+
+	r.mu.Lock()
+	instance.exitCode = &exitCode
+	r.mu.Unlock()
+
+	var output bytes.Buffer
+	if _, err := stdcopy.NewStdWriter(&output, stdcopy.Stdout).Write(stdout.Bytes()); err != nil {
+		return types.HijackedResponse{}, err
+	}
+	if _, err := stdcopy.NewStdWriter(&output, stdcopy.Stderr).Write(stderr.Bytes()); err != nil {
+		return types.HijackedResponse{}, err
+	}
+
+	conn, peer := net.Pipe()
+	peer.Close()
+
+	return types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(&output)}, nil
+}
+
+// ContainerExecInspect returns the exit code of an attached command
+func (r *Runtime) ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	instance, ok := r.execs[execID]
+	if !ok {
+		return container.ExecInspect{}, errdefs.NotFound(fmt.Errorf("No such exec instance: %s", execID))
+	}
+
+	inspect := container.ExecInspect{ExecID: execID, ContainerID: instance.containerID, Running: instance.exitCode == nil}
+	if instance.exitCode != nil {
+		inspect.ExitCode = *instance.exitCode
+	}
+
+	return inspect, nil
+}
+
+// CopyFromContainer returns a file of a container, with its path translated to the host, as a tar stream
+func (r *Runtime) CopyFromContainer(ctx context.Context, containerID, srcPath string) (io.ReadCloser, container.PathStat, error) {
+	r.mu.Lock()
+	c, err := r.find(containerID)
+	if err != nil {
+		r.mu.Unlock()
+		return nil, container.PathStat{}, err
+	}
+	hostPath := r.translate(c, []string{srcPath})[0]
+	r.mu.Unlock()
+
+	content, err := os.ReadFile(hostPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, container.PathStat{}, errdefs.NotFound(fmt.Errorf("Could not find the file %s in container %s", srcPath, c.Name))
+	}
+	if err != nil {
+		return nil, container.PathStat{}, err
+	}
+
+	archive, err := tarFile(filepath.Base(hostPath), content)
+	if err != nil {
+		return nil, container.PathStat{}, err
+	}
+
+	return io.NopCloser(archive), container.PathStat{Name: filepath.Base(hostPath), Size: int64(len(content)), Mode: 0600}, nil
+}
+
+// environment returns the environment of a container process, the host environment with the container env translated and TMPDIR in the container directory
+func (r *Runtime) environment(c *record) []string {
+	env := append(os.Environ(), r.translate(c, c.Config.Env)...)
+	return append(env, "TMPDIR="+filepath.Join(r.containerDir(c.Name), rootfsDir, "tmp"))
+}
+
+// containerDir returns the directory of a container
+func (r *Runtime) containerDir(containerName string) string {
+	return filepath.Join(r.containersDir(), strings.TrimPrefix(containerName, "/"))
+}
+
+// find returns a container by ID or name with its running state refreshed
+func (r *Runtime) find(containerID string) (*record, error) {
+	c, err := r.load(containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The process exited while no Betsy process was waiting for it
+	if c.Running && !r.isRunning(c) {
+		c.Running = false
+		c.FinishedAt = time.Now()
+		if err := r.save(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// isRunning checks if the process of a container is still running
+func (r *Runtime) isRunning(c *record) bool {
+	if p, ok := r.processes[c.ID]; ok && p.pid == c.PID {
+		select {
+		case <-p.done:
+			return false
+		default:
+			return true
+		}
+	}
+
+	return utils.IsProcessAlive(c.PID)
+}
+
+// load reads a container record by ID or name
+func (r *Runtime) load(containerID string) (*record, error) {
+	content, err := os.ReadFile(filepath.Join(r.containerDir(containerID), recordFile))
+	if err == nil {
+		var c record
+		if err := json.Unmarshal(content, &c); err != nil {
+			return nil, err
+		}
+		return &c, nil
+	}
+
+	records, err := r.records()
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range records {
+		if c.ID == containerID {
+			return c, nil
+		}
+	}
+
+	return nil, errdefs.NotFound(fmt.Errorf("No such container: %s", containerID))
+}
+
+// records reads the records of all containers
+func (r *Runtime) records() ([]*record, error) {
+	entries, err := os.ReadDir(r.containersDir())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*record, 0, len(entries))
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(r.containersDir(), entry.Name(), recordFile))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var c record
+		if err := json.Unmarshal(content, &c); err != nil {
+			return nil, err
+		}
+		if c.Running && !r.isRunning(&c) {
+			c.Running = false
+		}
+		records = append(records, &c)
+	}
+
+	return records, nil
+}
+
+// save writes a container record
+func (r *Runtime) save(c *record) error {
+	content, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(r.containerDir(c.Name), recordFile), content, 0644)
+}
+
+// status returns the Docker state name of a container
+func status(c *record) string {
+	switch {
+	case c.Running:
+		return "running"
+	case c.StartedAt.IsZero():
+		return "created"
+	default:
+		return "exited"
+	}
+}
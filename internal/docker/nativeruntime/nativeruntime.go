@@ -0,0 +1,215 @@
+// Package nativeruntime runs the Betsy nodes as child processes of the Betsy process, it implements the container runtime of the container manager without a Docker daemon
+package nativeruntime
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/errdefs"
+)
+
+// ErrImagesNotSupported is returned by the image operations, the native runtime runs binaries installed on the host
+var ErrImagesNotSupported = errors.New("the native runtime runs the binaries installed on the host and does not manage images")
+
+// Runtime runs containers as host processes, their state is kept in a directory so other Betsy processes can inspect, stop and remove them
+type Runtime struct {
+	dir       string
+	mu        sync.Mutex
+	processes map[string]*process // containers started by this runtime keyed by ID
+	execs     map[string]*execInstance
+	networks  map[string]network.Summary
+	watchers  []*watcher
+}
+
+// watcher is a subscriber of the container events stream
+type watcher struct {
+	filters filters.Args
+	ch      chan events.Message
+}
+
+// New creates a native runtime keeping the containers and volumes state in dir
+func New(dir string) *Runtime {
+	return &Runtime{
+		dir:       dir,
+		processes: map[string]*process{},
+		execs:     map[string]*execInstance{},
+		networks:  map[string]network.Summary{},
+	}
+}
+
+// Ping checks that the state directory can be used
+func (r *Runtime) Ping(ctx context.Context) (types.Ping, error) {
+	if err := os.MkdirAll(r.containersDir(), 0755); err != nil {
+		return types.Ping{}, err
+	}
+
+	return types.Ping{OSType: "native"}, nil
+}
+
+// Close releases the runtime, the processes it started keep running until they are stopped
+func (r *Runtime) Close() error {
+	return nil
+}
+
+// ImagePull fails, binaries need to be installed on the host
+func (r *Runtime) ImagePull(ctx context.Context, refStr string, options image.PullOptions) (io.ReadCloser, error) {
+	return nil, ErrImagesNotSupported
+}
+
+// ImageList returns no images
+func (r *Runtime) ImageList(ctx context.Context, options image.ListOptions) ([]image.Summary, error) {
+	return []image.Summary{}, nil
+}
+
+// ImageSave fails, binaries need to be installed on the host
+func (r *Runtime) ImageSave(ctx context.Context, imageIDs []string) (io.ReadCloser, error) {
+	return nil, ErrImagesNotSupported
+}
+
+// ImageLoad fails, binaries need to be installed on the host
+func (r *Runtime) ImageLoad(ctx context.Context, input io.Reader, quiet bool) (image.LoadResponse, error) {
+	return image.LoadResponse{}, ErrImagesNotSupported
+}
+
+// NetworkCreate records a network, native processes share the host network
+func (r *Runtime) NetworkCreate(ctx context.Context, name string, options network.CreateOptions) (network.CreateResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, item := range r.networks {
+		if item.Name == name {
+			return network.CreateResponse{}, errdefs.Conflict(fmt.Errorf("network with name %s already exists", name))
+		}
+	}
+
+	id := newID()
+	r.networks[id] = network.Summary{ID: id, Name: name, Driver: "host", Labels: options.Labels, Created: time.Now()}
+
+	return network.CreateResponse{ID: id}, nil
+}
+
+// NetworkList lists the networks recorded by this runtime matching the label filters
+func (r *Runtime) NetworkList(ctx context.Context, options network.ListOptions) ([]network.Summary, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	networks := make([]network.Summary, 0)
+	for _, item := range r.networks {
+		if options.Filters.MatchKVList("label", item.Labels) {
+			networks = append(networks, item)
+		}
+	}
+
+	return networks, nil
+}
+
+// NetworkRemove removes a network by ID or name, networks of other processes are not known and ignored
+func (r *Runtime) NetworkRemove(ctx context.Context, networkID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, item := range r.networks {
+		if id == networkID || item.Name == networkID {
+			delete(r.networks, id)
+		}
+	}
+
+	return nil
+}
+
+// Events streams the events of the containers started by this runtime matching the filters until ctx is done
+func (r *Runtime) Events(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error) {
+	messages := make(chan events.Message, 100)
+	errs := make(chan error, 1)
+
+	w := &watcher{filters: options.Filters, ch: messages}
+	r.mu.Lock()
+	r.watchers = append(r.watchers, w)
+	r.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+
+		r.mu.Lock()
+		r.watchers = slices.DeleteFunc(r.watchers, func(item *watcher) bool {
+			return item == w
+		})
+		r.mu.Unlock()
+
+		errs <- ctx.Err()
+	}()
+
+	return messages, errs
+}
+
+// publishLocked sends a container event to the watchers whose filters match the container, the caller holds the lock
+func (r *Runtime) publishLocked(c *record, action events.Action, attributes map[string]string) {
+	actor := map[string]string{
+		"name":  c.Name,
+		"image": c.Config.Image,
+	}
+	for key, value := range c.Config.Labels {
+		actor[key] = value
+	}
+	for key, value := range attributes {
+		actor[key] = value
+	}
+
+	message := events.Message{
+		Type:     events.ContainerEventType,
+		Action:   action,
+		Actor:    events.Actor{ID: c.ID, Attributes: actor},
+		TimeNano: time.Now().UnixNano(),
+	}
+
+	for _, w := range r.watchers {
+		if !w.filters.MatchKVList("label", c.Config.Labels) {
+			continue
+		}
+		if w.filters.Contains("type") && !w.filters.ExactMatch("type", string(events.ContainerEventType)) {
+			continue
+		}
+		if w.filters.Contains("event") && !w.filters.ExactMatch("event", string(action)) {
+			continue
+		}
+
+		select {
+		case w.ch <- message:
+		default:
+		}
+	}
+}
+
+// containersDir returns the directory of the containers state
+func (r *Runtime) containersDir() string {
+	return filepath.Join(r.dir, "containers")
+}
+
+// volumesDir returns the directory of the volumes
+func (r *Runtime) volumesDir() string {
+	return filepath.Join(r.dir, "volumes")
+}
+
+// newID returns a random identifier like the Docker resource IDs
+func newID() string {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(bytes)
+}
@@ -0,0 +1,33 @@
+//go:build !unix
+
+package nativeruntime
+
+import (
+	"os"
+	"syscall"
+)
+
+// sysProcAttr returns the default process attributes on platforms without sessions
+func sysProcAttr() *syscall.SysProcAttr {
+	return nil
+}
+
+// terminate kills the process of a container, signals other than kill are not supported
+func terminate(pid int) error {
+	return kill(pid)
+}
+
+// kill kills the process of a container
+func kill(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return nil
+	}
+
+	return process.Kill()
+}
+
+// exitCode returns the exit code of a process
+func exitCode(state *os.ProcessState) int {
+	return state.ExitCode()
+}
@@ -0,0 +1,41 @@
+//go:build unix
+
+package nativeruntime
+
+import (
+	"os"
+	"syscall"
+)
+
+// sysProcAttr starts the process in its own session so it outlives the Betsy process and its terminal, its process group is signaled as a whole
+func sysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+// terminate sends SIGTERM to the process group of a container
+func terminate(pid int) error {
+	return signalGroup(pid, syscall.SIGTERM)
+}
+
+// kill sends SIGKILL to the process group of a container
+func kill(pid int) error {
+	return signalGroup(pid, syscall.SIGKILL)
+}
+
+// signalGroup signals a process group, a group that already exited is not an error
+func signalGroup(pid int, signal syscall.Signal) error {
+	if err := syscall.Kill(-pid, signal); err != nil && err != syscall.ESRCH {
+		return err
+	}
+
+	return nil
+}
+
+// exitCode returns the exit code of a process, 128 plus the signal number when it was killed by a signal like a shell
+func exitCode(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+
+	return state.ExitCode()
+}
@@ -0,0 +1,144 @@
+package nativeruntime
+
+import (
+	"archive/tar"
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/docker/docker/api/types/mount"
+)
+
+// Characters allowed around a rewritten word of an arg
+const (
+	wordStart = " \t\n=:,\"'"
+	wordEnd   = " \t\n/,:\"'"
+)
+
+// rewrite replaces a word of the container view of an arg with its host view
+type rewrite struct {
+	from  string
+	to    string
+	start string // characters allowed before the word besides the start of the arg
+}
+
+// translate rewrites the args of a container so the process can run on the host: the addresses of the other containers and the mount targets
+func (r *Runtime) translate(c *record, args []string) []string {
+	rewrites := make([]rewrite, 0)
+
+	// Containers reach each other by name on the session network, processes use the published ports
+	peers, _ := r.records()
+	for _, peer := range peers {
+		for containerPort, hostPort := range publishedPorts(peer) {
+			rewrites = append(rewrites, rewrite{from: peer.Name + ":" + containerPort, to: "localhost:" + hostPort, start: wordStart + "/@"})
+		}
+	}
+
+	for target, source := range r.mountSources(c) {
+		rewrites = append(rewrites, rewrite{from: target, to: source, start: wordStart})
+	}
+
+	// Longer words first so a nested mount target wins over its parent
+	sort.SliceStable(rewrites, func(i, j int) bool {
+		return len(rewrites[i].from) > len(rewrites[j].from)
+	})
+
+	translated := make([]string, len(args))
+	for i, arg := range args {
+		translated[i] = rewriteWords(arg, rewrites, r.dir)
+	}
+
+	return translated
+}
+
+// rewriteWords applies the rewrites to an arg in a single pass, the host paths under the runtime directory are kept as they are
+func rewriteWords(arg string, rewrites []rewrite, keep string) string {
+	var result strings.Builder
+	for i := 0; i < len(arg); {
+		if i == 0 || strings.ContainsRune(wordStart, rune(arg[i-1])) {
+			if keep != "" && strings.HasPrefix(arg[i:], keep) {
+				result.WriteString(keep)
+				i += len(keep)
+				continue
+			}
+		}
+
+		matched := false
+		for _, item := range rewrites {
+			if i > 0 && !strings.ContainsRune(item.start, rune(arg[i-1])) {
+				continue
+			}
+			if !strings.HasPrefix(arg[i:], item.from) {
+				continue
+			}
+
+			end := i + len(item.from)
+			if end < len(arg) && !strings.ContainsRune(wordEnd, rune(arg[end])) {
+				continue
+			}
+
+			result.WriteString(item.to)
+			i = end
+			matched = true
+			break
+		}
+
+		if !matched {
+			result.WriteByte(arg[i])
+			i++
+		}
+	}
+
+	return result.String()
+}
+
+// mountSources returns the host directories of the mounts of a container keyed by their target, /tmp is replaced by the tmp directory of the container
+func (r *Runtime) mountSources(c *record) map[string]string {
+	sources := map[string]string{
+		"/tmp": filepath.Join(r.containerDir(c.Name), rootfsDir, "tmp"),
+	}
+
+	for _, item := range c.HostConfig.Mounts {
+		switch item.Type {
+		case mount.TypeVolume:
+			sources[item.Target] = r.volumeData(item.Source)
+		case mount.TypeBind:
+			sources[item.Target] = item.Source
+		}
+	}
+
+	return sources
+}
+
+// publishedPorts returns the host ports published by a container keyed by their container port
+func publishedPorts(c *record) map[string]string {
+	ports := map[string]string{}
+	for port, bindings := range c.HostConfig.PortBindings {
+		for _, binding := range bindings {
+			if binding.HostPort != "" {
+				ports[port.Port()] = binding.HostPort
+			}
+		}
+	}
+
+	return ports
+}
+
+// tarFile returns a tar archive containing a single regular file
+func tarFile(name string, content []byte) (*bytes.Buffer, error) {
+	var archive bytes.Buffer
+	writer := tar.NewWriter(&archive)
+	header := &tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg}
+	if err := writer.WriteHeader(header); err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(content); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return &archive, nil
+}
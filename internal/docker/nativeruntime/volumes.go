@@ -0,0 +1,123 @@
+package nativeruntime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+)
+
+// Files of a volume directory
+const (
+	volumeFile    = "volume.json"
+	volumeDataDir = "_data" // mounted directory, like the Docker local driver
+)
+
+// VolumeCreate creates a named volume directory, creating an existing volume returns it unchanged
+func (r *Runtime) VolumeCreate(ctx context.Context, options volume.CreateOptions) (volume.Volume, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if item, err := r.loadVolume(options.Name); err == nil {
+		return item, nil
+	}
+
+	item := volume.Volume{
+		Name:       options.Name,
+		Driver:     "local",
+		Labels:     options.Labels,
+		Mountpoint: r.volumeData(options.Name),
+		Scope:      "local",
+		CreatedAt:  time.Now().Format(time.RFC3339),
+	}
+	if err := os.MkdirAll(item.Mountpoint, 0755); err != nil {
+		return volume.Volume{}, err
+	}
+
+	content, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return volume.Volume{}, err
+	}
+	if err := os.WriteFile(filepath.Join(r.volumesDir(), options.Name, volumeFile), content, 0644); err != nil {
+		return volume.Volume{}, err
+	}
+
+	return item, nil
+}
+
+// VolumeInspect returns a volume by name
+func (r *Runtime) VolumeInspect(ctx context.Context, volumeID string) (volume.Volume, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.loadVolume(volumeID)
+}
+
+// VolumeList lists the volumes matching the label filters
+func (r *Runtime) VolumeList(ctx context.Context, options volume.ListOptions) (volume.ListResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries, err := os.ReadDir(r.volumesDir())
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return volume.ListResponse{}, err
+	}
+
+	volumes := make([]*volume.Volume, 0)
+	for _, entry := range entries {
+		item, err := r.loadVolume(entry.Name())
+		if errdefs.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return volume.ListResponse{}, err
+		}
+
+		if options.Filters.MatchKVList("label", item.Labels) {
+			volumes = append(volumes, &item)
+		}
+	}
+
+	return volume.ListResponse{Volumes: volumes}, nil
+}
+
+// VolumeRemove removes a volume directory and its data
+func (r *Runtime) VolumeRemove(ctx context.Context, volumeID string, force bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.loadVolume(volumeID); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(filepath.Join(r.volumesDir(), volumeID))
+}
+
+// volumeData returns the directory mounted for a volume
+func (r *Runtime) volumeData(name string) string {
+	return filepath.Join(r.volumesDir(), name, volumeDataDir)
+}
+
+// loadVolume reads the metadata of a volume
+func (r *Runtime) loadVolume(name string) (volume.Volume, error) {
+	content, err := os.ReadFile(filepath.Join(r.volumesDir(), name, volumeFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return volume.Volume{}, errdefs.NotFound(fmt.Errorf("get %s: no such volume", name))
+	}
+	if err != nil {
+		return volume.Volume{}, err
+	}
+
+	var item volume.Volume
+	if err := json.Unmarshal(content, &item); err != nil {
+		return volume.Volume{}, err
+	}
+
+	return item, nil
+}
@@ -0,0 +1,73 @@
+package docker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/docker/docker/api/types/network"
+	"github.com/rs/zerolog/log"
+)
+
+const networkNamePrefix = "betsy-network-"
+
+// newSessionID returns a random identifier for the current Betsy session
+func newSessionID() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// ensureNetwork creates the user-defined bridge network of the session if it does not exist yet
+func (cm *ContainerManager) ensureNetwork(ctx context.Context) error {
+	if cm.networkID != "" {
+		return nil
+	}
+
+	networkName := networkNamePrefix + cm.SessionID
+	resp, err := cm.client.NetworkCreate(ctx, networkName, network.CreateOptions{
+		Driver: "bridge",
+		Labels: cm.labels(),
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Debug().Msgf("Created docker network %s: %s", networkName, resp.ID)
+	cm.networkID = resp.ID
+	cm.NetworkName = networkName
+
+	return nil
+}
+
+// endpointsConfig returns the networking config attaching a container to the session network under its container name
+func (cm *ContainerManager) endpointsConfig(containerName string) *network.NetworkingConfig {
+	return &network.NetworkingConfig{
+		EndpointsConfig: map[string]*network.EndpointSettings{
+			cm.NetworkName: {
+				NetworkID: cm.networkID,
+				Aliases:   []string{containerName},
+			},
+		},
+	}
+}
+
+// removeNetwork removes the session network
+func (cm *ContainerManager) removeNetwork(ctx context.Context) error {
+	if cm.networkID == "" {
+		return nil
+	}
+
+	if err := cm.client.NetworkRemove(ctx, cm.networkID); err != nil {
+		return err
+	}
+
+	log.Debug().Msgf("Successfully removed docker network %s", cm.NetworkName)
+	cm.networkID = ""
+	cm.NetworkName = ""
+
+	return nil
+}
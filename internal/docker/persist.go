@@ -0,0 +1,95 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/rs/zerolog/log"
+)
+
+// LabelPersist marks the volumes holding the chain data of a persisted environment, they are not owned by a session and are kept by betsy prune
+const LabelPersist = "io.betsy.persist"
+
+// persistStopTimeout is the time given to an eth node with a persisted datadir to flush its state before it is killed
+const persistStopTimeout = 30
+
+// persistNamePattern matches the names accepted by Docker for volumes
+var persistNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// PersistVolumeName returns the name of the volume holding the chain data of a persisted environment
+func PersistVolumeName(name string) string {
+	return "betsy-" + name
+}
+
+// IsValidPersistName checks if a persisted environment name can be used in a volume name
+func IsValidPersistName(name string) bool {
+	return persistNamePattern.MatchString(name)
+}
+
+// SupportsPersistence checks if an execution client can keep its chain data in a volume
+func (cm *ContainerManager) SupportsPersistence(image string) bool {
+	imageFound, ok := cm.supportedImages[image]
+	return ok && imageFound.DataDir != ""
+}
+
+// EnablePersistence creates the volume of a persisted environment if needed and mounts it as the datadir of the eth node, it returns true when the volume already existed and the chain is resumed
+func (cm *ContainerManager) EnablePersistence(ctx context.Context, name string, ethClient string) (bool, error) {
+	if !IsValidPersistName(name) {
+		return false, fmt.Errorf("invalid persist name %q, use letters, digits, '_', '.' and '-'", name)
+	}
+
+	if !cm.SupportsPersistence(ethClient) {
+		return false, fmt.Errorf("execution client %s does not support persisted chain state", ethClient)
+	}
+
+	volumeName := PersistVolumeName(name)
+	_, err := cm.client.VolumeInspect(ctx, volumeName)
+	if err != nil && !client.IsErrNotFound(err) {
+		return false, err
+	}
+
+	resumed := err == nil
+	if !resumed {
+		_, err := cm.client.VolumeCreate(ctx, volume.CreateOptions{
+			Name:   volumeName,
+			Labels: map[string]string{LabelPersist: name},
+		})
+		if err != nil {
+			return false, err
+		}
+		log.Info().Msgf("Created volume %s to persist the chain state", volumeName)
+	} else {
+		log.Info().Msgf("Resuming the chain state persisted in volume %s", volumeName)
+	}
+
+	cm.persistVolume = volumeName
+	return resumed, nil
+}
+
+// persistedMounts returns the datadir flags and volume mount of an eth node when persistence is enabled
+func (cm *ContainerManager) persistedMounts(imageFound ContainerDetails) ([]string, []mount.Mount) {
+	if cm.persistVolume == "" || imageFound.NodeType != "eth" || imageFound.DataDir == "" {
+		return nil, nil
+	}
+
+	return []string{"--datadir", imageFound.DataDir}, []mount.Mount{
+		{
+			Type:   mount.TypeVolume,
+			Source: cm.persistVolume,
+			Target: imageFound.DataDir,
+		},
+	}
+}
+
+// stopTimeout returns the seconds a container is given to stop, eth nodes with a persisted datadir are stopped gracefully
+func stopTimeout(nodeType string, persisted bool) int {
+	if persisted && nodeType == "eth" {
+		return persistStopTimeout
+	}
+
+	return 0
+}
@@ -0,0 +1,116 @@
+package docker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/transeptorlabs/betsy/internal/docker/fakeruntime"
+)
+
+func TestEnablePersistence(t *testing.T) {
+	cm, runtime := newTestManager(t)
+
+	resumed, err := cm.EnablePersistence(context.Background(), "dev", "geth")
+	if err != nil {
+		t.Fatalf("EnablePersistence() error = %v", err)
+	}
+	if resumed {
+		t.Error("EnablePersistence() of a new volume resumed the chain")
+	}
+	if !slices.Contains(runtime.History(), "volume create betsy-dev") {
+		t.Errorf("events = %v, want the volume to be created", runtime.History())
+	}
+
+	next, err := NewContainerManagerWithRuntime(runtime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed, err = next.EnablePersistence(context.Background(), "dev", "geth")
+	if err != nil {
+		t.Fatalf("EnablePersistence() error = %v", err)
+	}
+	if !resumed {
+		t.Error("EnablePersistence() of an existing volume did not resume the chain")
+	}
+
+	volumes, err := runtime.VolumeList(context.Background(), volume.ListOptions{Filters: managedFilter()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(volumes.Volumes) != 0 {
+		t.Errorf("persisted volume is labeled as managed by a session and would be pruned")
+	}
+}
+
+func TestEnablePersistenceRejectsUnsupportedSetups(t *testing.T) {
+	cm, _ := newTestManager(t)
+
+	if _, err := cm.EnablePersistence(context.Background(), "dev", "anvil"); err == nil {
+		t.Error("EnablePersistence() with an execution client without datadir succeeded")
+	}
+
+	if _, err := cm.EnablePersistence(context.Background(), "../dev", "geth"); err == nil {
+		t.Error("EnablePersistence() with an invalid name succeeded")
+	}
+}
+
+// startPersistedGeth chdirs to a temporary directory and starts geth with its datadir persisted in the betsy-dev volume, commands scripts the one-shot containers run with the geth image
+func startPersistedGeth(t *testing.T, cm *ContainerManager, runtime *fakeruntime.Runtime, commands map[string]fakeruntime.ExecResult) string {
+	t.Helper()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	keystorePath := "/data/keystore/UTC--2024-06-01T00-00-00.000000000Z--71562b71999873db5b286df957af199ec94617f7"
+	runtime.Script(cm.supportedImages["geth"].imageName, fakeruntime.Behavior{
+		Exec: map[string]fakeruntime.ExecResult{
+			"find /data -maxdepth 2 -type f -name UTC--*": {Stdout: keystorePath + "\n"},
+		},
+		Files: map[string][]byte{
+			keystorePath: []byte(`{"address":"71562b71999873db5b286df957af199ec94617f7"}`),
+		},
+		Commands: commands,
+	})
+
+	if _, err := cm.EnablePersistence(context.Background(), "dev", "geth"); err != nil {
+		t.Fatalf("EnablePersistence() error = %v", err)
+	}
+
+	startEthNode(t, cm, "geth")
+	return keystorePath
+}
+
+func TestPersistedEthNodeMountsDatadir(t *testing.T) {
+	cm, runtime := newTestManager(t)
+	keystorePath := startPersistedGeth(t, cm, runtime, nil)
+
+	geth, ok := runtime.Container("betsy-geth")
+	if !ok {
+		t.Fatal("geth container was not created")
+	}
+
+	index := slices.Index(geth.Config.Cmd, "--datadir")
+	if index < 0 || index+1 >= len(geth.Config.Cmd) || geth.Config.Cmd[index+1] != "/data" {
+		t.Errorf("Cmd = %v, want --datadir /data", geth.Config.Cmd)
+	}
+
+	wantMounts := []mount.Mount{{Type: mount.TypeVolume, Source: "betsy-dev", Target: "/data"}}
+	if !slices.Equal(geth.HostConfig.Mounts, wantMounts) {
+		t.Errorf("Mounts = %v, want %v", geth.HostConfig.Mounts, wantMounts)
+	}
+
+	if cm.EthNodeSigner.KeystoreFile != filepath.Base(keystorePath) {
+		t.Errorf("KeystoreFile = %q, want the dev account of the persisted datadir", cm.EthNodeSigner.KeystoreFile)
+	}
+}
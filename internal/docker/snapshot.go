@@ -0,0 +1,240 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+	"github.com/rs/zerolog/log"
+	"github.com/transeptorlabs/betsy/internal/session"
+)
+
+// Labels of the volumes holding a snapshot of a persisted chain
+const (
+	LabelSnapshot     = "io.betsy.snapshot"      // name of the persisted chain
+	LabelSnapshotName = "io.betsy.snapshot.name" // name of the snapshot
+)
+
+// copyVolumeScript replaces the content of the target volume with the content of the source volume
+const copyVolumeScript = "find /target -mindepth 1 -delete && cp -a /source/. /target/"
+
+// copyPollInterval is the interval used to wait for the volume copy to finish
+const copyPollInterval = 200 * time.Millisecond
+
+// ErrNoPersistedSession is returned when a snapshot is requested for a session whose chain state is not persisted
+var ErrNoPersistedSession = errors.New("snapshots need a session started with --persist")
+
+// Snapshot contains the details of a snapshot of a persisted chain
+type Snapshot struct {
+	Name      string
+	Volume    string
+	CreatedAt string
+}
+
+// SnapshotVolumeName returns the name of the volume holding a snapshot of a persisted chain
+func SnapshotVolumeName(persist string, name string) string {
+	return PersistVolumeName(persist) + "-snapshot-" + name
+}
+
+// SaveSnapshot stops the eth node of a session, copies its datadir volume to a snapshot volume and starts it again
+func (cm *ContainerManager) SaveSnapshot(ctx context.Context, state *session.State, name string) error {
+	if state.Persist == "" {
+		return ErrNoPersistedSession
+	}
+	if !IsValidPersistName(name) {
+		return fmt.Errorf("invalid snapshot name %q, use letters, digits, '_', '.' and '-'", name)
+	}
+
+	ethNode, err := session.FindContainer(state.Containers, "eth")
+	if err != nil {
+		return err
+	}
+
+	snapshotVolume := SnapshotVolumeName(state.Persist, name)
+	_, err = cm.client.VolumeCreate(ctx, volume.CreateOptions{
+		Name: snapshotVolume,
+		Labels: map[string]string{
+			LabelSnapshot:     state.Persist,
+			LabelSnapshotName: name,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return cm.withStoppedEthNode(ctx, state, ethNode, nil, func(image string) error {
+		log.Info().Msgf("Saving snapshot %s of chain %s...", name, state.Persist)
+		return cm.copyVolume(ctx, image, PersistVolumeName(state.Persist), snapshotVolume)
+	})
+}
+
+// RestoreSnapshot stops the containers of a session, replaces the datadir volume of the eth node with a snapshot and restarts the eth node then the bundlers with a clean state
+func (cm *ContainerManager) RestoreSnapshot(ctx context.Context, state *session.State, name string) error {
+	if state.Persist == "" {
+		return ErrNoPersistedSession
+	}
+
+	snapshotVolume := SnapshotVolumeName(state.Persist, name)
+	if _, err := cm.client.VolumeInspect(ctx, snapshotVolume); err != nil {
+		if client.IsErrNotFound(err) {
+			return fmt.Errorf("snapshot %s of chain %s not found", name, state.Persist)
+		}
+		return err
+	}
+
+	ethNode, err := session.FindContainer(state.Containers, "eth")
+	if err != nil {
+		return err
+	}
+
+	bundlers := make([]session.Container, 0)
+	for _, sessionContainer := range state.Containers {
+		if sessionContainer.NodeType == "bundler" {
+			bundlers = append(bundlers, sessionContainer)
+		}
+	}
+
+	return cm.withStoppedEthNode(ctx, state, ethNode, bundlers, func(image string) error {
+		log.Info().Msgf("Restoring snapshot %s of chain %s...", name, state.Persist)
+		return cm.copyVolume(ctx, image, snapshotVolume, PersistVolumeName(state.Persist))
+	})
+}
+
+// ListSnapshots returns the snapshots of a persisted chain sorted by name
+func (cm *ContainerManager) ListSnapshots(ctx context.Context, persist string) ([]Snapshot, error) {
+	volumes, err := cm.client.VolumeList(ctx, volume.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("label", LabelSnapshot+"="+persist)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]Snapshot, 0, len(volumes.Volumes))
+	for _, item := range volumes.Volumes {
+		snapshots = append(snapshots, Snapshot{
+			Name:      item.Labels[LabelSnapshotName],
+			Volume:    item.Name,
+			CreatedAt: item.CreatedAt,
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Name < snapshots[j].Name
+	})
+
+	return snapshots, nil
+}
+
+// withStoppedEthNode stops the bundlers and the eth node of a session, runs fn with the eth node image and starts the eth node then the bundlers again
+func (cm *ContainerManager) withStoppedEthNode(ctx context.Context, state *session.State, ethNode session.Container, bundlers []session.Container, fn func(image string) error) error {
+	containerJSON, err := cm.client.ContainerInspect(ctx, ethNode.ContainerID)
+	if err != nil {
+		return err
+	}
+
+	noWaitTimeout := 0
+	for _, bundler := range bundlers {
+		log.Debug().Msgf("Stopping %s container", bundler.Name)
+		if err := cm.client.ContainerStop(ctx, bundler.ContainerID, container.StopOptions{Timeout: &noWaitTimeout}); err != nil {
+			return err
+		}
+	}
+
+	log.Debug().Msgf("Stopping %s container", ethNode.Name)
+	timeout := persistStopTimeout
+	if err := cm.client.ContainerStop(ctx, ethNode.ContainerID, container.StopOptions{Timeout: &timeout}); err != nil {
+		return err
+	}
+
+	fnErr := fn(containerJSON.Config.Image)
+
+	// Start the containers again even if fn failed so the session keeps running
+	for _, sessionContainer := range append([]session.Container{ethNode}, bundlers...) {
+		if err := cm.client.ContainerStart(ctx, sessionContainer.ContainerID, container.StartOptions{}); err != nil {
+			return errors.Join(fnErr, err)
+		}
+
+		if err := cm.waitUntilReady(ctx, sessionContainer.Name, cm.sessionContainerDetails(state, sessionContainer)); err != nil {
+			return errors.Join(fnErr, err)
+		}
+	}
+
+	return fnErr
+}
+
+// copyVolume replaces the content of the target volume with the content of the source volume using a one-shot container of the given image
+func (cm *ContainerManager) copyVolume(ctx context.Context, image string, source string, target string) error {
+	config := &container.Config{
+		Image:      image,
+		Entrypoint: []string{"/bin/sh", "-c"},
+		Cmd:        []string{copyVolumeScript},
+		Labels:     cm.labels(),
+	}
+
+	hostConfig := &container.HostConfig{
+		Mounts: []mount.Mount{
+			{Type: mount.TypeVolume, Source: source, Target: "/source", ReadOnly: true},
+			{Type: mount.TypeVolume, Source: target, Target: "/target"},
+		},
+	}
+
+	resp, err := cm.client.ContainerCreate(ctx, config, hostConfig, nil, nil, "betsy-snapshot-"+cm.SessionID)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := cm.client.ContainerRemove(ctx, resp.ID, container.RemoveOptions{Force: true}); err != nil {
+			log.Err(err).Msgf("Failed to remove volume copy container %s", resp.ID)
+		}
+	}()
+
+	if err := cm.client.ContainerStart(ctx, resp.ID, container.StartOptions{}); err != nil {
+		return err
+	}
+
+	for {
+		containerJSON, err := cm.client.ContainerInspect(ctx, resp.ID)
+		if err != nil {
+			return err
+		}
+
+		if !containerJSON.State.Running {
+			if containerJSON.State.ExitCode != 0 {
+				return cm.withLastLogLines(ctx, resp.ID, fmt.Errorf("copying volume %s to %s failed with code %d", source, target, containerJSON.State.ExitCode))
+			}
+
+			log.Debug().Msgf("Copied volume %s to %s", source, target)
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(copyPollInterval):
+		}
+	}
+}
+
+// sessionContainerDetails returns the details used to probe the readiness of a container of a session owned by another process
+func (cm *ContainerManager) sessionContainerDetails(state *session.State, sessionContainer session.Container) ContainerDetails {
+	details, ok := cm.supportedImages[sessionContainer.Name]
+	if !ok && sessionContainer.NodeType == "bundler" {
+		// additional bundler instances share the definition of the configured bundler
+		details = cm.supportedImages[state.Bundler]
+	}
+
+	details.ContainerID = sessionContainer.ContainerID
+	details.HostPort = sessionContainer.HostPort
+	details.RPCPath = strings.TrimPrefix(sessionContainer.RPCURL, "http://localhost:"+sessionContainer.HostPort)
+	details.ReadinessProbe.Method = sessionContainer.ProbeMethod
+
+	return details
+}
@@ -0,0 +1,120 @@
+package docker
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/transeptorlabs/betsy/internal/docker/fakeruntime"
+	"github.com/transeptorlabs/betsy/internal/session"
+)
+
+// startPersistedSession starts a persisted geth node and a transeptor bundler and returns the session state used by the snapshot commands
+func startPersistedSession(t *testing.T, copyExitCode int) (*ContainerManager, *fakeruntime.Runtime, *session.State) {
+	t.Helper()
+
+	cm, runtime := newTestManager(t)
+	startPersistedGeth(t, cm, runtime, map[string]fakeruntime.ExecResult{
+		"/bin/sh -c " + copyVolumeScript: {ExitCode: copyExitCode},
+	})
+
+	ctx := context.WithValue(context.Background(), BundlerNodeWalletDetails, testWalletDetails)
+	if _, err := cm.RunContainerInTheBackground(ctx, "transeptor", "4337"); err != nil {
+		t.Fatalf("RunContainerInTheBackground(transeptor) error = %v", err)
+	}
+
+	return cm, runtime, &session.State{
+		SessionID:  cm.SessionID,
+		Bundler:    "transeptor",
+		Persist:    "dev",
+		Containers: cm.SessionContainers(),
+	}
+}
+
+// eventsSince returns the events recorded after the first n events
+func eventsSince(runtime *fakeruntime.Runtime, n int) []string {
+	return runtime.History()[n:]
+}
+
+func TestSaveSnapshot(t *testing.T) {
+	cm, runtime, state := startPersistedSession(t, 0)
+	before := len(runtime.History())
+
+	if err := cm.SaveSnapshot(context.Background(), state, "baseline"); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+
+	helper := "betsy-snapshot-" + cm.SessionID
+	want := []string{
+		"volume create betsy-dev-snapshot-baseline",
+		"stop betsy-geth",
+		"create " + helper,
+		"start " + helper,
+		"remove " + helper,
+		"start betsy-geth",
+	}
+	if got := eventsSince(runtime, before); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+
+	snapshots, err := cm.ListSnapshots(context.Background(), "dev")
+	if err != nil {
+		t.Fatalf("ListSnapshots() error = %v", err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Name != "baseline" || snapshots[0].Volume != "betsy-dev-snapshot-baseline" {
+		t.Errorf("ListSnapshots() = %v, want the baseline snapshot", snapshots)
+	}
+}
+
+func TestRestoreSnapshotRestartsBundlers(t *testing.T) {
+	cm, runtime, state := startPersistedSession(t, 0)
+	if err := cm.SaveSnapshot(context.Background(), state, "baseline"); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+	before := len(runtime.History())
+
+	if err := cm.RestoreSnapshot(context.Background(), state, "baseline"); err != nil {
+		t.Fatalf("RestoreSnapshot() error = %v", err)
+	}
+
+	helper := "betsy-snapshot-" + cm.SessionID
+	want := []string{
+		"stop betsy-transeptor",
+		"stop betsy-geth",
+		"create " + helper,
+		"start " + helper,
+		"remove " + helper,
+		"start betsy-geth",
+		"start betsy-transeptor",
+	}
+	if got := eventsSince(runtime, before); !slices.Equal(got, want) {
+		t.Errorf("events = %v, want %v", got, want)
+	}
+
+	if err := cm.RestoreSnapshot(context.Background(), state, "missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("RestoreSnapshot() of a missing snapshot error = %v", err)
+	}
+}
+
+func TestFailedSnapshotRestartsEthNode(t *testing.T) {
+	cm, runtime, state := startPersistedSession(t, 1)
+
+	if err := cm.SaveSnapshot(context.Background(), state, "baseline"); err == nil {
+		t.Fatal("SaveSnapshot() with a failing copy succeeded")
+	}
+
+	geth, ok := runtime.Container("betsy-geth")
+	if !ok || !geth.Running {
+		t.Error("geth was not restarted after the failed snapshot")
+	}
+}
+
+func TestSnapshotRequiresPersistedSession(t *testing.T) {
+	cm, _ := newTestManager(t)
+
+	err := cm.SaveSnapshot(context.Background(), &session.State{}, "baseline")
+	if err != ErrNoPersistedSession {
+		t.Errorf("SaveSnapshot() error = %v, want %v", err, ErrNoPersistedSession)
+	}
+}
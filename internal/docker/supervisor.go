@@ -0,0 +1,299 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/rs/zerolog/log"
+)
+
+// maxCrashHistory is the number of crashes kept by the supervisor
+const maxCrashHistory = 50
+
+// RestartPolicy controls how the supervisor restarts the containers that crashed
+type RestartPolicy struct {
+	MaxRestarts int           // restarts allowed per container, 0 disables restarts
+	Backoff     time.Duration // delay before the first restart, doubled after each restart of the same container
+	MaxBackoff  time.Duration
+}
+
+// DefaultRestartPolicy returns the restart policy used when none is configured
+func DefaultRestartPolicy() RestartPolicy {
+	return RestartPolicy{
+		MaxRestarts: 5,
+		Backoff:     time.Second,
+		MaxBackoff:  30 * time.Second,
+	}
+}
+
+// backoff returns the delay before the given restart attempt (1 based)
+func (p RestartPolicy) backoff(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+
+	return delay
+}
+
+// CrashReport records a container exit detected by the supervisor
+type CrashReport struct {
+	Name      string
+	NodeType  string
+	ExitCode  int
+	OOMKilled bool
+	At        time.Time
+	Attempt   int    // restart attempt made for the crash, 0 when the container was not restarted
+	Restarted bool   // the container was restarted and is ready again
+	Error     string // why the container was not restarted
+}
+
+// String describes the crash and its outcome
+func (r CrashReport) String() string {
+	reason := fmt.Sprintf("exited with code %d", r.ExitCode)
+	if r.OOMKilled {
+		reason = "was killed by the OOM killer"
+	}
+
+	outcome := "restart pending"
+	switch {
+	case r.Restarted:
+		outcome = fmt.Sprintf("restarted (attempt %d)", r.Attempt)
+	case r.Error != "":
+		outcome = "not restarted: " + r.Error
+	}
+
+	return fmt.Sprintf("%s %s, %s", r.Name, reason, outcome)
+}
+
+// SupervisorHooks are called by the supervisor so the environment can react to crashes
+type SupervisorHooks struct {
+	OnCrash   func(report CrashReport)                            // called when a crash is detected, before the container is restarted
+	OnRestart func(ctx context.Context, report CrashReport) error // called once a restarted container is ready, e.g. to re-wire the bundler
+}
+
+// Supervisor watches the containers of the session and restarts the ones that crash
+type Supervisor struct {
+	cm       *ContainerManager
+	policy   RestartPolicy
+	hooks    SupervisorHooks
+	mu       sync.Mutex
+	history  []CrashReport
+	attempts map[string]int
+	wg       sync.WaitGroup
+}
+
+// NewSupervisor creates a supervisor for the containers started by the container manager
+func (cm *ContainerManager) NewSupervisor(policy RestartPolicy, hooks SupervisorHooks) *Supervisor {
+	return &Supervisor{
+		cm:       cm,
+		policy:   policy,
+		hooks:    hooks,
+		attempts: make(map[string]int),
+	}
+}
+
+// History returns the crashes detected since the session started, oldest first
+func (s *Supervisor) History() []CrashReport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := make([]CrashReport, len(s.history))
+	copy(history, s.history)
+	return history
+}
+
+// Run watches the Docker events of the session containers until ctx is done
+func (s *Supervisor) Run(ctx context.Context) {
+	defer s.wg.Wait()
+
+	log.Info().Msgf("Supervising containers (max %d restarts per container)", s.policy.MaxRestarts)
+	for {
+		s.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// The events stream was interrupted, e.g. by a daemon restart
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// watch handles the events of a single events stream subscription
+func (s *Supervisor) watch(ctx context.Context) {
+	messages, errs := s.cm.client.Events(ctx, events.ListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("type", string(events.ContainerEventType)),
+			filters.Arg("label", LabelSession+"="+s.cm.SessionID),
+		),
+	})
+
+	// Containers stopped or killed through the API (betsy down, snapshot restore) also die, they are not crashes
+	killed := make(map[string]bool)
+	oomKilled := make(map[string]bool)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-errs:
+			if ctx.Err() == nil {
+				log.Err(err).Msg("Container events stream failed, subscribing again")
+			}
+			return
+		case message := <-messages:
+			id := message.Actor.ID
+			switch message.Action {
+			case events.ActionKill:
+				killed[id] = true
+			case events.ActionOOM:
+				oomKilled[id] = true
+			case events.ActionStart:
+				delete(killed, id)
+				delete(oomKilled, id)
+			case events.ActionDie:
+				if killed[id] && !oomKilled[id] {
+					continue
+				}
+
+				exitCode, _ := strconv.Atoi(message.Actor.Attributes["exitCode"])
+				s.crashed(ctx, id, exitCode, oomKilled[id])
+			}
+		}
+	}
+}
+
+// crashed records the crash of a session container and restarts it in the background when the policy allows it
+func (s *Supervisor) crashed(ctx context.Context, containerID string, exitCode int, oomKilled bool) {
+	name, details, ok := s.cm.findStarted(containerID)
+	if !ok {
+		return
+	}
+
+	if !oomKilled {
+		// The die event does not tell OOM kills apart when the oom event was missed
+		if containerJSON, err := s.cm.client.ContainerInspect(ctx, containerID); err == nil && containerJSON.State != nil {
+			oomKilled = containerJSON.State.OOMKilled
+		}
+	}
+
+	report := CrashReport{
+		Name:      name,
+		NodeType:  details.NodeType,
+		ExitCode:  exitCode,
+		OOMKilled: oomKilled,
+		At:        time.Now(),
+	}
+
+	s.mu.Lock()
+	s.attempts[name]++
+	attempt := s.attempts[name]
+	s.mu.Unlock()
+
+	switch {
+	case details.NodeType == "eth" && s.cm.persistVolume == "":
+		report.Error = "the chain state of the in-memory eth node is lost, restart betsy"
+	case s.policy.MaxRestarts == 0:
+		report.Error = "restarts are disabled"
+	case attempt > s.policy.MaxRestarts:
+		report.Error = fmt.Sprintf("gave up after %d restarts", s.policy.MaxRestarts)
+	default:
+		report.Attempt = attempt
+	}
+
+	s.record(report)
+	log.Error().Msgf("Container %s", report)
+	if s.hooks.OnCrash != nil {
+		s.hooks.OnCrash(report)
+	}
+
+	if report.Attempt == 0 {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.restart(ctx, report, details)
+	}()
+}
+
+// restart starts a crashed container again after the backoff delay and waits until it is ready
+func (s *Supervisor) restart(ctx context.Context, report CrashReport, details ContainerDetails) {
+	delay := s.policy.backoff(report.Attempt)
+	log.Info().Msgf("Restarting %s container in %s (attempt %d of %d)", report.Name, delay, report.Attempt, s.policy.MaxRestarts)
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(delay):
+	}
+
+	err := s.cm.client.ContainerStart(ctx, details.ContainerID, container.StartOptions{})
+	if err == nil {
+		err = s.cm.waitUntilReady(ctx, report.Name, details)
+	}
+	if err == nil && s.hooks.OnRestart != nil {
+		err = s.hooks.OnRestart(ctx, report)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	if err != nil {
+		log.Err(err).Msgf("Failed to restart %s container", report.Name)
+		report.Error = err.Error()
+	} else {
+		log.Info().Msgf("Container %s restarted", report.Name)
+		report.Restarted = true
+	}
+	s.update(report)
+}
+
+// record appends a crash to the history, dropping the oldest one when it is full
+func (s *Supervisor) record(report CrashReport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.history) == maxCrashHistory {
+		s.history = s.history[1:]
+	}
+	s.history = append(s.history, report)
+}
+
+// update replaces a crash of the history with its outcome, crashes dropped from the history in the meantime are ignored
+func (s *Supervisor) update(report CrashReport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := len(s.history) - 1; i >= 0; i-- {
+		if s.history[i].Name == report.Name && s.history[i].At.Equal(report.At) {
+			s.history[i] = report
+			return
+		}
+	}
+}
+
+// findStarted returns the name and details of a container started by the container manager
+func (cm *ContainerManager) findStarted(containerID string) (string, ContainerDetails, bool) {
+	for _, name := range cm.startedImages() {
+		details := cm.supportedImages[name]
+		if details.ContainerID == containerID {
+			return name, details, true
+		}
+	}
+
+	return "", ContainerDetails{}, false
+}
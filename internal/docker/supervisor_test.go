@@ -0,0 +1,165 @@
+package docker
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/transeptorlabs/betsy/internal/docker/fakeruntime"
+)
+
+// testRestartPolicy restarts crashed containers without waiting
+var testRestartPolicy = RestartPolicy{MaxRestarts: 2, Backoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+
+// startSupervisor starts an anvil node and a transeptor bundler and supervises them until the test ends
+func startSupervisor(t *testing.T, policy RestartPolicy, hooks SupervisorHooks) (*Supervisor, *fakeruntime.Runtime) {
+	t.Helper()
+
+	cm, runtime := newTestManager(t)
+	startEthNode(t, cm, "anvil")
+
+	ctx := context.WithValue(context.Background(), BundlerNodeWalletDetails, testWalletDetails)
+	if _, err := cm.RunContainerInTheBackground(ctx, "transeptor", "4337"); err != nil {
+		t.Fatalf("RunContainerInTheBackground(transeptor) error = %v", err)
+	}
+
+	supervisor := cm.NewSupervisor(policy, hooks)
+	runCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		supervisor.Run(runCtx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	waitFor(t, "the events subscription", func() bool { return runtime.Subscribers() == 1 })
+	return supervisor, runtime
+}
+
+// waitFor fails the test when condition does not hold within a second
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if condition() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestSupervisorRestartsCrashedBundler(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	supervisor, runtime := startSupervisor(t, testRestartPolicy, SupervisorHooks{
+		OnCrash: func(report CrashReport) {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, "crash "+report.Name)
+		},
+		OnRestart: func(ctx context.Context, report CrashReport) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, "restart "+report.Name)
+			return nil
+		},
+	})
+
+	if err := runtime.Crash("betsy-transeptor", 1); err != nil {
+		t.Fatalf("Crash() error = %v", err)
+	}
+
+	waitFor(t, "the bundler restart", func() bool {
+		history := supervisor.History()
+		return len(history) == 1 && history[0].Restarted
+	})
+
+	report := supervisor.History()[0]
+	if report.Name != "transeptor" || report.NodeType != "bundler" || report.ExitCode != 1 || report.Attempt != 1 {
+		t.Errorf("History()[0] = %+v, want the first restart of transeptor after exit code 1", report)
+	}
+	if c, _ := runtime.Container("betsy-transeptor"); !c.Running {
+		t.Error("betsy-transeptor is not running after the restart")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if want := []string{"crash transeptor", "restart transeptor"}; !slices.Equal(calls, want) {
+		t.Errorf("hooks calls = %v, want %v", calls, want)
+	}
+}
+
+func TestSupervisorIgnoresStoppedContainers(t *testing.T) {
+	supervisor, runtime := startSupervisor(t, testRestartPolicy, SupervisorHooks{})
+
+	c, _ := runtime.Container("betsy-transeptor")
+	if err := runtime.ContainerStop(context.Background(), c.ID, container.StopOptions{}); err != nil {
+		t.Fatalf("ContainerStop() error = %v", err)
+	}
+
+	// A crash afterwards proves the stop event was processed
+	if err := runtime.Crash("betsy-anvil", 1); err != nil {
+		t.Fatalf("Crash() error = %v", err)
+	}
+	waitFor(t, "the eth node crash", func() bool { return len(supervisor.History()) == 1 })
+
+	if report := supervisor.History()[0]; report.Name != "anvil" {
+		t.Errorf("History() = %v, want only the anvil crash", supervisor.History())
+	}
+}
+
+func TestSupervisorDoesNotRestartInMemoryEthNode(t *testing.T) {
+	supervisor, runtime := startSupervisor(t, testRestartPolicy, SupervisorHooks{})
+
+	if err := runtime.OOMKill("betsy-anvil"); err != nil {
+		t.Fatalf("OOMKill() error = %v", err)
+	}
+	waitFor(t, "the eth node crash", func() bool { return len(supervisor.History()) == 1 })
+
+	report := supervisor.History()[0]
+	if !report.OOMKilled || report.Attempt != 0 || report.Error == "" {
+		t.Errorf("History()[0] = %+v, want an OOM kill that is not restarted", report)
+	}
+	if c, _ := runtime.Container("betsy-anvil"); c.Running {
+		t.Error("betsy-anvil was restarted without its chain state")
+	}
+}
+
+func TestSupervisorGivesUpAfterMaxRestarts(t *testing.T) {
+	supervisor, runtime := startSupervisor(t, testRestartPolicy, SupervisorHooks{})
+
+	for crash := 1; crash <= testRestartPolicy.MaxRestarts+1; crash++ {
+		if err := runtime.Crash("betsy-transeptor", 2); err != nil {
+			t.Fatalf("Crash() error = %v", err)
+		}
+		waitFor(t, "the crash to be handled", func() bool {
+			history := supervisor.History()
+			return len(history) == crash && (history[crash-1].Restarted || history[crash-1].Error != "")
+		})
+	}
+
+	history := supervisor.History()
+	last := history[len(history)-1]
+	if last.Attempt != 0 || last.Error != "gave up after 2 restarts" {
+		t.Errorf("last crash = %+v, want the supervisor to give up", last)
+	}
+}
+
+func TestRestartPolicyBackoff(t *testing.T) {
+	policy := RestartPolicy{MaxRestarts: 5, Backoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	var got []time.Duration
+	for attempt := 1; attempt <= 4; attempt++ {
+		got = append(got, policy.backoff(attempt))
+	}
+
+	if want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}; !slices.Equal(got, want) {
+		t.Errorf("backoff() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package mempool
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/transeptorlabs/betsy/internal/data"
+)
+
+// UserOpComparison contains which bundlers saw a userOp in their mempool and which one included it
+type UserOpComparison struct {
+	UserOpHash common.Hash
+	Op         *data.UserOpV7Hexify
+	SeenBy     map[string]time.Time // bundler name to the time the userOp was first seen in its mempool
+	IncludedBy string               // bundler name, or signer address when it is not a Betsy bundler
+	TxHash     common.Hash
+}
+
+// Compare merges the userOps tracked by the mempools of several bundlers, sorted by the time they were first seen
+func Compare(mempools []*UserOpMempool) []UserOpComparison {
+	signers := make(map[common.Address]string)
+	for _, m := range mempools {
+		signers[m.signerAddress] = m.name
+	}
+
+	comparisons := make(map[common.Hash]*UserOpComparison)
+	firstSeen := make(map[common.Hash]time.Time)
+	for _, m := range mempools {
+		m.mutex.Lock()
+		for userOpHash, entry := range m.userOps {
+			comparison, ok := comparisons[userOpHash]
+			if !ok {
+				comparison = &UserOpComparison{
+					UserOpHash: userOpHash,
+					Op:         entry.op,
+					SeenBy:     make(map[string]time.Time),
+				}
+				comparisons[userOpHash] = comparison
+			}
+
+			comparison.SeenBy[m.name] = entry.seenAt
+			if seen, ok := firstSeen[userOpHash]; !ok || entry.seenAt.Before(seen) {
+				firstSeen[userOpHash] = entry.seenAt
+			}
+
+			if entry.status == StatusIncluded {
+				comparison.TxHash = entry.txHash
+				comparison.IncludedBy = entry.includedBy.Hex()
+				if name, ok := signers[entry.includedBy]; ok {
+					comparison.IncludedBy = name
+				}
+			}
+		}
+		m.mutex.Unlock()
+	}
+
+	result := make([]UserOpComparison, 0, len(comparisons))
+	for _, comparison := range comparisons {
+		result = append(result, *comparison)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return firstSeen[result[i].UserOpHash].Before(firstSeen[result[j].UserOpHash])
+	})
+
+	return result
+}
+
+// MergeUserOps returns the userOps seen by any of the mempools
+func MergeUserOps(mempools []*UserOpMempool) map[common.Hash]*data.UserOpV7Hexify {
+	ops := make(map[common.Hash]*data.UserOpV7Hexify)
+	for _, m := range mempools {
+		for userOpHash, op := range m.GetUserOps() {
+			ops[userOpHash] = op
+		}
+	}
+
+	return ops
+}
@@ -11,14 +11,30 @@ import (
 	"github.com/transeptorlabs/betsy/internal/data"
 )
 
+// Statuses of a user operation tracked by a mempool
+const (
+	StatusPending  = "pending"  // the userOp is in the bundler mempool
+	StatusRemoved  = "removed"  // the userOp left the bundler mempool without a receipt yet
+	StatusIncluded = "included" // the userOp was included on-chain by a bundle
+)
+
+// maxReceiptChecks is the number of refreshes a removed userOp receipt is looked up for
+const maxReceiptChecks = 10
+
 // MempoolEntry is a struct used to store user operations in a mempool
 type MempoolEntry struct {
-	op     *data.UserOpV7Hexify
-	status string
+	op            *data.UserOpV7Hexify
+	status        string
+	seenAt        time.Time
+	includedBy    common.Address
+	txHash        common.Hash
+	receiptChecks int
 }
 
 // UserOpMempool is a struct used to store user operations in a mempool
 type UserOpMempool struct {
+	name                     string
+	signerAddress            common.Address
 	userOps                  map[common.Hash]MempoolEntry
 	mutex                    sync.Mutex
 	ethClient                *ethclient.Client
@@ -26,13 +42,16 @@ type UserOpMempool struct {
 	bundlerClient            *client.BundlerClient
 	ticker                   *time.Ticker
 	isRunning                bool
+	paused                   bool // the bundler is down, e.g. restarting after a crash
 	done                     chan bool
 	mempoolRefreshErrorCount int
 }
 
-// NewUserOpMempool creates a new UserOpMempool
-func NewUserOpMempool(epAddress common.Address, ethClient *ethclient.Client, bundlerUrl string) *UserOpMempool {
+// NewUserOpMempool creates a new UserOpMempool polling the bundler instance with the given name and signer
+func NewUserOpMempool(name string, signerAddress common.Address, epAddress common.Address, ethClient *ethclient.Client, bundlerUrl string) *UserOpMempool {
 	return &UserOpMempool{
+		name:                     name,
+		signerAddress:            signerAddress,
 		userOps:                  make(map[common.Hash]MempoolEntry),
 		epAddress:                epAddress,
 		ethClient:                ethClient,
@@ -43,6 +62,11 @@ func NewUserOpMempool(epAddress common.Address, ethClient *ethclient.Client, bun
 	}
 }
 
+// Name returns the name of the bundler instance polled by the mempool
+func (m *UserOpMempool) Name() string {
+	return m.name
+}
+
 // GetUserOps returns all user operations in the mempool
 func (m *UserOpMempool) GetUserOps() map[common.Hash]*data.UserOpV7Hexify {
 	m.mutex.Lock()
@@ -57,29 +81,30 @@ func (m *UserOpMempool) GetUserOps() map[common.Hash]*data.UserOpV7Hexify {
 	return ops
 }
 
-// addUserOp adds a user operation to the mempool
-func (m *UserOpMempool) addUserOp(op *data.UserOpV7Hexify) error {
+// addUserOp adds a user operation to the mempool and returns its hash
+func (m *UserOpMempool) addUserOp(op *data.UserOpV7Hexify) (common.Hash, error) {
 	log.Debug().Msgf("Attempting to add userOp to mempool: %#v\n", op)
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
 	userOpHash, err := op.GetUserOpHash(m.epAddress, m.ethClient)
 	if err != nil {
-		return err
+		return common.Hash{}, err
 	}
 
 	if _, ok := m.userOps[userOpHash]; ok {
 		log.Debug().Msgf("Skipping userOp already in mempool(userOpHash): %s\n", userOpHash)
-		return nil
+		return userOpHash, nil
 	}
 
 	m.userOps[userOpHash] = MempoolEntry{
 		op:     op,
-		status: "pending",
+		status: StatusPending,
+		seenAt: time.Now(),
 	}
 	log.Debug().Msgf("Successfully added userOp in mempool(userOpHash): %s\n", userOpHash)
 
-	return nil
+	return userOpHash, nil
 }
 
 // refreshMempool refreshes the mempool by fetching user operations from the bundler
@@ -92,13 +117,49 @@ func (m *UserOpMempool) refreshMempool() error {
 	}
 
 	log.Debug().Msgf("Total userOps fetched from bundler(count): %d", len(userOps))
+	current := make(map[common.Hash]bool)
 	if len(userOps) > 0 {
 		for _, op := range userOps {
-			err = m.addUserOp(&op)
+			userOpHash, err := m.addUserOp(&op)
 			if err != nil {
 				return err
 			}
+			current[userOpHash] = true
+		}
+	}
+
+	return m.updateIncludedUserOps(current)
+}
+
+// updateIncludedUserOps looks up the receipt of the userOps that left the bundler mempool to find the bundler that included them
+func (m *UserOpMempool) updateIncludedUserOps(current map[common.Hash]bool) error {
+	m.mutex.Lock()
+	pending := make([]common.Hash, 0)
+	for userOpHash, entry := range m.userOps {
+		if entry.status != StatusIncluded && !current[userOpHash] && entry.receiptChecks < maxReceiptChecks {
+			pending = append(pending, userOpHash)
+		}
+	}
+	m.mutex.Unlock()
+
+	for _, userOpHash := range pending {
+		receipt, err := m.bundlerClient.Eth_getUserOperationReceipt(userOpHash)
+		if err != nil {
+			return err
+		}
+
+		m.mutex.Lock()
+		entry := m.userOps[userOpHash]
+		entry.receiptChecks++
+		entry.status = StatusRemoved
+		if receipt != nil {
+			entry.status = StatusIncluded
+			entry.includedBy = receipt.Receipt.From
+			entry.txHash = receipt.Receipt.TransactionHash
+			log.Debug().Msgf("UserOp %s included in tx %s by %s", userOpHash, entry.txHash, entry.includedBy)
 		}
+		m.userOps[userOpHash] = entry
+		m.mutex.Unlock()
 	}
 
 	return nil
@@ -110,7 +171,7 @@ func (m *UserOpMempool) Run() error {
 		return nil
 	}
 
-	log.Info().Msg("Starting up Mempool...")
+	log.Info().Msgf("Starting up %s Mempool...", m.name)
 	m.ticker = time.NewTicker(3 * time.Second)
 	go func(m *UserOpMempool) {
 		for {
@@ -118,10 +179,16 @@ func (m *UserOpMempool) Run() error {
 			case <-m.done:
 				return
 			case <-m.ticker.C:
+				if m.isPaused() {
+					continue
+				}
+
 				err := m.refreshMempool()
 				if err != nil {
 					log.Err(err).Msg("Could not refresh mempool")
+					m.mutex.Lock()
 					m.mempoolRefreshErrorCount = m.mempoolRefreshErrorCount + 1
+					m.mutex.Unlock()
 					continue
 				}
 			}
@@ -133,6 +200,33 @@ func (m *UserOpMempool) Run() error {
 	return nil
 }
 
+// Pause stops polling the bundler while it is down
+func (m *UserOpMempool) Pause() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	log.Info().Msgf("Pausing %s Mempool...", m.name)
+	m.paused = true
+}
+
+// Resume polls the bundler again once it is back, the userOps it lost are marked as removed by the next refresh
+func (m *UserOpMempool) Resume() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	log.Info().Msgf("Resuming %s Mempool...", m.name)
+	m.paused = false
+	m.mempoolRefreshErrorCount = 0
+}
+
+// isPaused checks if polling the bundler is paused
+func (m *UserOpMempool) isPaused() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.paused
+}
+
 // Stop stops the mempool
 func (m *UserOpMempool) Stop() {
 	if !m.isRunning {
@@ -140,7 +234,7 @@ func (m *UserOpMempool) Stop() {
 	}
 
 	m.ticker.Stop()
-	log.Info().Msg("Shutting down Mempool...")
+	log.Info().Msgf("Shutting down %s Mempool...", m.name)
 	m.isRunning = false
 	m.done <- true
 }
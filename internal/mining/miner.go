@@ -0,0 +1,162 @@
+package mining
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/rs/zerolog/log"
+	"github.com/transeptorlabs/betsy/internal/docker"
+)
+
+// MaxBlocks is the maximum number of blocks mined by a single Mine call
+const MaxBlocks = 100
+
+// mineTimeout is how long to wait for each block, mined by a transaction or by the mine method
+const mineTimeout = 10 * time.Second
+
+// ErrMiningNotSupported is returned when the execution client cannot mine blocks on demand
+var ErrMiningNotSupported = errors.New("the execution client does not support mining blocks on demand")
+
+// Miner controls the block production of the dev chain
+type Miner struct {
+	client     *ethclient.Client
+	mode       string
+	blockTime  time.Duration
+	definition docker.MiningDefinition
+	devAccount sync.Locker // serializes the transactions mining blocks with the other transactions of the dev account
+}
+
+// NewMiner creates a new miner for the eth node
+func NewMiner(client *ethclient.Client, mode string, blockTime time.Duration, definition docker.MiningDefinition, devAccount sync.Locker) *Miner {
+	return &Miner{
+		client:     client,
+		mode:       mode,
+		blockTime:  blockTime,
+		definition: definition,
+		devAccount: devAccount,
+	}
+}
+
+// Start switches the eth node to the configured mode once the environment is set up, in manual mode automatic mining is turned off
+func (m *Miner) Start(ctx context.Context) error {
+	if m.mode != docker.MiningManual {
+		return nil
+	}
+
+	log.Info().Msg("Turning off automatic mining, mine blocks with POST /api/mine")
+	return m.client.Client().CallContext(ctx, nil, m.definition.AutomineMethod, false)
+}
+
+// CanMine checks if blocks can be mined on demand, clients mining with a transaction only mine it right away in auto mode
+func (m *Miner) CanMine() bool {
+	return m.definition.MineMethod != "" || (m.definition.MineWithTx && m.mode == docker.MiningAuto)
+}
+
+// Mine mines the given number of blocks and returns the latest block number
+func (m *Miner) Mine(ctx context.Context, blocks int) (uint64, error) {
+	if !m.CanMine() {
+		return 0, ErrMiningNotSupported
+	}
+
+	if blocks < 1 || blocks > MaxBlocks {
+		return 0, fmt.Errorf("blocks must be between 1 and %d", MaxBlocks)
+	}
+
+	for i := 0; i < blocks; i++ {
+		if m.definition.MineMethod == "" {
+			if err := m.mineWithTx(ctx); err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		if err := m.mineWithMethod(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	return m.client.BlockNumber(ctx)
+}
+
+// Timeout returns how long mining the given number of blocks may take at most
+func (m *Miner) Timeout(blocks int) time.Duration {
+	return time.Duration(max(blocks, 1)) * mineTimeout
+}
+
+// mineWithMethod mines a block with the mine method of the client
+func (m *Miner) mineWithMethod(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, mineTimeout)
+	defer cancel()
+
+	return m.client.Client().CallContext(ctx, nil, m.definition.MineMethod)
+}
+
+// mineWithTx sends an empty transaction from the unlocked dev account to itself and waits for the block mining it
+func (m *Miner) mineWithTx(ctx context.Context) error {
+	var accounts []common.Address
+	if err := m.client.Client().CallContext(ctx, &accounts, "eth_accounts"); err != nil {
+		return err
+	}
+	if len(accounts) == 0 {
+		return ErrMiningNotSupported
+	}
+
+	head, err := m.client.BlockNumber(ctx)
+	if err != nil {
+		return err
+	}
+
+	tx := map[string]interface{}{
+		"from":  accounts[0],
+		"to":    accounts[0],
+		"value": (*hexutil.Big)(common.Big0),
+	}
+	m.devAccount.Lock()
+	err = m.client.Client().CallContext(ctx, nil, "eth_sendTransaction", tx)
+	m.devAccount.Unlock()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, mineTimeout)
+	defer cancel()
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("block %d was not mined: %w", head+1, ctx.Err())
+		case <-ticker.C:
+			number, err := m.client.BlockNumber(ctx)
+			if err != nil {
+				return err
+			}
+			if number > head {
+				return nil
+			}
+		}
+	}
+}
+
+// Mode returns the block production mode
+func (m *Miner) Mode() string {
+	return m.mode
+}
+
+// String describes the block production mode
+func (m *Miner) String() string {
+	switch m.mode {
+	case docker.MiningInterval:
+		return fmt.Sprintf("interval (a block every %s)", m.blockTime)
+	case docker.MiningManual:
+		return "manual (blocks are mined with POST /api/mine)"
+	default:
+		return "auto (a block per transaction)"
+	}
+}
@@ -0,0 +1,103 @@
+package mining
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/transeptorlabs/betsy/internal/docker"
+)
+
+// devNode mimics geth --dev, it mines a block for every transaction sent from its unlocked account
+type devNode struct {
+	mu      sync.Mutex
+	account common.Address
+	head    uint64
+	sent    []map[string]interface{}
+}
+
+func (n *devNode) Accounts() []common.Address {
+	return []common.Address{n.account}
+}
+
+func (n *devNode) BlockNumber() hexutil.Uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return hexutil.Uint64(n.head)
+}
+
+func (n *devNode) SendTransaction(tx map[string]interface{}) common.Hash {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, tx)
+	n.head++
+	return common.Hash{byte(n.head)}
+}
+
+func newDevNode(t *testing.T) (*devNode, *ethclient.Client) {
+	t.Helper()
+
+	node := &devNode{account: common.HexToAddress("0x71562b71999873DB5b286dF957af199Ec94617F7")}
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", node); err != nil {
+		t.Fatalf("RegisterName() error = %v", err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	t.Cleanup(server.Stop)
+
+	client, err := ethclient.Dial(httpServer.URL)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(client.Close)
+
+	return node, client
+}
+
+func TestMineWithTransaction(t *testing.T) {
+	node, client := newDevNode(t)
+	definition := docker.MiningDefinition{MineWithTx: true}
+
+	miner := NewMiner(client, docker.MiningAuto, 0, definition, &sync.Mutex{})
+	if !miner.CanMine() {
+		t.Fatal("CanMine() = false, want true in auto mode")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	head, err := miner.Mine(ctx, 3)
+	if err != nil {
+		t.Fatalf("Mine() error = %v", err)
+	}
+	if head != 3 {
+		t.Errorf("Mine() = %d, want 3", head)
+	}
+
+	if len(node.sent) != 3 {
+		t.Fatalf("sent %d transactions, want 3", len(node.sent))
+	}
+	for _, tx := range node.sent {
+		if common.HexToAddress(tx["from"].(string)) != node.account {
+			t.Errorf("transaction from = %v, want %s", tx["from"], node.account)
+		}
+		if tx["value"] != "0x0" {
+			t.Errorf("transaction value = %v, want 0x0", tx["value"])
+		}
+	}
+
+	// A transaction does not mine a block right away in interval mode
+	miner = NewMiner(client, docker.MiningInterval, time.Second, definition, &sync.Mutex{})
+	if miner.CanMine() {
+		t.Error("CanMine() = true in interval mode, want false")
+	}
+	if _, err := miner.Mine(ctx, 1); err != ErrMiningNotSupported {
+		t.Errorf("Mine() error = %v, want ErrMiningNotSupported", err)
+	}
+}
@@ -2,14 +2,23 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
 	"net/http"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
 	"github.com/transeptorlabs/betsy/internal/data"
+	"github.com/transeptorlabs/betsy/internal/docker"
 	"github.com/transeptorlabs/betsy/internal/mempool"
+	"github.com/transeptorlabs/betsy/internal/mining"
+	"github.com/transeptorlabs/betsy/internal/session"
+	"github.com/transeptorlabs/betsy/internal/utils"
 	"github.com/transeptorlabs/betsy/wallet"
 )
 
@@ -17,28 +26,77 @@ const (
 	contentType = "application/json"
 )
 
+const (
+	faucetWait          = 5 * time.Second  // how long the faucet waits for its transaction to be mined before returning it as pending
+	faucetWriteDeadline = 30 * time.Second // write deadline of the faucet routes, they sign, submit and wait for a transaction
+	mineWriteMargin     = 5 * time.Second  // time left to the mine route to write its response after mining its blocks
+)
+
+// mineRequest is the body of the POST /api/mine request
+type mineRequest struct {
+	Blocks int `json:"blocks"`
+}
+
+// faucetRequest is the body of the POST /api/faucet request and of the dashboard faucet form
+type faucetRequest struct {
+	Address string `json:"address" form:"address"`
+	Amount  string `json:"amount" form:"amount"`   // in ether
+	Deposit bool   `json:"deposit" form:"deposit"` // top up the EntryPoint deposit of the address instead of its balance
+}
+
+// errInvalidFaucetRequest is returned by the faucet for an invalid address or amount, the other errors come from the node or the wallet
+var errInvalidFaucetRequest = errors.New("invalid faucet request")
+
+// Faucet funds addresses from the coinbase account of the session.
+type Faucet interface {
+	Fund(ctx context.Context, address common.Address, amount *big.Int, wait time.Duration) (wallet.FaucetResult, error)
+	DepositTo(ctx context.Context, address common.Address, amount *big.Int, wait time.Duration) (wallet.FaucetResult, error)
+}
+
+// ContainerRuntime exposes the live state and logs of the Betsy components.
+type ContainerRuntime interface {
+	Readiness(ctx context.Context) []docker.ComponentStatus
+	ReadLogs(ctx context.Context, component string, options docker.LogOptions) (string, error)
+}
+
+// NodeInfo contains the endpoints of the running environment shown on the dashboard.
+type NodeInfo struct {
+	EthNodeUrl         string
+	BundlerNodeUrl     string
+	DashboardServerUrl string
+	Bundlers           []session.Bundler
+	ChainID            *big.Int
+	GasLimit           uint64
+	BlockProduction    string
+}
+
 // HTTPServer represents an HTTP server.
 type HTTPServer struct {
 	listenHost string
 	debug      bool
 	server     *http.Server
 	wallet     *wallet.Wallet
-	mempool    *mempool.UserOpMempool
+	funder     Faucet
+	mempools   []*mempool.UserOpMempool
+	runtime    ContainerRuntime
+	miner      *mining.Miner
+	supervisor *docker.Supervisor
+	nodeInfo   NodeInfo
 }
 
 // NewHTTPServer creates a new HTTP server.
-func NewHTTPServer(listenHost string, debug bool, wallet *wallet.Wallet, mempool *mempool.UserOpMempool) *HTTPServer {
-	return &HTTPServer{
+func NewHTTPServer(listenHost string, debug bool, wallet *wallet.Wallet, mempools []*mempool.UserOpMempool, runtime ContainerRuntime, miner *mining.Miner, supervisor *docker.Supervisor, nodeInfo NodeInfo) *HTTPServer {
+	s := &HTTPServer{
 		listenHost: listenHost,
 		debug:      debug,
 		wallet:     wallet,
-		mempool:    mempool,
+		funder:     wallet,
+		mempools:   mempools,
+		runtime:    runtime,
+		miner:      miner,
+		supervisor: supervisor,
+		nodeInfo:   nodeInfo,
 	}
-}
-
-// Run starts the HTTP server.
-func (s *HTTPServer) Run() error {
-	log.Info().Msg("Starting HTTP server...")
 
 	if s.debug {
 		gin.SetMode(gin.DebugMode)
@@ -46,11 +104,32 @@ func (s *HTTPServer) Run() error {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	router := s.router()
+	router.LoadHTMLGlob("ui/templates/*")
+
+	// The server is created with the HTTPServer so it can be shut down before Run is called
+	s.server = &http.Server{
+		Addr:         s.listenHost,
+		Handler:      router.Handler(),
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	return s
+}
+
+// Run starts the HTTP server.
+func (s *HTTPServer) Run() error {
+	log.Info().Msg("Starting HTTP server...")
+	return s.server.ListenAndServe()
+}
+
+// router registers the routes of the dashboard and of the API
+func (s *HTTPServer) router() *gin.Engine {
 	router := gin.New()
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 
-	router.LoadHTMLGlob("ui/templates/*")
 	router.Static("assets/css", "ui/assets/css")
 	router.Static("assets/js", "ui/assets/js")
 	router.Static("assets/img", "ui/assets/img")
@@ -64,8 +143,20 @@ func (s *HTTPServer) Run() error {
 	})
 
 	healthRoutes.GET("/ready", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status": "ready",
+		components := s.runtime.Readiness(c)
+		for _, component := range components {
+			if !component.Ready {
+				c.JSON(http.StatusServiceUnavailable, gin.H{
+					"status":     "not ready",
+					"components": components,
+				})
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"status":     "ready",
+			"components": components,
 		})
 	})
 
@@ -76,26 +167,133 @@ func (s *HTTPServer) Run() error {
 		})
 	})
 
-	router.GET("/accounts", func(c *gin.Context) {
-		accounts, err := s.wallet.GetDevAccounts(c)
+	// API group
+	apiRoutes := router.Group("/api")
+	apiRoutes.POST("/mine", func(c *gin.Context) {
+		var req mineRequest
+		if err := c.ShouldBindJSON(&req); err != nil && err != io.EOF {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Blocks == 0 {
+			req.Blocks = 1
+		}
+		extendWriteDeadline(c, s.miner.Timeout(req.Blocks)+mineWriteMargin)
+
+		blockNumber, err := s.miner.Mine(c, req.Blocks)
+		if err == mining.ErrMiningNotSupported {
+			c.JSON(http.StatusNotImplemented, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"mined":       req.Blocks,
+			"blockNumber": blockNumber,
+		})
+	})
+
+	apiRoutes.POST("/faucet", func(c *gin.Context) {
+		extendWriteDeadline(c, faucetWriteDeadline)
+
+		var req faucetRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		result, err := s.faucet(c, req)
+		if errors.Is(err, errInvalidFaucetRequest) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
 
-		c.HTML(http.StatusOK, "accounts", gin.H{
-			"accounts": accounts,
+		c.JSON(http.StatusOK, result)
+	})
+
+	apiRoutes.GET("/smart-accounts", func(c *gin.Context) {
+		smartAccounts, err := s.wallet.GetSmartAccounts(c)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"smartAccounts": smartAccounts,
 		})
 	})
 
+	apiRoutes.GET("/crashes", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"crashes": s.supervisor.History(),
+		})
+	})
+
+	router.GET("/environment", func(c *gin.Context) {
+		s.renderEnvironment(c, "")
+	})
+
+	router.POST("/environment/mine", func(c *gin.Context) {
+		message := "Mined a block"
+		if _, err := s.miner.Mine(c, 1); err != nil {
+			message = err.Error()
+		}
+		s.renderEnvironment(c, message)
+	})
+
+	router.GET("/accounts", func(c *gin.Context) {
+		s.renderAccounts(c, "")
+	})
+
+	router.POST("/accounts/faucet", func(c *gin.Context) {
+		extendWriteDeadline(c, faucetWriteDeadline)
+
+		var req faucetRequest
+		if err := c.ShouldBind(&req); err != nil {
+			s.renderAccounts(c, err.Error())
+			return
+		}
+
+		result, err := s.faucet(c, req)
+		if err != nil {
+			s.renderAccounts(c, err.Error())
+			return
+		}
+
+		what := "Funded"
+		if req.Deposit {
+			what = "Deposited to the EntryPoint for"
+		}
+		message := fmt.Sprintf("%s %s with %s ETH (tx %s)", what, req.Address, req.Amount, result.TxHash.Hex())
+		if result.Pending {
+			message += ", pending until the next block is mined"
+		}
+		s.renderAccounts(c, message)
+	})
+
 	router.GET("/mempool", func(c *gin.Context) {
-		ops := s.mempool.GetUserOps()
+		ops := mempool.MergeUserOps(s.mempools)
+		bundlers := make([]string, 0, len(s.mempools))
+		for _, m := range s.mempools {
+			bundlers = append(bundlers, m.Name())
+		}
+
 		c.HTML(http.StatusOK, "mempool", gin.H{
 			"isCFDeploy": func(op *data.UserOpV7Hexify) bool {
 				initCode, _ := op.GetInitCode()
 				return hexutil.Encode(initCode) != "0x" // counterfactual deploy is not empty
 			},
-			"totalOps": len(ops),
-			"userOps":  ops,
+			"totalOps":    len(ops),
+			"userOps":     ops,
+			"bundlers":    bundlers,
+			"comparisons": mempool.Compare(s.mempools),
 		})
 	})
 
@@ -105,18 +303,104 @@ func (s *HTTPServer) Run() error {
 		})
 	})
 
+	router.GET("/logs", func(c *gin.Context) {
+		component := c.DefaultQuery("component", "bundler")
+		components := make([]string, 0)
+		for _, status := range s.runtime.Readiness(c) {
+			components = append(components, status.Name)
+		}
+
+		logs, err := s.runtime.ReadLogs(c, component, docker.LogOptions{Tail: 200, Timestamps: true})
+		if err != nil {
+			logs = err.Error()
+		}
+
+		c.HTML(http.StatusOK, "logs", gin.H{
+			"component":  component,
+			"components": components,
+			"logs":       logs,
+		})
+	})
+
 	router.NoRoute(func(c *gin.Context) {
 		c.Redirect(http.StatusFound, "/dashboard")
 	})
 
-	s.server = &http.Server{
-		Addr:         s.listenHost,
-		Handler:      router.Handler(),
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+	return router
+}
+
+// renderAccounts renders the dev accounts page with an optional message
+func (s *HTTPServer) renderAccounts(c *gin.Context, message string) {
+	accounts, err := s.wallet.GetDevAccounts(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+		return
 	}
 
-	return s.server.ListenAndServe()
+	smartAccounts, err := s.wallet.GetSmartAccounts(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err})
+		return
+	}
+
+	c.HTML(http.StatusOK, "accounts", gin.H{
+		"mnemonic":       s.wallet.Mnemonic(),
+		"derivationPath": s.wallet.DerivationPath(),
+		"accounts":       accounts,
+		"smartAccounts":  smartAccounts,
+		"message":        message,
+	})
+}
+
+// faucet sends the requested amount from the coinbase account to the address or to its EntryPoint deposit
+func (s *HTTPServer) faucet(ctx context.Context, req faucetRequest) (wallet.FaucetResult, error) {
+	if !common.IsHexAddress(req.Address) {
+		return wallet.FaucetResult{}, fmt.Errorf("%w: invalid address %q", errInvalidFaucetRequest, req.Address)
+	}
+
+	amount, err := utils.ParseEther(req.Amount)
+	if err != nil {
+		return wallet.FaucetResult{}, fmt.Errorf("%w: %w", errInvalidFaucetRequest, err)
+	}
+	if amount.Sign() <= 0 {
+		return wallet.FaucetResult{}, fmt.Errorf("%w: the amount must be positive", errInvalidFaucetRequest)
+	}
+
+	// Blocks are only mined on request in manual mode, the transaction is returned as soon as it is submitted
+	wait := faucetWait
+	if s.miner.Mode() == docker.MiningManual {
+		wait = 0
+	}
+
+	if req.Deposit {
+		return s.funder.DepositTo(ctx, common.HexToAddress(req.Address), amount, wait)
+	}
+
+	return s.funder.Fund(ctx, common.HexToAddress(req.Address), amount, wait)
+}
+
+// extendWriteDeadline gives a route more time to write its response than the write timeout of the server
+func extendWriteDeadline(c *gin.Context, timeout time.Duration) {
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		log.Debug().Err(err).Msg("Failed to extend the write deadline")
+	}
+}
+
+// renderEnvironment renders the environment page with an optional message
+func (s *HTTPServer) renderEnvironment(c *gin.Context, message string) {
+	blockNumber, err := s.wallet.GetEthClient().BlockNumber(c)
+	if err != nil {
+		log.Err(err).Msg("Failed to get block number")
+	}
+
+	c.HTML(http.StatusOK, "environment", gin.H{
+		"nodeInfo":    s.nodeInfo,
+		"components":  s.runtime.Readiness(c),
+		"blockNumber": blockNumber,
+		"canMine":     s.miner.CanMine(),
+		"crashes":     s.supervisor.History(),
+		"message":     message,
+	})
 }
 
 // Shutdown gracefully shuts down the HTTP server.
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/gin-gonic/gin"
+	"github.com/transeptorlabs/betsy/internal/docker"
+	"github.com/transeptorlabs/betsy/internal/mining"
+	"github.com/transeptorlabs/betsy/wallet"
+)
+
+// fakeFaucet mines its transfers like a dev chain producing a block every blockTime, no block without block time
+type fakeFaucet struct {
+	mu        sync.Mutex
+	blockTime time.Duration
+	waits     []time.Duration
+	err       error // returned by every call, like a failure of the node
+}
+
+func (f *fakeFaucet) Fund(ctx context.Context, address common.Address, amount *big.Int, wait time.Duration) (wallet.FaucetResult, error) {
+	f.mu.Lock()
+	f.waits = append(f.waits, wait)
+	f.mu.Unlock()
+
+	if f.err != nil {
+		return wallet.FaucetResult{}, f.err
+	}
+
+	result := wallet.FaucetResult{TxHash: common.HexToHash("0x01"), Pending: true}
+	if f.blockTime == 0 || wait < f.blockTime {
+		time.Sleep(wait)
+		return result, nil
+	}
+
+	time.Sleep(f.blockTime)
+	result.Pending = false
+	result.Balance = amount
+	return result, nil
+}
+
+func (f *fakeFaucet) DepositTo(ctx context.Context, address common.Address, amount *big.Int, wait time.Duration) (wallet.FaucetResult, error) {
+	return f.Fund(ctx, address, amount, wait)
+}
+
+// startFaucetServer serves the routes of a server whose write timeout is shorter than a block
+func startFaucetServer(t *testing.T, mode string, blockTime time.Duration) (*fakeFaucet, string) {
+	t.Helper()
+
+	faucet := &fakeFaucet{blockTime: blockTime}
+	s := &HTTPServer{
+		funder: faucet,
+		miner:  mining.NewMiner(nil, mode, blockTime, docker.MiningDefinition{}, &sync.Mutex{}),
+	}
+
+	return faucet, startServer(t, s)
+}
+
+// startServer serves the routes of s with a write timeout shorter than a block and returns its URL
+func startServer(t *testing.T, s *HTTPServer) string {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	server := httptest.NewUnstartedServer(s.router())
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	t.Cleanup(server.Close)
+
+	return server.URL
+}
+
+// sendFaucetRequest posts a faucet request and returns the response
+func sendFaucetRequest(t *testing.T, url string, req faucetRequest) *http.Response {
+	t.Helper()
+
+	body, _ := json.Marshal(req)
+	res, err := http.Post(url+"/api/faucet", contentType, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST /api/faucet error = %v", err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+
+	return res
+}
+
+// postFaucet sends a faucet request and decodes its result
+func postFaucet(t *testing.T, url string) wallet.FaucetResult {
+	t.Helper()
+
+	res := sendFaucetRequest(t, url, faucetRequest{Address: "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266", Amount: "1.5"})
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("POST /api/faucet status = %d, want 200", res.StatusCode)
+	}
+
+	var result wallet.FaucetResult
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		t.Fatalf("decoding the faucet result error = %v", err)
+	}
+
+	return result
+}
+
+func TestFaucetWithIntervalMining(t *testing.T) {
+	faucet, url := startFaucetServer(t, docker.MiningInterval, 200*time.Millisecond)
+
+	// The block comes after the write timeout of the server, the faucet route has its own deadline
+	result := postFaucet(t, url)
+	want, _ := new(big.Int).SetString("1500000000000000000", 10)
+	if result.Pending || result.Balance.Cmp(want) != 0 {
+		t.Errorf("faucet result = %+v, want a mined transfer with a balance of %s", result, want)
+	}
+	if len(faucet.waits) != 1 || faucet.waits[0] != faucetWait {
+		t.Errorf("faucet waits = %v, want %s", faucet.waits, faucetWait)
+	}
+}
+
+func TestFaucetWithManualMining(t *testing.T) {
+	faucet, url := startFaucetServer(t, docker.MiningManual, 0)
+
+	// No block comes without a request, the transfer is returned as soon as it is submitted
+	start := time.Now()
+	result := postFaucet(t, url)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("faucet took %s, want an immediate response", elapsed)
+	}
+	if !result.Pending || result.Balance != nil || result.TxHash != common.HexToHash("0x01") {
+		t.Errorf("faucet result = %+v, want a pending transfer", result)
+	}
+	if len(faucet.waits) != 1 || faucet.waits[0] != 0 {
+		t.Errorf("faucet waits = %v, want no wait", faucet.waits)
+	}
+}
+
+func TestFaucetErrorStatus(t *testing.T) {
+	faucet, url := startFaucetServer(t, docker.MiningAuto, 0)
+	address := "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"
+
+	// Invalid input is a client error, it never reaches the wallet
+	tests := []struct {
+		req  faucetRequest
+		want int
+	}{
+		{faucetRequest{Address: "0x1234", Amount: "1"}, http.StatusBadRequest},
+		{faucetRequest{Address: address, Amount: "one"}, http.StatusBadRequest},
+		{faucetRequest{Address: address, Amount: "0"}, http.StatusBadRequest},
+	}
+	for _, test := range tests {
+		if res := sendFaucetRequest(t, url, test.req); res.StatusCode != test.want {
+			t.Errorf("POST /api/faucet %+v status = %d, want %d", test.req, res.StatusCode, test.want)
+		}
+	}
+	if len(faucet.waits) != 0 {
+		t.Errorf("faucet called %d times with invalid requests", len(faucet.waits))
+	}
+
+	// A failure of the node or of the signer is a server error
+	faucet.err = errors.New("insufficient funds for gas * price + value")
+	for _, deposit := range []bool{false, true} {
+		req := faucetRequest{Address: address, Amount: "1", Deposit: deposit}
+		if res := sendFaucetRequest(t, url, req); res.StatusCode != http.StatusInternalServerError {
+			t.Errorf("POST /api/faucet %+v status = %d, want 500", req, res.StatusCode)
+		}
+	}
+}
+
+// devNode mimics geth --dev, it mines a block for every transaction sent from its unlocked account
+type devNode struct {
+	mu   sync.Mutex
+	head uint64
+}
+
+func (n *devNode) Accounts() []common.Address {
+	return []common.Address{common.HexToAddress("0x71562b71999873DB5b286dF957af199Ec94617F7")}
+}
+
+func (n *devNode) BlockNumber() hexutil.Uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return hexutil.Uint64(n.head)
+}
+
+func (n *devNode) SendTransaction(tx map[string]interface{}) common.Hash {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.head++
+	return common.Hash{byte(n.head)}
+}
+
+func TestMineExtendsTheWriteDeadline(t *testing.T) {
+	rpcServer := rpc.NewServer()
+	if err := rpcServer.RegisterName("eth", &devNode{}); err != nil {
+		t.Fatalf("RegisterName() error = %v", err)
+	}
+	node := httptest.NewServer(rpcServer)
+	t.Cleanup(node.Close)
+	t.Cleanup(rpcServer.Stop)
+
+	client, err := ethclient.Dial(node.URL)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(client.Close)
+
+	// Each block is only seen by the next poll of the miner, mining them takes longer than the write timeout
+	url := startServer(t, &HTTPServer{
+		miner: mining.NewMiner(client, docker.MiningAuto, 0, docker.MiningDefinition{MineWithTx: true}, &sync.Mutex{}),
+	})
+
+	res, err := http.Post(url+"/api/mine", contentType, strings.NewReader(`{"blocks": 3}`))
+	if err != nil {
+		t.Fatalf("POST /api/mine error = %v", err)
+	}
+	defer res.Body.Close()
+
+	var body struct {
+		Mined       int    `json:"mined"`
+		BlockNumber uint64 `json:"blockNumber"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding the mine result error = %v", err)
+	}
+	if res.StatusCode != http.StatusOK || body.Mined != 3 || body.BlockNumber != 3 {
+		t.Errorf("POST /api/mine = %d %+v, want 3 blocks mined", res.StatusCode, body)
+	}
+}
@@ -0,0 +1,72 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/transeptorlabs/betsy/wallet"
+)
+
+// ErrNoPersistedState is returned when a persisted environment has no stored state
+var ErrNoPersistedState = errors.New("no persisted state found")
+
+// PersistedState contains the deployments and funded accounts of a persisted chain, stored next to the session state so a later session can resume the chain
+type PersistedState struct {
+	Name                 string                      `json:"name"`
+	EthClient            string                      `json:"ethClient"`
+	UpdatedAt            time.Time                   `json:"updatedAt"`
+	PreDeployedContracts wallet.PreDeployedContracts `json:"preDeployedContracts"`
+	FundedAccounts       []common.Address            `json:"fundedAccounts"`
+}
+
+// PersistedStateFile returns the path of the stored state of a persisted environment, relative to the directory of the session state file
+func PersistedStateFile(stateFile string, name string) string {
+	return filepath.Join(filepath.Dir(stateFile), "persist", name+".json")
+}
+
+// PersistedSnapshotFile returns the path of the stored state of a snapshot of a persisted environment
+func PersistedSnapshotFile(stateFile string, name string, snapshot string) string {
+	return filepath.Join(filepath.Dir(stateFile), "persist", "snapshots", name, snapshot+".json")
+}
+
+// SavePersisted atomically writes the state of a persisted environment to path
+func SavePersisted(path string, state *PersistedState) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// LoadPersisted reads the state of a persisted environment from path
+func LoadPersisted(path string) (*PersistedState, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoPersistedState
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state PersistedState
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, fmt.Errorf("corrupt persisted state file %s: %w", path, err)
+	}
+
+	return &state, nil
+}
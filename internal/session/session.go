@@ -0,0 +1,134 @@
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/transeptorlabs/betsy/internal/utils"
+	"github.com/transeptorlabs/betsy/wallet"
+)
+
+// DefaultStateFile is the default location of the session state file, relative to the project directory
+const DefaultStateFile = ".betsy/session.json"
+
+// ErrNoSession is returned when there is no session state file
+var ErrNoSession = errors.New("no running Betsy session found")
+
+// State contains everything needed to manage a running Betsy session from another process
+type State struct {
+	PID                  int                         `json:"pid"`
+	SessionID            string                      `json:"sessionId"`
+	StartedAt            time.Time                   `json:"startedAt"`
+	Runtime              string                      `json:"runtime,omitempty"`
+	Bundler              string                      `json:"bundler"`
+	Persist              string                      `json:"persist,omitempty"`
+	NetworkName          string                      `json:"networkName"`
+	EthNodeUrl           string                      `json:"ethNodeUrl"`
+	BundlerNodeUrl       string                      `json:"bundlerNodeUrl"`
+	Bundlers             []Bundler                   `json:"bundlers"`
+	DashboardServerUrl   string                      `json:"dashboardServerUrl"`
+	Containers           []Container                 `json:"containers"`
+	PreDeployedContracts wallet.PreDeployedContracts `json:"preDeployedContracts"`
+	Accounts             []Account                   `json:"accounts"`
+	SmartAccounts        []wallet.SmartAccount       `json:"smartAccounts,omitempty"` // with their deployment state, balance and deposit on start-up
+}
+
+// NativeDir returns the directory holding the processes state of the native runtime, relative to the directory of the session state file
+func NativeDir(stateFile string) string {
+	return filepath.Join(filepath.Dir(stateFile), "native")
+}
+
+// Container contains the details of a container started by the session
+type Container struct {
+	Name        string `json:"name"`
+	NodeType    string `json:"nodeType"`
+	ContainerID string `json:"containerId"`
+	HostPort    string `json:"hostPort"`
+	RPCURL      string `json:"rpcUrl"`
+	ProbeMethod string `json:"probeMethod"`
+}
+
+// Bundler contains the details of a bundler instance started by the session
+type Bundler struct {
+	Name          string         `json:"name"`
+	Url           string         `json:"url"`
+	Beneficiary   common.Address `json:"beneficiary"`
+	SignerAddress common.Address `json:"signerAddress"`
+	SignerBalance *big.Int       `json:"signerBalance,omitempty"` // in wei, sent to the signer on start-up
+}
+
+// Account contains the details of a funded dev account
+type Account struct {
+	Address       common.Address `json:"address"`
+	PrivateKeyHex string         `json:"privateKey"`
+	Balance       *big.Int       `json:"balance,omitempty"` // in wei, sent to the account on start-up
+}
+
+// Save atomically writes the session state to path
+func Save(path string, state *State) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// Load reads the session state from path
+func Load(path string) (*State, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNoSession
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state State
+	if err := json.Unmarshal(content, &state); err != nil {
+		return nil, fmt.Errorf("corrupt session state file %s: %w", path, err)
+	}
+
+	return &state, nil
+}
+
+// Remove deletes the session state file at path
+func Remove(path string) error {
+	err := os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// IsProcessAlive checks if the Betsy process owning the session is still running
+func (s *State) IsProcessAlive() bool {
+	return utils.IsProcessAlive(s.PID)
+}
+
+// FindContainer finds a container by name (e.g. geth, anvil, transeptor) or node type (eth, bundler)
+func FindContainer(containers []Container, component string) (Container, error) {
+	for _, container := range containers {
+		if container.Name == component || container.NodeType == component {
+			return container, nil
+		}
+	}
+
+	return Container{}, fmt.Errorf("no %s container found in session", component)
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"net"
+	"strconv"
+)
+
+// IsPortAvailable checks if a TCP port can be bound on all host interfaces
+func IsPortAvailable(port uint) bool {
+	listener, err := net.Listen("tcp", net.JoinHostPort("0.0.0.0", strconv.Itoa(int(port))))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+
+	return true
+}
+
+// FindFreePort asks the OS for a free TCP port that is not in the excluded set
+func FindFreePort(excluded map[uint]bool) (uint, error) {
+	for {
+		listener, err := net.Listen("tcp", "0.0.0.0:0")
+		if err != nil {
+			return 0, err
+		}
+		port := uint(listener.Addr().(*net.TCPAddr).Port)
+		listener.Close()
+
+		if !excluded[port] {
+			return port, nil
+		}
+	}
+}
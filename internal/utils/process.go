@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"os"
+	"syscall"
+)
+
+// IsProcessAlive checks if a process with the given pid is running on this host
+func IsProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	return process.Signal(syscall.Signal(0)) == nil
+}
@@ -2,7 +2,10 @@ package utils
 
 import (
 	"errors"
+	"fmt"
+	"math/big"
 	"os"
+	"strings"
 )
 
 // RemoveFile removes a file from the filesystem
@@ -37,3 +40,22 @@ func PadToBytes16(data []byte) ([]byte, error) {
 
 	return data, nil
 }
+
+// ParseEther parses a decimal ETH amount (e.g. "4337" or "0.5") and returns its value in wei
+func ParseEther(amount string) (*big.Int, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(amount))
+	if !ok {
+		return nil, fmt.Errorf("invalid ETH amount: %q", amount)
+	}
+
+	if value.Sign() < 0 {
+		return nil, fmt.Errorf("ETH amount can not be negative: %q", amount)
+	}
+
+	wei := new(big.Rat).Mul(value, new(big.Rat).SetInt(big.NewInt(1e18)))
+	if !wei.IsInt() {
+		return nil, fmt.Errorf("ETH amount has more than 18 decimals: %q", amount)
+	}
+
+	return wei.Num(), nil
+}
@@ -0,0 +1,212 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/rs/zerolog/log"
+	"github.com/transeptorlabs/betsy/contracts/entrypoint"
+	"github.com/transeptorlabs/betsy/contracts/examples"
+	"github.com/transeptorlabs/betsy/contracts/factory"
+)
+
+// Layouts of the pre-deployed contracts
+const (
+	LayoutCanonical = "canonical" // CREATE2 through the deterministic deployer, the addresses of the public networks
+	LayoutLegacy    = "legacy"    // CREATE from dev account 0, the addresses depend on its nonce
+)
+
+// Layouts returns the supported layouts of the pre-deployed contracts
+func Layouts() []string {
+	return []string{LayoutCanonical, LayoutLegacy}
+}
+
+var (
+	// DeterministicDeployerAddress is the CREATE2 deployment proxy (https://github.com/Arachnid/deterministic-deployment-proxy), at the same address on every chain
+	DeterministicDeployerAddress = common.HexToAddress("0x4e59b44847b379578588920cA78FbF26c0B4956C")
+	// CanonicalEntryPointV7Address is the address of the EntryPoint v0.7 on the public networks
+	CanonicalEntryPointV7Address = common.HexToAddress("0x0000000071727De22E5E9d8BAf0edaC6f37da032")
+	// EntryPointV7Salt is the CREATE2 salt of the canonical EntryPoint v0.7 deployment, the other contracts use a zero salt
+	EntryPointV7Salt = common.HexToHash("0x90d8084deab30c2a37c45e8d47f49f2f7965183cb6990a98943ef94940681de3")
+)
+
+// DeterministicDeployerSigner sends the pre-signed transaction deploying the deterministic deployer, it is valid on every chain as it has no chain ID (pre EIP-155)
+var DeterministicDeployerSigner = common.HexToAddress("0x3fab184622dc19b6109349b94811493bf2a45362")
+
+// DeterministicDeployerTx is the pre-signed deployment of the deterministic deployer, 100000 gas at 100 gwei
+const DeterministicDeployerTx = "0xf8a58085174876e800830186a08080b853604580600e600039806000f350fe7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe03601600081602082378035828234f58015156039578182fd5b8082525050506014600cf31ba02222222222222222222222222222222222222222222222222222222222222222a02222222222222222222222222222222222222222222222222222222222222222"
+
+// DeterministicDeployerCost is the gas cost of the pre-signed deployment paid by its signer
+var DeterministicDeployerCost = big.NewInt(100000 * 100_000_000_000)
+
+// DeterministicAddress returns the address of a contract deployed with the deterministic deployer
+func DeterministicAddress(salt common.Hash, initCode []byte) common.Address {
+	return crypto.CreateAddress2(DeterministicDeployerAddress, salt, crypto.Keccak256(initCode))
+}
+
+// EntryPointV7InitCode returns the creation code of the EntryPoint contract
+func EntryPointV7InitCode() ([]byte, error) {
+	return hexutil.Decode(entrypoint.EntryPointV7MetaData.Bin)
+}
+
+// SimpleAccountFactoryV7InitCode returns the creation code of the SimpleAccountFactory contract bound to the EntryPoint
+func SimpleAccountFactoryV7InitCode(entryPoint common.Address) ([]byte, error) {
+	code, err := hexutil.Decode(factory.SimpleAccountFactoryV7MetaData.Bin)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := factory.SimpleAccountFactoryV7MetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := parsed.Pack("", entryPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(code, args...), nil
+}
+
+// GlobalCounterInitCode returns the creation code of the GlobalCounter contract
+func GlobalCounterInitCode() ([]byte, error) {
+	return hexutil.Decode(examples.GlobalCounterMetaData.Bin)
+}
+
+// deployCanonicalContracts deploys the contracts that are not deployed yet with the deterministic deployer, at the addresses they have on the public networks
+func (w *Wallet) deployCanonicalContracts(ctx context.Context) error {
+	err := w.installDeterministicDeployer(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to install the deterministic deployer, the legacy layout (--predeploy.layout %s) does not need it: %w", LayoutLegacy, err)
+	}
+
+	auth, err := bind.NewKeyedTransactorWithChainID(w.devAccounts[0].PrivateKey, w.chainID)
+	if err != nil {
+		return err
+	}
+	auth.Context = ctx
+
+	if w.entryPointAddress == (common.Address{}) {
+		initCode, err := EntryPointV7InitCode()
+		if err != nil {
+			return err
+		}
+
+		w.entryPointAddress, err = w.deployDeterministic(ctx, auth, "EntryPointV7", EntryPointV7Salt, initCode)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.config.DeploySimpleAccountFactory && w.simpleAccountFactoryAddress == (common.Address{}) {
+		initCode, err := SimpleAccountFactoryV7InitCode(w.entryPointAddress)
+		if err != nil {
+			return err
+		}
+
+		w.simpleAccountFactoryAddress, err = w.deployDeterministic(ctx, auth, "SimpleAccountFactory", common.Hash{}, initCode)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.config.DeployGlobalCounter && w.globalCounterAddress == (common.Address{}) {
+		initCode, err := GlobalCounterInitCode()
+		if err != nil {
+			return err
+		}
+
+		w.globalCounterAddress, err = w.deployDeterministic(ctx, auth, "GlobalCounter", common.Hash{}, initCode)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// installDeterministicDeployer deploys the deterministic deployer with its pre-signed transaction when the chain does not have it, anvil includes it in its genesis
+func (w *Wallet) installDeterministicDeployer(ctx context.Context) error {
+	exists, err := checkContractExistence(ctx, DeterministicDeployerAddress, w.client)
+	if err != nil {
+		return err
+	}
+	if exists {
+		log.Debug().Msgf("The deterministic deployer is already installed at %s", DeterministicDeployerAddress)
+		return nil
+	}
+
+	balance, err := w.client.BalanceAt(ctx, DeterministicDeployerSigner, nil)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(DeterministicDeployerCost) < 0 {
+		_, err = w.fundAccounts(ctx, []transfer{{to: DeterministicDeployerSigner, value: new(big.Int).Sub(DeterministicDeployerCost, balance)}})
+		if err != nil {
+			return err
+		}
+	}
+
+	var tx types.Transaction
+	err = tx.UnmarshalBinary(common.FromHex(DeterministicDeployerTx))
+	if err != nil {
+		return err
+	}
+
+	err = w.client.SendTransaction(ctx, &tx)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.waitMined(ctx, &tx)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Installed the deterministic deployer at %s", DeterministicDeployerAddress)
+	return nil
+}
+
+// deployDeterministic deploys a contract with the deterministic deployer unless it already has code, and returns its address
+func (w *Wallet) deployDeterministic(ctx context.Context, auth *bind.TransactOpts, name string, salt common.Hash, initCode []byte) (common.Address, error) {
+	address := DeterministicAddress(salt, initCode)
+
+	exists, err := checkContractExistence(ctx, address, w.client)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if exists {
+		log.Info().Msgf("Reusing the %s contract deployed at %s", name, address)
+		return address, nil
+	}
+
+	log.Info().Msgf("Deploying the %s contract at %s...", name, address)
+	deployer := bind.NewBoundContract(DeterministicDeployerAddress, abi.ABI{}, w.client, w.client, w.client)
+	tx, err := deployer.RawTransact(auth, append(salt.Bytes(), initCode...))
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	_, err = w.waitMined(ctx, tx)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	exists, err = checkContractExistence(ctx, address, w.client)
+	if err != nil {
+		return common.Address{}, err
+	}
+	if !exists {
+		return common.Address{}, fmt.Errorf("%s was not deployed at %s", name, address)
+	}
+
+	return address, nil
+}
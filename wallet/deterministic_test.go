@@ -0,0 +1,82 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestDeterministicAddressesAreCanonical(t *testing.T) {
+	entryPointCode, err := EntryPointV7InitCode()
+	if err != nil {
+		t.Fatalf("EntryPointV7InitCode() error = %v", err)
+	}
+	factoryCode, err := SimpleAccountFactoryV7InitCode(CanonicalEntryPointV7Address)
+	if err != nil {
+		t.Fatalf("SimpleAccountFactoryV7InitCode() error = %v", err)
+	}
+	globalCounterCode, err := GlobalCounterInitCode()
+	if err != nil {
+		t.Fatalf("GlobalCounterInitCode() error = %v", err)
+	}
+
+	// The addresses of the contracts on the public networks
+	tests := []struct {
+		name     string
+		salt     common.Hash
+		initCode []byte
+		want     common.Address
+	}{
+		{"EntryPoint", EntryPointV7Salt, entryPointCode, CanonicalEntryPointV7Address},
+		{"SimpleAccountFactory", common.Hash{}, factoryCode, common.HexToAddress("0x91E60e0613810449d098b0b5Ec8b51A0FE8c8985")},
+		{"GlobalCounter", common.Hash{}, globalCounterCode, common.HexToAddress("0xcF209cDB6eC3484F4B239fB8e4899F8E35785Fc5")},
+	}
+	for _, test := range tests {
+		if got := DeterministicAddress(test.salt, test.initCode); got != test.want {
+			t.Errorf("%s address = %s, want %s", test.name, got, test.want)
+		}
+	}
+
+	// The factory is bound to the EntryPoint, another one moves it
+	otherFactoryCode, err := SimpleAccountFactoryV7InitCode(common.HexToAddress("0x0000000000000000000000000000000000000001"))
+	if err != nil {
+		t.Fatalf("SimpleAccountFactoryV7InitCode() error = %v", err)
+	}
+	if DeterministicAddress(common.Hash{}, otherFactoryCode) == tests[1].want {
+		t.Error("SimpleAccountFactory address does not depend on its EntryPoint")
+	}
+}
+
+func TestDeterministicDeployerTx(t *testing.T) {
+	raw, err := hexutil.Decode(DeterministicDeployerTx)
+	if err != nil {
+		t.Fatalf("decoding the deployer tx error = %v", err)
+	}
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		t.Fatalf("UnmarshalBinary() error = %v", err)
+	}
+
+	// Without chain ID the transaction is valid on every chain
+	if tx.Protected() {
+		t.Error("deployer tx is replay protected, want a pre EIP-155 tx")
+	}
+	signer, err := types.Sender(types.HomesteadSigner{}, tx)
+	if err != nil {
+		t.Fatalf("Sender() error = %v", err)
+	}
+	if signer != DeterministicDeployerSigner {
+		t.Errorf("deployer tx signer = %s, want %s", signer, DeterministicDeployerSigner)
+	}
+
+	// The first transaction of the signer creates the deployer
+	if got := crypto.CreateAddress(signer, tx.Nonce()); tx.Nonce() != 0 || got != DeterministicDeployerAddress {
+		t.Errorf("deployer tx creates %s with nonce %d, want %s with nonce 0", got, tx.Nonce(), DeterministicDeployerAddress)
+	}
+	if tx.Cost().Cmp(DeterministicDeployerCost) != 0 {
+		t.Errorf("deployer tx cost = %s, want %s", tx.Cost(), DeterministicDeployerCost)
+	}
+}
@@ -0,0 +1,266 @@
+package wallet
+
+import (
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// callHandler answers the eth_call of a contract method with its input without selector
+type callHandler func(to common.Address, input []byte) ([]byte, error)
+
+// fakeChain is the eth namespace of a dev node mining the transactions of a sender in nonce order, a nonce gap keeps the later transactions pending
+type fakeChain struct {
+	mu       sync.Mutex
+	chainID  *big.Int
+	baseFee  *big.Int // nil for a chain without EIP-1559
+	nonces   map[common.Address]uint64
+	balances map[common.Address]*big.Int
+	pending  map[common.Address]map[uint64]*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+	code     map[common.Address]hexutil.Bytes // code of the deployed contracts
+	mined    []*types.Transaction
+	failTo   common.Address         // transactions sent to this address are mined but fail
+	rejectTo common.Address         // transactions sent to this address are rejected by the node
+	calls    map[string]callHandler // eth_call handlers keyed by method selector
+	hold     bool                   // transactions stay pending until mine is called, like manual mining
+	head     uint64
+}
+
+func newFakeChain(baseFee *big.Int) *fakeChain {
+	return &fakeChain{
+		chainID:  big.NewInt(1337),
+		baseFee:  baseFee,
+		nonces:   map[common.Address]uint64{},
+		balances: map[common.Address]*big.Int{},
+		pending:  map[common.Address]map[uint64]*types.Transaction{},
+		receipts: map[common.Hash]*types.Receipt{},
+		code:     map[common.Address]hexutil.Bytes{},
+		calls:    map[string]callHandler{},
+		head:     1,
+	}
+}
+
+func (c *fakeChain) ChainId() *hexutil.Big {
+	return (*hexutil.Big)(c.chainID)
+}
+
+func (c *fakeChain) GetBlockByNumber(number string, full bool) *types.Header {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return &types.Header{
+		Number:     new(big.Int).SetUint64(c.head),
+		Difficulty: big.NewInt(0),
+		GasLimit:   30000000,
+		BaseFee:    c.baseFee,
+	}
+}
+
+func (c *fakeChain) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(2000000000))
+}
+
+func (c *fakeChain) MaxPriorityFeePerGas() *hexutil.Big {
+	return (*hexutil.Big)(big.NewInt(1000000000))
+}
+
+func (c *fakeChain) GetTransactionCount(address common.Address, block string) hexutil.Uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	nonce := c.nonces[address]
+	if block == "pending" {
+		nonce += uint64(len(c.pending[address]))
+	}
+
+	return hexutil.Uint64(nonce)
+}
+
+func (c *fakeChain) GetBalance(address common.Address, block string) *hexutil.Big {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return (*hexutil.Big)(c.balance(address))
+}
+
+func (c *fakeChain) SendRawTransaction(data hexutil.Bytes) (common.Hash, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(data); err != nil {
+		return common.Hash{}, err
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(c.chainID), tx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if *tx.To() == c.rejectTo {
+		return common.Hash{}, errors.New("insufficient funds for gas * price + value")
+	}
+	if tx.Nonce() < c.nonces[from] {
+		return common.Hash{}, errors.New("nonce too low")
+	}
+	if c.pending[from] == nil {
+		c.pending[from] = map[uint64]*types.Transaction{}
+	}
+	if _, ok := c.pending[from][tx.Nonce()]; ok {
+		return common.Hash{}, errors.New("replacement transaction underpriced")
+	}
+	c.pending[from][tx.Nonce()] = tx
+
+	if !c.hold {
+		c.minePending(from)
+	}
+
+	return tx.Hash(), nil
+}
+
+// mine mines the pending transactions of every sender
+func (c *fakeChain) mine() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for from := range c.pending {
+		c.minePending(from)
+	}
+}
+
+// minePending mines the pending transactions following the nonce of the sender
+func (c *fakeChain) minePending(from common.Address) {
+	for {
+		next, ok := c.pending[from][c.nonces[from]]
+		if !ok {
+			break
+		}
+		delete(c.pending[from], c.nonces[from])
+		c.nonces[from]++
+		c.head++
+
+		status := types.ReceiptStatusSuccessful
+		if *next.To() == c.failTo {
+			status = types.ReceiptStatusFailed
+		} else {
+			c.balances[*next.To()] = new(big.Int).Add(c.balance(*next.To()), next.Value())
+		}
+		c.receipts[next.Hash()] = &types.Receipt{
+			Type:        next.Type(),
+			Status:      status,
+			Logs:        []*types.Log{},
+			TxHash:      next.Hash(),
+			GasUsed:     next.Gas(),
+			BlockNumber: new(big.Int).SetUint64(c.head),
+		}
+		c.mined = append(c.mined, next)
+	}
+}
+
+// callArgs are the eth_call args used by the contract bindings
+type callArgs struct {
+	To    *common.Address `json:"to"`
+	Data  hexutil.Bytes   `json:"data"`
+	Input hexutil.Bytes   `json:"input"`
+}
+
+func (c *fakeChain) Call(args callArgs, block string) (hexutil.Bytes, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	input := args.Input
+	if len(input) == 0 {
+		input = args.Data
+	}
+	if len(input) < 4 || args.To == nil {
+		return nil, errors.New("no contract method to call")
+	}
+
+	call, ok := c.calls[hexutil.Encode(input[:4])]
+	if !ok {
+		return nil, errors.New("execution reverted")
+	}
+
+	return call(*args.To, input[4:])
+}
+
+func (c *fakeChain) GetCode(address common.Address, block string) hexutil.Bytes {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.code[address]
+}
+
+func (c *fakeChain) GetTransactionReceipt(hash common.Hash) *types.Receipt {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.receipts[hash]
+}
+
+func (c *fakeChain) balance(address common.Address) *big.Int {
+	if balance, ok := c.balances[address]; ok {
+		return balance
+	}
+
+	return big.NewInt(0)
+}
+
+// newFakeChainWallet returns a wallet whose coinbase sends its transactions to the fake chain
+func newFakeChainWallet(t *testing.T, chain *fakeChain) *Wallet {
+	t.Helper()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", chain); err != nil {
+		t.Fatalf("RegisterName() error = %v", err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	t.Cleanup(server.Stop)
+
+	client, err := ethclient.Dial(httpServer.URL)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(client.Close)
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	ks := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.ImportECDSA(key, "")
+	if err != nil {
+		t.Fatalf("ImportECDSA() error = %v", err)
+	}
+
+	return &Wallet{
+		client:          client,
+		keyStore:        ks,
+		coinbaseAddress: account.Address,
+		chainID:         chain.chainID,
+	}
+}
+
+// sentTxs returns the mined transactions keyed by their hash
+func (c *fakeChain) sentTxs() map[common.Hash]*types.Transaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	txs := make(map[common.Hash]*types.Transaction, len(c.mined))
+	for _, tx := range c.mined {
+		txs[tx.Hash()] = tx
+	}
+
+	return txs
+}
@@ -0,0 +1,113 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rs/zerolog/log"
+	"github.com/transeptorlabs/betsy/contracts/entrypoint"
+)
+
+// FaucetResult contains the outcome of a faucet request
+type FaucetResult struct {
+	TxHash  common.Hash `json:"txHash"`
+	Pending bool        `json:"pending"` // the transaction was not mined in time, e.g. it waits for the next block of the interval or manual mining mode
+	Balance *big.Int    `json:"balance"` // balance of the address in wei, or its EntryPoint deposit for a deposit, nil while pending
+}
+
+// Fund sends amount wei from the coinbase account to address and waits up to wait for the transfer to be mined, a transfer still pending is returned without balance
+func (w *Wallet) Fund(ctx context.Context, address common.Address, amount *big.Int, wait time.Duration) (FaucetResult, error) {
+	if amount.Sign() <= 0 {
+		return FaucetResult{}, errors.New("the amount must be positive")
+	}
+
+	signedTxs, _, errs, err := w.submitTransfers(ctx, []transfer{{to: address, value: amount}})
+	if err != nil {
+		return FaucetResult{}, err
+	}
+	if errs[0] != nil {
+		return FaucetResult{}, errs[0]
+	}
+
+	return w.faucetResult(ctx, signedTxs[0], wait, func(ctx context.Context) (*big.Int, error) {
+		return w.client.BalanceAt(ctx, address, nil)
+	})
+}
+
+// DepositTo adds amount wei to the EntryPoint deposit of address (e.g. a paymaster or a smart account) from the coinbase account and waits up to wait for the deposit to be mined, a deposit still pending is returned without balance
+func (w *Wallet) DepositTo(ctx context.Context, address common.Address, amount *big.Int, wait time.Duration) (FaucetResult, error) {
+	if amount.Sign() <= 0 {
+		return FaucetResult{}, errors.New("the amount must be positive")
+	}
+
+	entryPoint, err := entrypoint.NewEntryPointV7(w.entryPointAddress, w.client)
+	if err != nil {
+		return FaucetResult{}, err
+	}
+
+	tx, err := w.submitDeposit(ctx, entryPoint, address, amount)
+	if err != nil {
+		return FaucetResult{}, err
+	}
+
+	return w.faucetResult(ctx, tx, wait, func(ctx context.Context) (*big.Int, error) {
+		return entryPoint.BalanceOf(&bind.CallOpts{Context: ctx}, address)
+	})
+}
+
+// submitDeposit sends the depositTo transaction of address from the coinbase account, the coinbase lock is only held until it is submitted
+func (w *Wallet) submitDeposit(ctx context.Context, entryPoint *entrypoint.EntryPointV7, address common.Address, amount *big.Int) (*types.Transaction, error) {
+	if w.entryPointAddress == (common.Address{}) {
+		return nil, errors.New("the EntryPoint contract is not deployed")
+	}
+
+	w.coinbaseMu.Lock()
+	defer w.coinbaseMu.Unlock()
+
+	auth, err := w.coinbaseTransactor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	auth.Value = amount
+
+	tx, err := entryPoint.DepositTo(auth, address)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info().Msgf("Deposit of %s wei to the EntryPoint for %s sent (tx %s)", amount, address, tx.Hash().Hex())
+	return tx, nil
+}
+
+// faucetResult waits up to wait for a faucet transaction to be mined and reads the new balance with balanceOf, a transaction still pending afterwards is returned as pending
+func (w *Wallet) faucetResult(ctx context.Context, tx *types.Transaction, wait time.Duration, balanceOf func(ctx context.Context) (*big.Int, error)) (FaucetResult, error) {
+	result := FaucetResult{TxHash: tx.Hash(), Pending: true}
+	if wait <= 0 {
+		return result, nil
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	if _, err := w.waitMined(waitCtx, tx); err != nil {
+		if waitCtx.Err() != nil && ctx.Err() == nil {
+			log.Debug().Msgf("Faucet tx %s is still pending after %s", tx.Hash().Hex(), wait)
+			return result, nil
+		}
+		return FaucetResult{}, err
+	}
+
+	balance, err := balanceOf(ctx)
+	if err != nil {
+		return FaucetResult{}, err
+	}
+
+	result.Pending = false
+	result.Balance = balance
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package wallet
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestFundWaitsForTheBlock(t *testing.T) {
+	chain := newFakeChain(big.NewInt(7))
+	w := newFakeChainWallet(t, chain)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := w.Fund(ctx, defaultAccounts[0], big.NewInt(42), 5*time.Second)
+	if err != nil {
+		t.Fatalf("Fund() error = %v", err)
+	}
+	if result.Pending || result.Balance.Int64() != 42 {
+		t.Errorf("Fund() = %+v, want a mined transfer with a balance of 42", result)
+	}
+	if _, ok := chain.sentTxs()[result.TxHash]; !ok {
+		t.Errorf("Fund() tx %s was not mined", result.TxHash.Hex())
+	}
+}
+
+func TestFundReturnsPendingTransfers(t *testing.T) {
+	chain := newFakeChain(big.NewInt(7))
+	chain.hold = true
+	w := newFakeChainWallet(t, chain)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Without a block the transfer is returned once the wait is over, without holding the coinbase
+	start := time.Now()
+	first, err := w.Fund(ctx, defaultAccounts[0], big.NewInt(1), 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Fund() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Fund() took %s, want about the wait", elapsed)
+	}
+	if !first.Pending || first.Balance != nil {
+		t.Errorf("Fund() = %+v, want a pending transfer without balance", first)
+	}
+
+	// A zero wait returns as soon as the transfer is submitted, with the next nonce
+	second, err := w.Fund(ctx, defaultAccounts[1], big.NewInt(1), 0)
+	if err != nil {
+		t.Fatalf("Fund() error = %v", err)
+	}
+	if !second.Pending {
+		t.Errorf("Fund() = %+v, want a pending transfer", second)
+	}
+
+	chain.mine()
+	txs := chain.sentTxs()
+	if txs[first.TxHash] == nil || txs[second.TxHash] == nil {
+		t.Fatalf("mined %v, want both pending transfers", txs)
+	}
+	if txs[first.TxHash].Nonce()+1 != txs[second.TxHash].Nonce() {
+		t.Errorf("nonces = %d, %d, want consecutive nonces", txs[first.TxHash].Nonce(), txs[second.TxHash].Nonce())
+	}
+
+	if _, err := w.Fund(ctx, defaultAccounts[0], big.NewInt(0), 0); err == nil {
+		t.Error("Fund() of a zero amount succeeded")
+	}
+}
@@ -0,0 +1,229 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rs/zerolog/log"
+)
+
+// fundingTimeout is the time a funding transfer has to be mined, an interval block time needs a few blocks
+const fundingTimeout = 2 * time.Minute
+
+// transferGasLimit is the gas limit of a plain ETH transfer
+const transferGasLimit = uint64(21000)
+
+// transfer is an amount of wei sent by the coinbase account to a funded account
+type transfer struct {
+	to    common.Address
+	value *big.Int
+}
+
+// fundAccounts sends the transfers from the coinbase account, then it waits concurrently for every receipt and checks the final balances. It returns the hashes of the transfers
+func (w *Wallet) fundAccounts(ctx context.Context, transfers []transfer) ([]common.Hash, error) {
+	if len(transfers) == 0 {
+		return nil, nil
+	}
+
+	signedTxs, expectedBalances, errs, err := w.submitTransfers(ctx, transfers)
+	if err != nil {
+		return nil, err
+	}
+
+	var wg sync.WaitGroup
+	for i, item := range transfers {
+		if errs[i] != nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, item transfer) {
+			defer wg.Done()
+
+			if err := w.confirmTransfer(ctx, signedTxs[i], item, expectedBalances[i]); err != nil {
+				errs[i] = fmt.Errorf("funding %s: %w", item.to, err)
+			}
+		}(i, item)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to fund account")
+		}
+	}
+
+	hashes := make([]common.Hash, len(signedTxs))
+	for i, signedTx := range signedTxs {
+		hashes[i] = signedTx.Hash()
+	}
+
+	return hashes, errors.Join(errs...)
+}
+
+// submitTransfers signs the transfers with nonces assigned locally and submits them in nonce order. The coinbase lock is only held until they are submitted, so the faucet does not wait for the blocks of other callers. It returns the signed transfers, the balances expected once they are mined and the submission error of each transfer
+func (w *Wallet) submitTransfers(ctx context.Context, transfers []transfer) ([]*types.Transaction, []*big.Int, []error, error) {
+	// The faucet sends transfers while Betsy is running, the coinbase nonces are assigned by one caller at a time
+	w.coinbaseMu.Lock()
+	defer w.coinbaseMu.Unlock()
+
+	// Unlock the account (in the context of the keystore is necessary because the private key is encrypted for security reasons)
+	account, err := w.keyStore.Find(accounts.Account{Address: w.coinbaseAddress})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	err = w.keyStore.Unlock(account, w.password)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	nonce, err := w.client.PendingNonceAt(ctx, w.coinbaseAddress)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	newTx, err := w.transferTxBuilder(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Sign the transfers in nonce order before submitting them
+	signedTxs := make([]*types.Transaction, len(transfers))
+	expectedBalances := make([]*big.Int, len(transfers))
+	for i, item := range transfers {
+		balance, err := w.client.BalanceAt(ctx, item.to, nil)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		expectedBalances[i] = new(big.Int).Add(balance, item.value)
+
+		signedTxs[i], err = w.keyStore.SignTx(account, newTx(nonce+uint64(i), item.to, item.value), w.chainID)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	// The transfers are submitted in nonce order without waiting for their blocks, after a rejected one the next nonces would never be mined and are not sent
+	errs := make([]error, len(transfers))
+	for i, item := range transfers {
+		if i > 0 && errs[i-1] != nil {
+			errs[i] = fmt.Errorf("funding %s: not sent, nonce %d was not submitted", item.to, signedTxs[i-1].Nonce())
+			continue
+		}
+
+		if err := w.client.SendTransaction(ctx, signedTxs[i]); err != nil {
+			errs[i] = fmt.Errorf("funding %s: %w", item.to, err)
+			continue
+		}
+		log.Debug().Msgf("tx sent to fund (%s): %s", item.to, signedTxs[i].Hash().Hex())
+	}
+
+	return signedTxs, expectedBalances, errs, nil
+}
+
+// confirmTransfer waits for the receipt of a submitted transfer and checks the balance of the funded account
+func (w *Wallet) confirmTransfer(ctx context.Context, signedTx *types.Transaction, item transfer, expectedBalance *big.Int) error {
+	receipt, err := w.waitMined(ctx, signedTx)
+	if err != nil {
+		return err
+	}
+
+	// The coinbase account funding itself pays the gas, its balance is not checked
+	if item.to != w.coinbaseAddress {
+		balance, err := w.client.BalanceAt(ctx, item.to, receipt.BlockNumber)
+		if err != nil {
+			return err
+		}
+		if balance.Cmp(expectedBalance) < 0 {
+			return fmt.Errorf("balance is %s wei after tx %s, want at least %s wei", balance, signedTx.Hash().Hex(), expectedBalance)
+		}
+	}
+
+	log.Info().Msgf("Funded %s with %s wei in block %s (tx %s)", item.to, item.value, receipt.BlockNumber, signedTx.Hash().Hex())
+	return nil
+}
+
+// transferTxBuilder returns a function building the transfer transactions, EIP-1559 transactions when the chain has a base fee and legacy transactions otherwise
+func (w *Wallet) transferTxBuilder(ctx context.Context) (func(nonce uint64, to common.Address, value *big.Int) *types.Transaction, error) {
+	header, err := w.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.BaseFee == nil {
+		gasPrice, err := w.client.SuggestGasPrice(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(nonce uint64, to common.Address, value *big.Int) *types.Transaction {
+			return types.NewTx(&types.LegacyTx{Nonce: nonce, To: &to, Value: value, Gas: transferGasLimit, GasPrice: gasPrice})
+		}, nil
+	}
+
+	gasTipCap, err := w.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Twice the base fee keeps the transfers valid while the base fee rises over the next blocks
+	gasFeeCap := new(big.Int).Add(new(big.Int).Mul(header.BaseFee, big.NewInt(2)), gasTipCap)
+
+	return func(nonce uint64, to common.Address, value *big.Int) *types.Transaction {
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:   w.chainID,
+			Nonce:     nonce,
+			GasTipCap: gasTipCap,
+			GasFeeCap: gasFeeCap,
+			Gas:       transferGasLimit,
+			To:        &to,
+			Value:     value,
+		})
+	}, nil
+}
+
+// waitMined waits for a transaction sent by the wallet to be mined successfully
+func (w *Wallet) waitMined(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, fundingTimeout)
+	defer cancel()
+
+	receipt, err := bind.WaitMined(waitCtx, w.client, tx)
+	if err != nil {
+		return nil, fmt.Errorf("tx %s was not mined: %w", tx.Hash().Hex(), err)
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		return nil, fmt.Errorf("tx %s failed", tx.Hash().Hex())
+	}
+
+	return receipt, nil
+}
+
+// coinbaseTransactor returns the transact options of the coinbase account to call contracts, the caller holds coinbaseMu
+func (w *Wallet) coinbaseTransactor(ctx context.Context) (*bind.TransactOpts, error) {
+	account, err := w.keyStore.Find(accounts.Account{Address: w.coinbaseAddress})
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.keyStore.Unlock(account, w.password)
+	if err != nil {
+		return nil, err
+	}
+
+	auth, err := bind.NewKeyStoreTransactorWithChainID(w.keyStore, account, w.chainID)
+	if err != nil {
+		return nil, err
+	}
+	auth.Context = ctx
+
+	return auth, nil
+}
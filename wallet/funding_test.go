@@ -0,0 +1,146 @@
+package wallet
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestFundAccountsAssignsSequentialNonces(t *testing.T) {
+	chain := newFakeChain(big.NewInt(7))
+	w := newFakeChainWallet(t, chain)
+	chain.nonces[w.coinbaseAddress] = 5
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	transfers := make([]transfer, 0, len(defaultAccounts))
+	for i, address := range defaultAccounts {
+		transfers = append(transfers, transfer{to: address, value: big.NewInt(int64(1000 + i))})
+	}
+
+	hashes, err := w.fundAccounts(ctx, transfers)
+	if err != nil {
+		t.Fatalf("fundAccounts() error = %v", err)
+	}
+	if len(hashes) != len(transfers) {
+		t.Fatalf("fundAccounts() returned %d hashes, want %d", len(hashes), len(transfers))
+	}
+
+	// The transfers are confirmed concurrently, a nonce gap or a duplicate would keep one pending
+	txs := chain.sentTxs()
+	for i, hash := range hashes {
+		tx, ok := txs[hash]
+		if !ok {
+			t.Fatalf("tx %s of transfer %d was not mined", hash.Hex(), i)
+		}
+		if tx.Nonce() != uint64(5+i) {
+			t.Errorf("transfer %d nonce = %d, want %d", i, tx.Nonce(), 5+i)
+		}
+		if *tx.To() != transfers[i].to || tx.Value().Cmp(transfers[i].value) != 0 {
+			t.Errorf("transfer %d = %s to %s, want %s to %s", i, tx.Value(), tx.To(), transfers[i].value, transfers[i].to)
+		}
+		if tx.Type() != types.DynamicFeeTxType || tx.GasFeeCap().Int64() != 2*7+1000000000 {
+			t.Errorf("transfer %d type %d fee cap %s, want an EIP-1559 tx with twice the base fee", i, tx.Type(), tx.GasFeeCap())
+		}
+	}
+
+	// A later call, e.g. the faucet, continues after the last nonce
+	hashes, err = w.fundAccounts(ctx, []transfer{{to: defaultAccounts[0], value: big.NewInt(1)}})
+	if err != nil {
+		t.Fatalf("fundAccounts() error = %v", err)
+	}
+	if nonce := chain.sentTxs()[hashes[0]].Nonce(); nonce != 8 {
+		t.Errorf("next transfer nonce = %d, want 8", nonce)
+	}
+	if balance := chain.balance(defaultAccounts[0]); balance.Int64() != 1001 {
+		t.Errorf("balance = %s, want 1001", balance)
+	}
+}
+
+func TestFundAccountsLegacyChain(t *testing.T) {
+	chain := newFakeChain(nil)
+	w := newFakeChainWallet(t, chain)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	hashes, err := w.fundAccounts(ctx, []transfer{{to: defaultAccounts[0], value: big.NewInt(1)}})
+	if err != nil {
+		t.Fatalf("fundAccounts() error = %v", err)
+	}
+	if tx := chain.sentTxs()[hashes[0]]; tx.Type() != types.LegacyTxType {
+		t.Errorf("transfer type = %d, want a legacy tx without base fee", tx.Type())
+	}
+}
+
+func TestFundAccountsReportsFailedTransfers(t *testing.T) {
+	chain := newFakeChain(big.NewInt(7))
+	w := newFakeChainWallet(t, chain)
+	chain.failTo = defaultAccounts[1]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	transfers := []transfer{
+		{to: defaultAccounts[0], value: big.NewInt(1)},
+		{to: defaultAccounts[1], value: big.NewInt(1)},
+		{to: defaultAccounts[2], value: big.NewInt(1)},
+	}
+	_, err := w.fundAccounts(ctx, transfers)
+	if err == nil || !strings.Contains(err.Error(), "funding "+defaultAccounts[1].Hex()) {
+		t.Fatalf("fundAccounts() error = %v, want the failed transfer", err)
+	}
+	if strings.Contains(err.Error(), defaultAccounts[0].Hex()) || strings.Contains(err.Error(), defaultAccounts[2].Hex()) {
+		t.Errorf("fundAccounts() error = %v, want only the failed transfer", err)
+	}
+
+	for _, i := range []int{0, 2} {
+		if balance := chain.balance(defaultAccounts[i]); balance.Int64() != 1 {
+			t.Errorf("account %d balance = %s, want 1", i, balance)
+		}
+	}
+}
+
+func TestFundAccountsStopsAfterARejectedTransfer(t *testing.T) {
+	chain := newFakeChain(big.NewInt(7))
+	w := newFakeChainWallet(t, chain)
+	chain.rejectTo = defaultAccounts[1]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	transfers := []transfer{
+		{to: defaultAccounts[0], value: big.NewInt(1)},
+		{to: defaultAccounts[1], value: big.NewInt(1)},
+		{to: defaultAccounts[2], value: big.NewInt(1)},
+	}
+
+	// The transfer after the rejected nonce is reported right away instead of waiting for a block that never comes
+	start := time.Now()
+	_, err := w.fundAccounts(ctx, transfers)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("fundAccounts() took %s, want the failures reported right away", elapsed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "funding "+defaultAccounts[1].Hex()) || !strings.Contains(err.Error(), "funding "+defaultAccounts[2].Hex()+": not sent") {
+		t.Fatalf("fundAccounts() error = %v, want the rejected and the unsent transfers", err)
+	}
+	if strings.Contains(err.Error(), defaultAccounts[0].Hex()) {
+		t.Errorf("fundAccounts() error = %v, want the transfer before the rejected one funded", err)
+	}
+
+	// No transfer is left pending behind the gap, the next call continues after the funded account
+	chain.rejectTo = common.Address{}
+	hashes, err := w.fundAccounts(ctx, []transfer{{to: defaultAccounts[2], value: big.NewInt(1)}})
+	if err != nil {
+		t.Fatalf("fundAccounts() error = %v", err)
+	}
+	if nonce := chain.sentTxs()[hashes[0]].Nonce(); nonce != 1 {
+		t.Errorf("next transfer nonce = %d, want 1", nonce)
+	}
+}
@@ -0,0 +1,198 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/rs/zerolog/log"
+	"github.com/transeptorlabs/betsy/contracts/entrypoint"
+	"github.com/transeptorlabs/betsy/contracts/factory"
+)
+
+// SmartAccountsConfig contains the settings of the SimpleAccount smart accounts owned by the dev accounts
+type SmartAccountsConfig struct {
+	Enabled bool
+	Deploy  bool     // deploy the accounts through the factory, otherwise only their counterfactual address is computed
+	Salt    *big.Int // salt passed to the factory
+	Balance *big.Int // balance sent to each smart account, zero skips the funding
+	Deposit *big.Int // EntryPoint deposit of each smart account, zero skips the deposit
+}
+
+// funded returns whether the smart accounts receive a balance or an EntryPoint deposit
+func (c SmartAccountsConfig) funded() bool {
+	return (c.Balance != nil && c.Balance.Sign() > 0) || (c.Deposit != nil && c.Deposit.Sign() > 0)
+}
+
+// SmartAccount contains the details of the SimpleAccount smart account of a dev account
+type SmartAccount struct {
+	Address  common.Address `json:"address"`
+	Owner    common.Address `json:"owner"`
+	Salt     *big.Int       `json:"salt"`
+	Deployed bool           `json:"deployed"`
+	Balance  *big.Int       `json:"balance"`
+	Deposit  *big.Int       `json:"deposit"` // EntryPoint deposit
+}
+
+// GetSmartAccounts returns a copy of the smart accounts of the dev accounts with their current balance, deposit and deployment state
+func (w *Wallet) GetSmartAccounts(ctx context.Context) ([]SmartAccount, error) {
+	w.smartAccountsMu.Lock()
+	defer w.smartAccountsMu.Unlock()
+
+	if len(w.smartAccounts) == 0 {
+		return nil, nil
+	}
+
+	entryPoint, err := entrypoint.NewEntryPointV7Caller(w.entryPointAddress, w.client)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, account := range w.smartAccounts {
+		balance, err := w.client.BalanceAt(ctx, account.Address, nil)
+		if err != nil {
+			return nil, err
+		}
+		deposit, err := entryPoint.BalanceOf(&bind.CallOpts{Context: ctx}, account.Address)
+		if err != nil {
+			return nil, err
+		}
+		deployed, err := checkContractExistence(ctx, account.Address, w.client)
+		if err != nil {
+			return nil, err
+		}
+
+		w.smartAccounts[i].Balance = balance
+		w.smartAccounts[i].Deposit = deposit
+		w.smartAccounts[i].Deployed = deployed
+	}
+
+	return slices.Clone(w.smartAccounts), nil
+}
+
+// createSmartAccounts computes the counterfactual address of the smart account of every dev account, deploys, funds and deposits for them as configured. The accounts funded on a resumed chain are only deployed
+func (w *Wallet) createSmartAccounts(ctx context.Context) error {
+	config := w.config.SmartAccounts
+	if !config.Enabled {
+		return nil
+	}
+	if w.simpleAccountFactoryAddress == (common.Address{}) {
+		return errors.New("smart accounts need the SimpleAccountFactory contract")
+	}
+
+	salt := config.Salt
+	if salt == nil {
+		salt = big.NewInt(0)
+	}
+
+	simpleAccountFactory, err := factory.NewSimpleAccountFactoryV7(w.simpleAccountFactoryAddress, w.client)
+	if err != nil {
+		return err
+	}
+
+	smartAccounts := make([]SmartAccount, len(w.devAccounts))
+	for i, owner := range w.devAccounts {
+		address, err := simpleAccountFactory.GetAddress(&bind.CallOpts{Context: ctx}, owner.Address, salt)
+		if err != nil {
+			return err
+		}
+
+		smartAccounts[i] = SmartAccount{Address: address, Owner: owner.Address, Salt: salt, Balance: big.NewInt(0), Deposit: big.NewInt(0)}
+	}
+	w.smartAccountsMu.Lock()
+	w.smartAccounts = smartAccounts
+	w.smartAccountsMu.Unlock()
+
+	if config.Deploy {
+		if err := w.deploySmartAccounts(ctx, simpleAccountFactory, smartAccounts); err != nil {
+			return err
+		}
+	}
+
+	transfers := make([]transfer, 0, len(smartAccounts))
+	for _, account := range smartAccounts {
+		if slices.Contains(w.config.FundedAccounts, account.Address) {
+			log.Debug().Msgf("Smart account %s was already funded", account.Address)
+			continue
+		}
+
+		if config.Deposit != nil && config.Deposit.Sign() > 0 {
+			result, err := w.DepositTo(ctx, account.Address, config.Deposit, fundingTimeout)
+			if err == nil && result.Pending {
+				err = fmt.Errorf("tx %s was not mined in %s", result.TxHash.Hex(), fundingTimeout)
+			}
+			if err != nil {
+				return fmt.Errorf("deposit of smart account %s: %w", account.Address, err)
+			}
+		}
+		if config.Balance != nil && config.Balance.Sign() > 0 {
+			transfers = append(transfers, transfer{to: account.Address, value: config.Balance})
+		}
+	}
+
+	_, err = w.fundAccounts(ctx, transfers)
+	return err
+}
+
+// deploySmartAccounts deploys the smart accounts without code through the factory from the coinbase account, so owners without balance get their account too. The nonces are assigned locally and the deployments are mined together
+func (w *Wallet) deploySmartAccounts(ctx context.Context, simpleAccountFactory *factory.SimpleAccountFactoryV7, smartAccounts []SmartAccount) error {
+	w.coinbaseMu.Lock()
+	defer w.coinbaseMu.Unlock()
+
+	auth, err := w.coinbaseTransactor(ctx)
+	if err != nil {
+		return err
+	}
+
+	nonce, err := w.client.PendingNonceAt(ctx, w.coinbaseAddress)
+	if err != nil {
+		return err
+	}
+
+	deployed := make([]SmartAccount, 0, len(smartAccounts))
+	txs := make([]*types.Transaction, 0, len(smartAccounts))
+	for _, account := range smartAccounts {
+		exists, err := checkContractExistence(ctx, account.Address, w.client)
+		if err != nil {
+			return err
+		}
+		if exists {
+			log.Debug().Msgf("Smart account %s is already deployed", account.Address)
+			continue
+		}
+
+		auth.Nonce = new(big.Int).SetUint64(nonce)
+		tx, err := simpleAccountFactory.CreateAccount(auth, account.Owner, account.Salt)
+		if err != nil {
+			return fmt.Errorf("deploying smart account %s: %w", account.Address, err)
+		}
+		nonce++
+
+		deployed = append(deployed, account)
+		txs = append(txs, tx)
+	}
+
+	errs := make([]error, len(txs))
+	var wg sync.WaitGroup
+	for i, tx := range txs {
+		wg.Add(1)
+		go func(i int, tx *types.Transaction) {
+			defer wg.Done()
+
+			if _, err := w.waitMined(ctx, tx); err != nil {
+				errs[i] = fmt.Errorf("deploying smart account %s: %w", deployed[i].Address, err)
+				return
+			}
+			log.Info().Msgf("Deployed smart account %s owned by %s (tx %s)", deployed[i].Address, deployed[i].Owner, tx.Hash().Hex())
+		}(i, tx)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
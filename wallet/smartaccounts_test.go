@@ -0,0 +1,181 @@
+package wallet
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/transeptorlabs/betsy/contracts/entrypoint"
+	"github.com/transeptorlabs/betsy/contracts/factory"
+)
+
+// counterfactualAddress is the smart account address of the fake factory, derived from its owner and salt like a CREATE2 address
+func counterfactualAddress(factoryAddress common.Address, owner common.Address, salt *big.Int) common.Address {
+	return crypto.CreateAddress2(factoryAddress, common.BigToHash(salt), crypto.Keccak256(owner.Bytes()))
+}
+
+// fakeFactory answers the getAddress calls of the SimpleAccountFactory on the chain
+func fakeFactory(t *testing.T, chain *fakeChain) {
+	t.Helper()
+
+	parsed, err := factory.SimpleAccountFactoryV7MetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("GetAbi() error = %v", err)
+	}
+	method := parsed.Methods["getAddress"]
+
+	chain.calls[hexutil.Encode(method.ID)] = func(to common.Address, input []byte) ([]byte, error) {
+		args, err := method.Inputs.Unpack(input)
+		if err != nil {
+			return nil, err
+		}
+
+		return method.Outputs.Pack(counterfactualAddress(to, args[0].(common.Address), args[1].(*big.Int)))
+	}
+}
+
+func TestCreateSmartAccounts(t *testing.T) {
+	chain := newFakeChain(big.NewInt(7))
+	fakeFactory(t, chain)
+	w := newFakeChainWallet(t, chain)
+
+	owners, err := GenerateAccountsFromSeed(DefaultSeedPhrase, 3)
+	if err != nil {
+		t.Fatalf("GenerateAccountsFromSeed() error = %v", err)
+	}
+	factoryAddress := common.HexToAddress("0x91E60e0613810449d098b0b5Ec8b51A0FE8c8985")
+	salt := big.NewInt(7)
+	expected := make([]common.Address, len(owners))
+	for i, owner := range owners {
+		expected[i] = counterfactualAddress(factoryAddress, owner.Address, salt)
+	}
+
+	w.devAccounts = owners
+	w.simpleAccountFactoryAddress = factoryAddress
+	w.config.SmartAccounts = SmartAccountsConfig{Enabled: true, Salt: salt, Balance: big.NewInt(5)}
+	w.config.FundedAccounts = []common.Address{expected[1]}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := w.createSmartAccounts(ctx); err != nil {
+		t.Fatalf("createSmartAccounts() error = %v", err)
+	}
+
+	// Every dev account owns the smart account the factory computes for it and the salt
+	if len(w.smartAccounts) != len(owners) {
+		t.Fatalf("created %d smart accounts, want %d", len(w.smartAccounts), len(owners))
+	}
+	for i, account := range w.smartAccounts {
+		if account.Address != expected[i] || account.Owner != owners[i].Address || account.Salt.Cmp(salt) != 0 {
+			t.Errorf("smart account %d = %+v, want %s owned by %s", i, account, expected[i], owners[i].Address)
+		}
+	}
+
+	// The smart account funded on a resumed chain is skipped
+	for i, want := range []int64{5, 0, 5} {
+		if balance := chain.balance(expected[i]); balance.Int64() != want {
+			t.Errorf("smart account %d balance = %s, want %d", i, balance, want)
+		}
+	}
+	if funded := w.GetFundedAccounts(); funded[len(funded)-1] != expected[2] {
+		t.Errorf("GetFundedAccounts() = %v, want the funded smart accounts", funded)
+	}
+}
+
+func TestCreateSmartAccountsDefaults(t *testing.T) {
+	chain := newFakeChain(big.NewInt(7))
+	fakeFactory(t, chain)
+	w := newFakeChainWallet(t, chain)
+
+	owners, err := GenerateAccountsFromSeed(DefaultSeedPhrase, 1)
+	if err != nil {
+		t.Fatalf("GenerateAccountsFromSeed() error = %v", err)
+	}
+	w.devAccounts = owners
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Disabled smart accounts are not created
+	if err := w.createSmartAccounts(ctx); err != nil || len(w.smartAccounts) != 0 {
+		t.Fatalf("createSmartAccounts() = %v, %v, want no smart accounts", w.smartAccounts, err)
+	}
+
+	w.config.SmartAccounts = SmartAccountsConfig{Enabled: true}
+	if err := w.createSmartAccounts(ctx); err == nil {
+		t.Fatal("createSmartAccounts() without the factory succeeded")
+	}
+
+	// Without salt nor balance the accounts get the zero salt and no funding
+	w.simpleAccountFactoryAddress = common.HexToAddress("0x91E60e0613810449d098b0b5Ec8b51A0FE8c8985")
+	if err := w.createSmartAccounts(ctx); err != nil {
+		t.Fatalf("createSmartAccounts() error = %v", err)
+	}
+	want := counterfactualAddress(w.simpleAccountFactoryAddress, owners[0].Address, big.NewInt(0))
+	if w.smartAccounts[0].Address != want || w.smartAccounts[0].Salt.Sign() != 0 {
+		t.Errorf("smart account = %+v, want %s with the zero salt", w.smartAccounts[0], want)
+	}
+	if len(chain.sentTxs()) != 0 {
+		t.Errorf("sent %d transactions, want no funding", len(chain.sentTxs()))
+	}
+	if funded := w.GetFundedAccounts(); len(funded) != 1 {
+		t.Errorf("GetFundedAccounts() = %v, want only the dev account", funded)
+	}
+}
+
+func TestGetSmartAccountsConcurrently(t *testing.T) {
+	chain := newFakeChain(big.NewInt(7))
+	w := newFakeChainWallet(t, chain)
+
+	parsed, err := entrypoint.EntryPointV7MetaData.GetAbi()
+	if err != nil {
+		t.Fatalf("GetAbi() error = %v", err)
+	}
+	method := parsed.Methods["balanceOf"]
+	chain.calls[hexutil.Encode(method.ID)] = func(to common.Address, input []byte) ([]byte, error) {
+		return method.Outputs.Pack(big.NewInt(3))
+	}
+
+	deployed := common.HexToAddress("0x1")
+	undeployed := common.HexToAddress("0x2")
+	chain.balances[deployed] = big.NewInt(5)
+	chain.code[deployed] = hexutil.Bytes{0x60}
+	w.entryPointAddress = common.HexToAddress("0x0000000071727De22E5E9d8BAf0edAc6f37da032")
+	w.smartAccounts = []SmartAccount{{Address: deployed}, {Address: undeployed}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The dashboard refreshes the smart accounts from concurrent requests
+	var wg sync.WaitGroup
+	results := make([][]SmartAccount, 4)
+	errs := make([]error, len(results))
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = w.GetSmartAccounts(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, accounts := range results {
+		if errs[i] != nil {
+			t.Fatalf("GetSmartAccounts() error = %v", errs[i])
+		}
+		if len(accounts) != 2 || !accounts[0].Deployed || accounts[0].Balance.Int64() != 5 || accounts[0].Deposit.Int64() != 3 || accounts[1].Deployed || accounts[1].Balance.Sign() != 0 {
+			t.Errorf("GetSmartAccounts() = %+v, want the balance, deposit and deployment state of the chain", accounts)
+		}
+	}
+
+	// The returned accounts are a copy the caller can change
+	results[0][0].Address = common.Address{}
+	if w.smartAccounts[0].Address != deployed {
+		t.Errorf("changing the result of GetSmartAccounts() changed the wallet accounts to %+v", w.smartAccounts)
+	}
+}
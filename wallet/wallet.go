@@ -7,6 +7,10 @@ import (
 	"fmt"
 	"math/big"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
@@ -30,32 +34,74 @@ import (
 const keyStorePath = "./wallet/tmp"
 const coinbaseKeyStorePath = "./wallet/tmp/coinbase/"
 const DefaultSeedPhrase = "test test test test test test test test test test test junk"
-const cfEntrypointV7Address = "0x5FbDB2315678afecb367f032d93F642f64180aa3"
+
+// DerivationPathIndex is replaced by the account index in a derivation path template
+const DerivationPathIndex = "{index}"
+
+// DefaultDerivationPath is the derivation path template of the dev accounts, the one of Hardhat, Anvil and MetaMask
+const DefaultDerivationPath = "m/44'/60'/0'/0/" + DerivationPathIndex
 
 // BundlerWalletDetails contains the details of the bundler wallet
 type BundlerWalletDetails struct {
 	Beneficiary       common.Address
 	Mnemonic          string
+	PrivateKeyHex     string
+	SignerAddress     common.Address
 	EntryPointAddress common.Address
+	ChainID           *big.Int
+}
+
+// bundlerAccount contains the beneficiary and signing account of a bundler instance
+type bundlerAccount struct {
+	beneficiary common.Address
+	mnemonic    string
+	signer      DevAccount
+}
+
+// Signer contains the funded account of the eth node used to fund the dev accounts, either a keystore file copied from the node or a private key
+type Signer struct {
+	KeystoreFile  string
+	PrivateKeyHex string
+}
+
+// Config contains the settings used to create the dev accounts and pre-deploy contracts
+type Config struct {
+	Mnemonic                   string
+	AccountCount               int
+	DerivationPath             string           // derivation path template of the dev accounts, DefaultDerivationPath when empty
+	AccountBalance             *big.Int         // balance sent to each funded account
+	AccountBalances            map[int]*big.Int // balances of dev accounts by index overriding AccountBalance, a zero balance skips the funding
+	DeploySimpleAccountFactory bool
+	DeployGlobalCounter        bool
+	Layout                     string // layout of the pre-deployed contracts, LayoutCanonical when empty
+	BundlerCount               int
+	SmartAccounts              SmartAccountsConfig
+	FundedAccounts             []common.Address      // accounts already funded on a resumed chain
+	PreDeployedContracts       *PreDeployedContracts // contracts already deployed on a resumed chain
 }
 
 // Wallet contains the details of the wallet for Betsy
 type Wallet struct {
+	config                      Config
 	client                      *ethclient.Client
 	coinbaseAddress             common.Address
-	bundlerBeneficiaryAddress   common.Address
+	bundlerAccounts             []bundlerAccount
 	devAccounts                 []DevAccount
+	smartAccounts               []SmartAccount
 	keyStore                    *keystore.KeyStore
 	password                    string
 	entryPointAddress           common.Address
 	simpleAccountFactoryAddress common.Address
 	globalCounterAddress        common.Address
 	chainID                     *big.Int
+	coinbaseMu                  sync.Mutex // serializes the transactions of the coinbase account
+	smartAccountsMu             sync.Mutex // guards the smart accounts refreshed by the dashboard requests
 }
 
 // DevAccount contains the details of the default development account
 type DevAccount struct {
 	Address       common.Address
+	Path          string
 	PublicKey     *ecdsa.PublicKey
 	PrivateKey    *ecdsa.PrivateKey
 	PrivateKeyHex string
@@ -70,13 +116,13 @@ type PreDeployedContracts struct {
 }
 
 // NewWallet creates a new wallet for Betsy
-func NewWallet(ctx context.Context, ethNodePort string, coinbaseKeystoreFile string) (*Wallet, error) {
+func NewWallet(ctx context.Context, ethNodePort string, signer Signer, config Config) (*Wallet, error) {
 	client, err := ethclient.Dial("http://localhost:" + ethNodePort)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to connect to Ethereum client: %v", err)
 	}
 
-	chainID, err := client.NetworkID(ctx)
+	chainID, err := client.ChainID(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -84,42 +130,46 @@ func NewWallet(ctx context.Context, ethNodePort string, coinbaseKeystoreFile str
 	// Define the path to the keystore
 	ks := keystore.NewKeyStore(keyStorePath, keystore.StandardScryptN, keystore.StandardScryptP)
 
-	// Load the coinbase account from the keystore
-	coinbaseFile := coinbaseKeyStorePath + coinbaseKeystoreFile
-	jsonBytes, err := os.ReadFile(coinbaseFile)
+	// Load the funded coinbase account into the keystore
+	password := ""
+	cbAccount, err := importSigner(ks, signer, password)
 	if err != nil {
 		return nil, err
 	}
 
-	password := ""
-	cbAccount, err := ks.Import(jsonBytes, password, password)
-	if err != nil {
-		return nil, err
+	if config.DerivationPath == "" {
+		config.DerivationPath = DefaultDerivationPath
 	}
 
-	err = utils.RemoveFile(coinbaseFile)
+	devAccounts, err := GenerateAccounts(config.Mnemonic, config.DerivationPath, config.AccountCount)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create a new account for the bundler beneficiary in the keystore
-	bAccount, err := createAccount(ks, password)
-	if err != nil {
-		return nil, err
+	// The bundlers derive their signer from the mnemonic with the default path, it is dev account 0 only with the default path
+	firstSigner := devAccounts[0]
+	if config.DerivationPath != DefaultDerivationPath {
+		signers, err := GenerateAccountsFromSeed(config.Mnemonic, 1)
+		if err != nil {
+			return nil, err
+		}
+		firstSigner = signers[0]
 	}
 
-	devAccounts, err := GenerateAccountsFromSeed(DefaultSeedPhrase, 10)
+	// Create the beneficiary and signing accounts of every bundler instance
+	bundlerAccounts, err := createBundlerAccounts(ks, password, config.Mnemonic, firstSigner, config.BundlerCount)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create the wallet
 	wallet := &Wallet{
+		config:                      config,
 		client:                      client,
 		keyStore:                    ks,
 		devAccounts:                 devAccounts,
 		coinbaseAddress:             cbAccount.Address,
-		bundlerBeneficiaryAddress:   bAccount,
+		bundlerAccounts:             bundlerAccounts,
 		password:                    password,
 		entryPointAddress:           common.HexToAddress(""),
 		simpleAccountFactoryAddress: common.HexToAddress(""),
@@ -127,9 +177,36 @@ func NewWallet(ctx context.Context, ethNodePort string, coinbaseKeystoreFile str
 		chainID:                     chainID,
 	}
 
-	// Fund the default development accounts
-	for _, account := range wallet.devAccounts {
-		err = wallet.fundAccountWithEth(ctx, account.Address)
+	// The funding account assigns its nonces locally, they would race with the transactions of a dev account or a bundler signer
+	if slices.Contains(wallet.GetFundedAccounts(), wallet.coinbaseAddress) {
+		return nil, fmt.Errorf("the funding account %s of the eth node is also a dev account or a bundler signer, use fewer accounts or another mnemonic", wallet.coinbaseAddress)
+	}
+
+	// Fund the default development accounts and the bundler signers, skipping the accounts funded on a resumed chain
+	transfers := make([]transfer, 0)
+	for _, address := range wallet.GetFundedAccounts() {
+		if slices.Contains(config.FundedAccounts, address) {
+			log.Debug().Msgf("Account %s was already funded", address)
+			continue
+		}
+
+		balance := wallet.FundingBalance(address)
+		if balance.Sign() == 0 {
+			log.Debug().Msgf("Account %s has a zero balance, skipping its funding", address)
+			continue
+		}
+
+		transfers = append(transfers, transfer{to: address, value: balance})
+	}
+
+	_, err = wallet.fundAccounts(ctx, transfers)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reuse the contracts deployed on a resumed chain
+	if config.PreDeployedContracts != nil {
+		err = wallet.reusePreDeployedContracts(ctx, *config.PreDeployedContracts)
 		if err != nil {
 			return nil, err
 		}
@@ -141,6 +218,12 @@ func NewWallet(ctx context.Context, ethNodePort string, coinbaseKeystoreFile str
 		return nil, err
 	}
 
+	// Create the smart accounts of the dev accounts through the SimpleAccountFactory
+	err = wallet.createSmartAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	return wallet, nil
 }
 
@@ -164,57 +247,63 @@ func (w *Wallet) GetDevAccounts(ctx context.Context) ([]DevAccount, error) {
 	return w.devAccounts, nil
 }
 
-// GetBundlerWalletDetails returns the details of the bundler wallet
-func (w *Wallet) GetBundlerWalletDetails() BundlerWalletDetails {
+// GetBundlerWalletDetails returns the details of the wallet of a bundler instance (0 based)
+func (w *Wallet) GetBundlerWalletDetails(instance int) BundlerWalletDetails {
+	account := w.bundlerAccounts[instance]
 	return BundlerWalletDetails{
-		Beneficiary:       w.bundlerBeneficiaryAddress,
-		Mnemonic:          DefaultSeedPhrase,
+		Beneficiary:       account.beneficiary,
+		Mnemonic:          account.mnemonic,
+		PrivateKeyHex:     account.signer.PrivateKeyHex,
+		SignerAddress:     account.signer.Address,
 		EntryPointAddress: w.entryPointAddress,
+		ChainID:           w.chainID,
 	}
 }
 
-// fundAccountWithEth send 4337 ETH to the account using the coinbase account
-func (w *Wallet) fundAccountWithEth(ctx context.Context, toAddress common.Address) error {
-	// Unlock the account (in the context of the keystore is necessary because the private key is encrypted for security reasons)
-	account, err := w.keyStore.Find(accounts.Account{Address: w.coinbaseAddress})
-	if err != nil {
-		return err
+// GetFundedAccounts returns the accounts funded by the wallet, the dev accounts, the signers of the bundler instances that are not dev accounts (the first one signs with dev account 0 with the default derivation path) and the smart accounts receiving a balance or a deposit
+func (w *Wallet) GetFundedAccounts() []common.Address {
+	addresses := make([]common.Address, 0, len(w.devAccounts)+len(w.bundlerAccounts)+len(w.smartAccounts))
+	for _, account := range w.devAccounts {
+		addresses = append(addresses, account.Address)
 	}
-
-	err = w.keyStore.Unlock(account, w.password)
-	if err != nil {
-		return err
+	for _, account := range w.bundlerAccounts {
+		if !slices.Contains(addresses, account.signer.Address) {
+			addresses = append(addresses, account.signer.Address)
+		}
 	}
-
-	nonce, err := w.client.PendingNonceAt(ctx, w.coinbaseAddress)
-	if err != nil {
-		return err
+	if w.config.SmartAccounts.funded() {
+		w.smartAccountsMu.Lock()
+		for _, account := range w.smartAccounts {
+			addresses = append(addresses, account.Address)
+		}
+		w.smartAccountsMu.Unlock()
 	}
 
-	value := new(big.Int)
-	value.SetString("4337000000000000000000", 10) // 4337 ETH in Wei
-	gasLimit := uint64(21000)                     // The gas limit for a standard ETH transfer is 21000 units.
-	gasPrice, err := w.client.SuggestGasPrice(ctx)
-	if err != nil {
-		return err
-	}
+	return addresses
+}
 
-	tx := types.NewTransaction(nonce, toAddress, value, gasLimit, gasPrice, nil)
+// Mnemonic returns the mnemonic of the dev accounts
+func (w *Wallet) Mnemonic() string {
+	return w.config.Mnemonic
+}
 
-	// Sign the transaction and send it
-	signedTx, err := w.keyStore.SignTx(account, tx, w.chainID)
-	if err != nil {
-		return err
-	}
+// DerivationPath returns the derivation path template of the dev accounts
+func (w *Wallet) DerivationPath() string {
+	return w.config.DerivationPath
+}
 
-	err = w.client.SendTransaction(ctx, signedTx)
-	if err != nil {
-		return err
+// FundingBalance returns the balance sent to a funded account, the configured balance of a dev account or the default account balance
+func (w *Wallet) FundingBalance(address common.Address) *big.Int {
+	for i, account := range w.devAccounts {
+		if account.Address != address {
+			continue
+		}
+		if balance, ok := w.config.AccountBalances[i]; ok {
+			return balance
+		}
 	}
 
-	log.Info().Msgf("tx sent to fund (%s): %s", toAddress, signedTx.Hash().Hex())
-
-	return nil
+	return w.config.AccountBalance
 }
 
 // GetPreDeployedContracts returns the pre-deployed contracts
@@ -226,77 +315,210 @@ func (w *Wallet) GetPreDeployedContracts() PreDeployedContracts {
 	}
 }
 
-// deployPreCompiledContracts deploys the pre-compiled contracts
+// reusePreDeployedContracts keeps the contracts of a resumed chain that still have code, the missing ones are deployed again
+func (w *Wallet) reusePreDeployedContracts(ctx context.Context, contracts PreDeployedContracts) error {
+	reused := []struct {
+		name    string
+		address common.Address
+		target  *common.Address
+	}{
+		{"EntryPointV7", contracts.EntryPointAddress, &w.entryPointAddress},
+		{"SimpleAccountFactory", contracts.SimpleAccountFactoryAddress, &w.simpleAccountFactoryAddress},
+		{"GlobalCounter", contracts.GlobalCounterAddress, &w.globalCounterAddress},
+	}
+
+	for _, item := range reused {
+		if item.address == (common.Address{}) {
+			continue
+		}
+
+		exists, err := checkContractExistence(ctx, item.address, w.client)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			log.Warn().Msgf("%s contract is missing from the resumed chain at %s, deploying it again", item.name, item.address)
+			continue
+		}
+
+		log.Info().Msgf("Reusing the %s contract deployed at %s", item.name, item.address)
+		*item.target = item.address
+	}
+
+	// The other contracts are bound to the EntryPoint, they are deployed again with a new one
+	if w.entryPointAddress == (common.Address{}) {
+		w.simpleAccountFactoryAddress = common.Address{}
+	}
+
+	return nil
+}
+
+// deployPreCompiledContracts deploys the pre-compiled contracts that are not deployed yet with the configured layout
 func (w *Wallet) deployPreCompiledContracts(ctx context.Context) error {
-	log.Info().Msg("Deploying the 4337 EntryPointV7 contract...")
+	if w.config.Layout == LayoutLegacy {
+		return w.deployLegacyContracts(ctx)
+	}
+
+	return w.deployCanonicalContracts(ctx)
+}
+
+// deployLegacyContracts deploys the pre-compiled contracts that are not deployed yet with CREATE from dev account 0
+func (w *Wallet) deployLegacyContracts(ctx context.Context) error {
 	auth, err := bind.NewKeyedTransactorWithChainID(w.devAccounts[0].PrivateKey, w.chainID)
 	if err != nil {
 		return err
 	}
 
-	entryPointAddress, tx1, _, err := entrypoint.DeployEntryPointV7(auth, w.client)
-	time.Sleep(300 * time.Millisecond) // Allow it to be processed by the local node
+	if w.entryPointAddress == (common.Address{}) {
+		log.Info().Msg("Deploying the 4337 EntryPointV7 contract...")
+		entryPointAddress, tx1, _, err := entrypoint.DeployEntryPointV7(auth, w.client)
+		time.Sleep(300 * time.Millisecond) // Allow it to be processed by the local node
 
-	receipt, err := bind.WaitMined(ctx, w.client, tx1)
-	if err != nil {
-		return err
-	} else if receipt.Status == types.ReceiptStatusFailed {
-		return err
+		receipt, err := bind.WaitMined(ctx, w.client, tx1)
+		if err != nil {
+			return err
+		} else if receipt.Status == types.ReceiptStatusFailed {
+			return err
+		}
+
+		exists, err := checkContractExistence(ctx, entryPointAddress, w.client)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return err
+		}
+
+		w.entryPointAddress = entryPointAddress
 	}
 
-	exists, err := checkContractExistence(ctx, entryPointAddress, w.client)
-	if err != nil {
-		return err
+	if w.config.DeploySimpleAccountFactory && w.simpleAccountFactoryAddress == (common.Address{}) {
+		log.Info().Msg("Deploying the 4337 SimpleAccountFactory contract...")
+		simpleAFAddress, tx2, _, err := factory.DeploySimpleAccountFactoryV7(auth, w.client, w.entryPointAddress)
+		time.Sleep(300 * time.Millisecond) // Allow it to be processed by the local node
+
+		receipt2, err := bind.WaitMined(ctx, w.client, tx2)
+		if err != nil {
+			return err
+		} else if receipt2.Status == types.ReceiptStatusFailed {
+			return err
+		}
+
+		exists2, err := checkContractExistence(ctx, simpleAFAddress, w.client)
+		if err != nil {
+			return err
+		}
+		if !exists2 {
+			return err
+		}
+
+		w.simpleAccountFactoryAddress = simpleAFAddress
 	}
-	if !exists {
-		return err
+
+	if w.config.DeployGlobalCounter && w.globalCounterAddress == (common.Address{}) {
+		log.Info().Msg("Deploying the GlobalCounter contract...")
+		globalCounterAddress, tx3, _, err := examples.DeployGlobalCounter(auth, w.client)
+		time.Sleep(300 * time.Millisecond) // Allow it to be processed by the local node
+
+		receipt3, err := bind.WaitMined(ctx, w.client, tx3)
+		if err != nil {
+			return err
+		} else if receipt3.Status == types.ReceiptStatusFailed {
+			return err
+		}
+
+		exists3, err := checkContractExistence(ctx, globalCounterAddress, w.client)
+		if err != nil {
+			return err
+		}
+		if !exists3 {
+			return err
+		}
+
+		w.globalCounterAddress = globalCounterAddress
 	}
 
-	w.entryPointAddress = entryPointAddress
+	return nil
+}
 
-	log.Info().Msg("Deploying the 4337 SimpleAccountFactory contract...")
-	simpleAFAddress, tx2, _, err := factory.DeploySimpleAccountFactoryV7(auth, w.client, entryPointAddress)
-	time.Sleep(300 * time.Millisecond) // Allow it to be processed by the local node
+// importSigner imports the funded signer of the eth node into the keystore
+func importSigner(ks *keystore.KeyStore, signer Signer, password string) (accounts.Account, error) {
+	if signer.PrivateKeyHex != "" {
+		privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(signer.PrivateKeyHex, "0x"))
+		if err != nil {
+			return accounts.Account{}, err
+		}
 
-	receipt2, err := bind.WaitMined(ctx, w.client, tx2)
-	if err != nil {
-		return err
-	} else if receipt2.Status == types.ReceiptStatusFailed {
-		return err
+		// The keystore can already contain the key when several dev accounts share it
+		address := crypto.PubkeyToAddress(privateKey.PublicKey)
+		if ks.HasAddress(address) {
+			return ks.Find(accounts.Account{Address: address})
+		}
+
+		return ks.ImportECDSA(privateKey, password)
 	}
 
-	exists2, err := checkContractExistence(ctx, simpleAFAddress, w.client)
+	coinbaseFile := coinbaseKeyStorePath + signer.KeystoreFile
+	jsonBytes, err := os.ReadFile(coinbaseFile)
 	if err != nil {
-		return err
+		return accounts.Account{}, err
 	}
-	if !exists2 {
-		return err
-	}
-
-	w.simpleAccountFactoryAddress = simpleAFAddress
 
-	log.Info().Msg("Deploying the GlobalCounter contract...")
-	globalCounterAddress, tx3, _, err := examples.DeployGlobalCounter(auth, w.client)
-	time.Sleep(300 * time.Millisecond) // Allow it to be processed by the local node
-
-	receipt3, err := bind.WaitMined(ctx, w.client, tx3)
+	cbAccount, err := ks.Import(jsonBytes, password, password)
 	if err != nil {
-		return err
-	} else if receipt3.Status == types.ReceiptStatusFailed {
-		return err
+		return accounts.Account{}, err
 	}
 
-	exists3, err := checkContractExistence(ctx, globalCounterAddress, w.client)
+	err = utils.RemoveFile(coinbaseFile)
 	if err != nil {
-		return err
+		return accounts.Account{}, err
 	}
-	if !exists3 {
-		return err
+
+	return cbAccount, nil
+}
+
+// createBundlerAccounts creates a beneficiary account for each bundler instance, the first instance signs with the first dev account and the others with account 0 of a mnemonic derived from the dev mnemonic so competing bundlers never share nonces
+func createBundlerAccounts(ks *keystore.KeyStore, password string, mnemonic string, firstSigner DevAccount, count int) ([]bundlerAccount, error) {
+	if count < 1 {
+		count = 1
 	}
 
-	w.globalCounterAddress = globalCounterAddress
+	bundlerAccounts := make([]bundlerAccount, 0, count)
+	for instance := 0; instance < count; instance++ {
+		beneficiary, err := createAccount(ks, password)
+		if err != nil {
+			return nil, err
+		}
 
-	return nil
+		account := bundlerAccount{
+			beneficiary: beneficiary,
+			mnemonic:    mnemonic,
+			signer:      firstSigner,
+		}
+
+		if instance > 0 {
+			account.mnemonic, err = deriveBundlerMnemonic(mnemonic, instance)
+			if err != nil {
+				return nil, err
+			}
+
+			signers, err := GenerateAccountsFromSeed(account.mnemonic, 1)
+			if err != nil {
+				return nil, err
+			}
+			account.signer = signers[0]
+		}
+
+		bundlerAccounts = append(bundlerAccounts, account)
+	}
+
+	return bundlerAccounts, nil
+}
+
+// deriveBundlerMnemonic deterministically derives the mnemonic of a bundler instance from the dev mnemonic
+func deriveBundlerMnemonic(mnemonic string, instance int) (string, error) {
+	entropy := crypto.Keccak256([]byte(fmt.Sprintf("%s/bundler/%d", mnemonic, instance)))[:16]
+	return bip39.NewMnemonic(entropy)
 }
 
 // GetAccount generates a new key and stores it into the key directory, encrypting it with the passphrase and return the address
@@ -309,8 +531,13 @@ func createAccount(ks *keystore.KeyStore, password string) (common.Address, erro
 	return account.Address, nil
 }
 
-// generateAccountsFromSeed generates a number of accounts from a seed phrase
+// GenerateAccountsFromSeed generates a number of accounts from a seed phrase with the default derivation path
 func GenerateAccountsFromSeed(seedPhrase string, numAccounts int) ([]DevAccount, error) {
+	return GenerateAccounts(seedPhrase, DefaultDerivationPath, numAccounts)
+}
+
+// GenerateAccounts generates a number of accounts from a seed phrase, the index of each account replaces {index} in the derivation path template
+func GenerateAccounts(seedPhrase string, pathTemplate string, numAccounts int) ([]DevAccount, error) {
 	var accounts []DevAccount
 
 	// Generate the seed from the mnemonic and create a master key from the seed
@@ -320,28 +547,19 @@ func GenerateAccountsFromSeed(seedPhrase string, numAccounts int) ([]DevAccount,
 		return nil, err
 	}
 
-	// Derive the Ethereum account keys (follows the path m/44'/60'/0'/0/x, where x is the account index)
-	// This path is used to generate hierarchical deterministic (HD) wallets.
+	// Derive the Ethereum account keys of the hierarchical deterministic (HD) wallet, m/44'/60'/0'/0/x by default where x is the account index
 	for i := 0; i < numAccounts; i++ {
-		purpose, err := masterKey.NewChildKey(bip32.FirstHardenedChild + 44)
-		if err != nil {
-			return nil, err
-		}
-		coinType, err := purpose.NewChildKey(bip32.FirstHardenedChild + 60)
+		path, err := AccountPath(pathTemplate, i)
 		if err != nil {
 			return nil, err
 		}
-		account, err := coinType.NewChildKey(bip32.FirstHardenedChild + 0)
-		if err != nil {
-			return nil, err
-		}
-		change, err := account.NewChildKey(0)
-		if err != nil {
-			return nil, err
-		}
-		key, err := change.NewChildKey(uint32(i))
-		if err != nil {
-			return nil, err
+
+		key := masterKey
+		for _, component := range path {
+			key, err = key.NewChildKey(component)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		// Generate the private key, public key and Ethereum address
@@ -354,6 +572,7 @@ func GenerateAccountsFromSeed(seedPhrase string, numAccounts int) ([]DevAccount,
 		address := crypto.PubkeyToAddress(*publicKey)
 		devAccount := DevAccount{
 			Address:       address,
+			Path:          path.String(),
 			PublicKey:     publicKey,
 			PrivateKey:    privateKey,
 			PrivateKeyHex: "0x" + hex.EncodeToString(crypto.FromECDSA(privateKey)),
@@ -366,6 +585,15 @@ func GenerateAccountsFromSeed(seedPhrase string, numAccounts int) ([]DevAccount,
 	return accounts, nil
 }
 
+// AccountPath returns the derivation path of an account, the index replaces {index} in the path template
+func AccountPath(pathTemplate string, index int) (accounts.DerivationPath, error) {
+	if strings.Count(pathTemplate, DerivationPathIndex) != 1 {
+		return nil, fmt.Errorf("derivation path %s must contain %s once", pathTemplate, DerivationPathIndex)
+	}
+
+	return accounts.ParseDerivationPath(strings.Replace(pathTemplate, DerivationPathIndex, strconv.Itoa(index), 1))
+}
+
 // checkContractExistence checks if a contract exists at the given address
 func checkContractExistence(ctx context.Context, contractAddress common.Address, client *ethclient.Client) (bool, error) {
 	stopRequestCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
@@ -380,11 +608,21 @@ func checkContractExistence(ctx context.Context, contractAddress common.Address,
 	return len(code) > 0, nil
 }
 
+// GetChainID returns the chain ID of the eth node
+func (w *Wallet) GetChainID() *big.Int {
+	return w.chainID
+}
+
 // GetEthClient returns the Ethereum client
 func (w *Wallet) GetEthClient() *ethclient.Client {
 	return w.client
 }
 
+// DevAccountLock returns the lock serializing the transactions of the coinbase, the dev account of the eth node
+func (w *Wallet) DevAccountLock() sync.Locker {
+	return &w.coinbaseMu
+}
+
 // GetGethClient returns the Geth client
 func (w *Wallet) GetGethClient() *gethclient.Client {
 	return gethclient.New(w.client.Client())
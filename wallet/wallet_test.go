@@ -0,0 +1,110 @@
+package wallet
+
+import (
+	"math/big"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Accounts of the default mnemonic with the default derivation path, the ones of Hardhat and Anvil
+var defaultAccounts = []common.Address{
+	common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"),
+	common.HexToAddress("0x70997970C51812dc3A010C7d01b50e0d17dc79C8"),
+	common.HexToAddress("0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC"),
+}
+
+func TestGenerateAccountsDefaultPath(t *testing.T) {
+	accounts, err := GenerateAccountsFromSeed(DefaultSeedPhrase, len(defaultAccounts))
+	if err != nil {
+		t.Fatalf("GenerateAccountsFromSeed() error = %v", err)
+	}
+	if len(accounts) != len(defaultAccounts) {
+		t.Fatalf("GenerateAccountsFromSeed() returned %d accounts, want %d", len(accounts), len(defaultAccounts))
+	}
+
+	for i, account := range accounts {
+		if account.Address != defaultAccounts[i] {
+			t.Errorf("account %d = %s, want %s", i, account.Address, defaultAccounts[i])
+		}
+	}
+	if accounts[1].Path != "m/44'/60'/0'/0/1" {
+		t.Errorf("account 1 path = %s, want m/44'/60'/0'/0/1", accounts[1].Path)
+	}
+	if accounts[0].PrivateKeyHex != "0xac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80" {
+		t.Errorf("account 0 private key = %s, want the Anvil one", accounts[0].PrivateKeyHex)
+	}
+
+	none, err := GenerateAccountsFromSeed(DefaultSeedPhrase, 0)
+	if err != nil || len(none) != 0 {
+		t.Errorf("GenerateAccountsFromSeed(0) = %v, %v, want no accounts", none, err)
+	}
+}
+
+func TestGenerateAccountsCustomPath(t *testing.T) {
+	// The account index of the Ledger Live layout, account 0 has the default path
+	accounts, err := GenerateAccounts(DefaultSeedPhrase, "m/44'/60'/{index}'/0/0", 2)
+	if err != nil {
+		t.Fatalf("GenerateAccounts() error = %v", err)
+	}
+
+	if accounts[0].Address != defaultAccounts[0] {
+		t.Errorf("account 0 = %s, want %s", accounts[0].Address, defaultAccounts[0])
+	}
+	if accounts[1].Path != "m/44'/60'/1'/0/0" {
+		t.Errorf("account 1 path = %s, want m/44'/60'/1'/0/0", accounts[1].Path)
+	}
+	if slices.Contains(defaultAccounts, accounts[1].Address) {
+		t.Errorf("account 1 = %s, want an account outside of the default path", accounts[1].Address)
+	}
+}
+
+func TestAccountPathTemplate(t *testing.T) {
+	path, err := AccountPath(DefaultDerivationPath, 7)
+	if err != nil {
+		t.Fatalf("AccountPath() error = %v", err)
+	}
+	if path.String() != "m/44'/60'/0'/0/7" {
+		t.Errorf("AccountPath() = %s, want m/44'/60'/0'/0/7", path)
+	}
+
+	for _, template := range []string{"m/44'/60'/0'/0/0", "m/44'/60'/{index}'/0/{index}", "m/44'/x'/0'/0/{index}"} {
+		if _, err := AccountPath(template, 0); err == nil {
+			t.Errorf("AccountPath(%s) succeeded", template)
+		}
+	}
+
+	if _, err := GenerateAccounts(DefaultSeedPhrase, "m/44'/60'/0'/0/0", 1); err == nil {
+		t.Error("GenerateAccounts() without the index in the path succeeded")
+	}
+}
+
+func TestFundingBalance(t *testing.T) {
+	accounts, err := GenerateAccountsFromSeed(DefaultSeedPhrase, 2)
+	if err != nil {
+		t.Fatalf("GenerateAccountsFromSeed() error = %v", err)
+	}
+
+	w := &Wallet{
+		devAccounts: accounts,
+		config: Config{
+			AccountBalance:  big.NewInt(100),
+			AccountBalances: map[int]*big.Int{1: big.NewInt(5)},
+		},
+	}
+
+	tests := []struct {
+		address common.Address
+		want    int64
+	}{
+		{accounts[0].Address, 100},
+		{accounts[1].Address, 5},
+		{common.HexToAddress("0x0000000000000000000000000000000000000001"), 100},
+	}
+	for _, test := range tests {
+		if got := w.FundingBalance(test.address); got.Int64() != test.want {
+			t.Errorf("FundingBalance(%s) = %s, want %d", test.address, got, test.want)
+		}
+	}
+}